@@ -3,6 +3,8 @@ package config
 type Config struct {
 	Database struct {
 		Host string `yaml:"host"`
+		// 启动时自动执行嵌入的数据库迁移
+		AutoMigrate bool `yaml:"auto_migrate" env:"DATABASE_AUTO_MIGRATE" env-default:"true"`
 	} `yaml:"database"`
 	Server struct {
 		Addr         string   `yaml:"addr" env-default:""`
@@ -28,6 +30,31 @@ type Config struct {
 			TTL int `yaml:"ttl" env-default:"900"`
 		} `yaml:"temp_password"`
 	} `yaml:"passkey"`
+	Timelog struct {
+		// reject|warn|off，对重叠的时间日志拒绝写入、仅记录警告或不检查
+		OverlapPolicy string `yaml:"overlap_policy" env:"TIMELOG_OVERLAP_POLICY" env-default:"reject"`
+		// IANA 时区名，按日期划分的查询与统计都以该时区为准
+		Timezone string `yaml:"timezone" env:"TIMELOG_TIMEZONE" env-default:"Asia/Singapore"`
+		// 每天的开始时间 HH:MM，早于该时间的记录归入前一天
+		DayStartsAt string `yaml:"day_starts_at" env:"TIMELOG_DAY_STARTS_AT" env-default:"00:00"`
+	} `yaml:"timelog"`
+	Category struct {
+		// 分类树最多的层数（根分类为第 1 层），例如 领域/项目/组件/活动 需要 4 层
+		MaxDepth int `yaml:"max_depth" env:"CATEGORY_MAX_DEPTH" env-default:"3"`
+	} `yaml:"category"`
+	Report struct {
+		// 是否在每天 daily_at（本地时间 HH:MM）生成前一天的日报
+		Enabled bool   `yaml:"enabled" env:"REPORT_ENABLED" env-default:"true"`
+		DailyAt string `yaml:"daily_at" env:"REPORT_DAILY_AT" env-default:"04:00"`
+	} `yaml:"report"`
+	Backup struct {
+		// 是否在每天 daily_at（本地时间 HH:MM）自动备份数据库
+		Enabled bool   `yaml:"enabled" env:"BACKUP_ENABLED" env-default:"true"`
+		Dir     string `yaml:"dir" env:"BACKUP_DIR" env-default:"backups"`
+		DailyAt string `yaml:"daily_at" env:"BACKUP_DAILY_AT" env-default:"03:30"`
+		// 保留最近的快照数量，0 表示不清理
+		Retention int `yaml:"retention" env:"BACKUP_RETENTION" env-default:"14"`
+	} `yaml:"backup"`
 	MCP struct {
 		Enabled    bool   `yaml:"enabled" env:"MCP_ENABLED" env-default:"false"`
 		Level      string `yaml:"level" env:"MCP_LEVEL" env-default:"debug"`
@@ -35,6 +62,10 @@ type Config struct {
 		Transport  string `yaml:"transport" env:"MCP_TRANSPORT" env-default:"stdio"`
 		ListenAddr string `yaml:"listen_addr" env:"MCP_LISTEN_ADDR" env-default:":8080"`
 		Token      string `yaml:"token" env:"MCP_TOKEN" env-default:""`
+		// 是否注册会修改数据的工具（创建/编辑时间日志、计时、任务与约束），关闭时只提供只读工具
+		AllowWrites bool `yaml:"allow_writes" env:"MCP_ALLOW_WRITES" env-default:"false"`
+		// 是否在主服务的 /mcp 路径上提供 streamable HTTP MCP 端点，使用登录会话或个人访问令牌认证
+		Mount bool `yaml:"mount" env:"MCP_MOUNT" env-default:"false"`
 	} `yaml:"mcp"`
 	Test struct {
 		Flush bool `yaml:"flush" env-default:"false"`
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"embed"
+	"flag"
 	"fmt"
 	"os"
 	"os/signal"
@@ -21,11 +22,44 @@ import (
 var staticFiles embed.FS
 
 func main() {
+	migrateOnly := flag.Bool("migrate-only", false, "apply database migrations and exit")
+	restore := flag.String("restore", "", "verify a backup (name in backup.dir or file path), swap it in as the database and exit; stop the server first")
+	flag.Parse()
+
 	cfg := config.GetConfig("config.yml")
 	logger := log.SetZapLogger(*cfg)
 
+	if *migrateOnly {
+		cfg.Database.AutoMigrate = true
+	}
+
 	service.InitService(logger, cfg)
+
+	// 恢复必须在打开数据库之前完成
+	if *restore != "" {
+		kept, err := service.RestoreBackup(*restore)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Restore failed: %v\n", err)
+			os.Exit(1)
+		}
+		if kept != "" {
+			fmt.Printf("Previous database kept at %s\n", kept)
+		}
+		fmt.Printf("Database %s restored from %s\n", cfg.Database.Host, *restore)
+		return
+	}
+
 	model.InitDao(cfg, logger)
+
+	if *migrateOnly {
+		version, _, err := model.SchemaVersion(model.GetDao().RawDB)
+		if err != nil {
+			panic("Failed to read schema version: " + err.Error())
+		}
+		fmt.Printf("Database is at migration version %d\n", version)
+		return
+	}
+
 	if err := service.InitWebAuthn(); err != nil {
 		panic("Failed to initialize WebAuthn: " + err.Error())
 	}
@@ -43,6 +77,9 @@ func main() {
 	wg.Add(1)
 	go router.LaunchServer(ctx, &wg, r, cfg)
 
+	wg.Add(1)
+	go service.LaunchScheduler(ctx, &wg)
+
 	byebye := make(chan os.Signal, 1) // Listen for system signal，such as SIGINT, SIGTERM
 	signal.Notify(byebye, syscall.SIGINT, syscall.SIGTERM)
 
@@ -55,6 +92,7 @@ func main() {
 	logger.Info("Received signal: %s, shutting down...", someonesaidbye)
 
 	cancel() // tell other goroutines to stop
+	wg.Wait()
 	logger.Info("Program exited gracefully.")
 	fmt.Println("Program exited gracefully.")
 }
@@ -0,0 +1,286 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/model/gen"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// formatMCPResponse returns data as structured content and also wraps it in the
+// standard text format to prevent LLM hallucinations for clients that only read text
+func formatMCPResponse[T any](summaryText string, data *T) (*mcp.CallToolResult, *T, error) {
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+	}
+	// Add summary to the text rendering
+	var dataMap map[string]interface{}
+	if err := json.Unmarshal(jsonBytes, &dataMap); err != nil {
+		return nil, nil, fmt.Errorf("data must be a JSON object: %w", err)
+	}
+	dataMap["_summary"] = summaryText
+
+	responseText, err := strictJSON(dataMap)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{
+			Text: responseText,
+		}},
+	}, data, nil
+}
+
+// weekRangeOf returns the Monday and Sunday of the week containing date
+func weekRangeOf(date time.Time) (time.Time, time.Time) {
+	// 以周一为一周的开始
+	daysSinceMonday := (int(date.Weekday()) + 6) % 7
+	monday := date.AddDate(0, 0, -daysSinceMonday)
+	return monday, monday.AddDate(0, 0, 6)
+}
+
+// strictJSON renders data inside the <STRICT_JSON> envelope shared by tools and prompts
+func strictJSON(data interface{}) (string, error) {
+	jsonBytes, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal response: %w", err)
+	}
+	return fmt.Sprintf("<STRICT_JSON>\n%s\n</STRICT_JSON>", string(jsonBytes)), nil
+}
+
+// Tool handlers with correct MCP signature
+type DateInfoParams struct{}
+
+func (s *TimelogMCPServer) GetDateInfo(ctx context.Context, req *mcp.CallToolRequest, args DateInfoParams) (*mcp.CallToolResult, *DateInfoResult, error) {
+	loc := model.GetLocation()
+	now := time.Now().In(loc)
+	// 逻辑日期：早于 day_starts_at 的时间仍属于前一天
+	date := model.LocalDateOf(now)
+	today := date.Format("2006-01-02")
+	yesterday := date.AddDate(0, 0, -1).Format("2006-01-02")
+	weekday := date.Weekday()
+	monday, sunday := weekRangeOf(date)
+	weekRange := []string{
+		monday.Format("2006-01-02"),
+		sunday.Format("2006-01-02"),
+	}
+
+	response := &DateInfoResult{
+		Timezone:    fmt.Sprintf("%s (%s, UTC%s)", loc.String(), now.Format("MST"), now.Format("-07:00")),
+		DayStartsAt: model.GetDayStartsAt(),
+		Now:         now.Format("2006-01-02 15:04:05"),
+		Today:       today,
+		Yesterday:   yesterday,
+		Weekday:     weekday.String(),
+		WeekRange:   weekRange,
+	}
+	summaryText := "当前日期和时间信息，包括今天、昨天和本周日期范围"
+	return formatMCPResponse(summaryText, response)
+}
+
+func (s *TimelogMCPServer) GetTimeLogsByDateRange(ctx context.Context, req *mcp.CallToolRequest, args DateRangeParams) (*mcp.CallToolResult, *TimeLogsResult, error) {
+	startDateStr := args.StartDate
+	endDateStr := args.EndDate
+
+	// 使用 model 层的函数，自动处理时区转换
+	timeLogs, err := model.ListTimeLogsByLocalDateRange(s.db, startDateStr, endDateStr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get time logs by date range: %w", err)
+	}
+	paths, err := s.categoryPaths()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	result := make([]TimeLogEntry, 0, len(timeLogs))
+	totalDuration := time.Duration(0)
+
+	for i := range timeLogs {
+		tl := &timeLogs[i]
+		entry := timeLogEntry(tl)
+		entry.Category = paths[tl.CategoryID]
+
+		if tl.EndTime != nil {
+			duration := tl.EndTime.Sub(tl.StartTime)
+			totalDuration += duration
+			hours := int(duration.Hours())
+			minutes := int(duration.Minutes()) % 60
+			entry.Duration = fmt.Sprintf("%dh %dm", hours, minutes)
+		} else {
+			entry.Duration = "ongoing"
+		}
+
+		result = append(result, entry)
+	}
+
+	totalHours := int(totalDuration.Hours())
+	totalMinutes := int(totalDuration.Minutes()) % 60
+
+	response := &TimeLogsResult{
+		TimeLogs:      result,
+		Count:         len(result),
+		DateRange:     fmt.Sprintf("%s to %s", startDateStr, endDateStr[:10]),
+		TotalDuration: fmt.Sprintf("%dh %dm", totalHours, totalMinutes),
+	}
+
+	summaryText := fmt.Sprintf("Found %d time logs from %s to %s, total duration: %dh %dm", len(result), startDateStr, endDateStr[:10], totalHours, totalMinutes)
+	return formatMCPResponse(summaryText, response)
+}
+
+func (s *TimelogMCPServer) GetTasksByStatus(ctx context.Context, req *mcp.CallToolRequest, args TaskStatusParams) (*mcp.CallToolResult, *TasksResult, error) {
+	statusStr := args.Status
+
+	// Include all tasks (suspended and completed) to filter by status in application code
+	tasks, err := model.GetAllTasks(s.db, true, true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get tasks: %w", err)
+	}
+
+	result := make([]TaskEntry, 0, len(tasks))
+	for i := range tasks {
+		task := &tasks[i]
+		isCompleted := task.IsCompleted != nil && *task.IsCompleted
+
+		// Filter by status
+		if statusStr == "completed" && !isCompleted {
+			continue
+		}
+		if statusStr == "pending" && isCompleted {
+			continue
+		}
+
+		entry := taskEntry(task)
+		if task.CategoryID > 0 {
+			if cat, err := model.GetCategoryByID(s.db, int32(task.CategoryID)); err == nil && cat != nil {
+				entry.Category = cat.Name
+				if cat.Color != nil {
+					entry.CategoryColor = *cat.Color
+				}
+			}
+		}
+		if task.CreatedAt != nil {
+			entry.CreatedAt = task.CreatedAt.In(model.GetLocation()).Format("2006-01-02 15:04:05")
+		}
+
+		result = append(result, entry)
+	}
+
+	response := &TasksResult{
+		Tasks:  result,
+		Count:  len(result),
+		Status: statusStr,
+	}
+
+	summaryText := fmt.Sprintf("Found %d %s tasks", len(result), statusStr)
+	return formatMCPResponse(summaryText, response)
+}
+
+func (s *TimelogMCPServer) GetCurrentActivity(ctx context.Context, req *mcp.CallToolRequest, args CurrentActivityParams) (*mcp.CallToolResult, *CurrentActivityResult, error) {
+	timeLogs, err := model.ListTimeLogsWithOptions(s.db, 0, "start_time DESC", "end_time IS NULL")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get current activity: %w", err)
+	}
+
+	result := make([]TimeLogEntry, 0, len(timeLogs))
+	for i := range timeLogs {
+		tl := &timeLogs[i]
+		duration := time.Since(tl.StartTime)
+		hours := int(duration.Hours())
+		minutes := int(duration.Minutes()) % 60
+
+		entry := timeLogEntry(tl)
+		entry.Duration = fmt.Sprintf("%dh %dm", hours, minutes)
+		result = append(result, entry)
+	}
+
+	response := &CurrentActivityResult{
+		ActiveLogs: result,
+		Count:      len(result),
+	}
+
+	summaryText := fmt.Sprintf("Found %d active time logs", len(result))
+	return formatMCPResponse(summaryText, response)
+}
+
+func (s *TimelogMCPServer) GetActiveConstraints(ctx context.Context, req *mcp.CallToolRequest, args ConstraintParams) (*mcp.CallToolResult, *ConstraintsResult, error) {
+	constraints, err := model.GetActiveConstraints(s.db)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get active constraints: %w", err)
+	}
+
+	result := make([]ConstraintEntry, 0, len(constraints))
+	for i := range constraints {
+		result = append(result, constraintEntry(&constraints[i]))
+	}
+
+	response := &ConstraintsResult{
+		Constraints: result,
+		Count:       len(result),
+	}
+
+	summaryText := fmt.Sprintf("Found %d active constraints", len(result))
+	return formatMCPResponse(summaryText, response)
+}
+
+func timeLogEntry(tl *gen.Timelog) TimeLogEntry {
+	loc := model.GetLocation()
+	entry := TimeLogEntry{
+		ID:         tl.ID,
+		CategoryID: tl.CategoryID,
+		TaskID:     tl.TaskID,
+		StartTime:  tl.StartTime.In(loc).Format("2006-01-02 15:04:05"),
+		Remarks:    tl.Remark,
+	}
+	if tl.EndTime != nil {
+		end := tl.EndTime.In(loc).Format("2006-01-02 15:04:05")
+		entry.EndTime = &end
+	}
+	return entry
+}
+
+func taskEntry(task *gen.Task) TaskEntry {
+	loc := model.GetLocation()
+	entry := TaskEntry{
+		ID:               task.ID,
+		Title:            task.Title,
+		Description:      task.Description,
+		CategoryID:       task.CategoryID,
+		DueDate:          task.DueDate.In(loc).Format("2006-01-02"),
+		EstimatedMinutes: task.EstimatedMinutes,
+		IsCompleted:      task.IsCompleted != nil && *task.IsCompleted,
+		IsSuspended:      task.IsSuspended != nil && *task.IsSuspended,
+	}
+	if task.CompletedAt != nil {
+		completedAt := task.CompletedAt.In(loc).Format("2006-01-02 15:04:05")
+		entry.CompletedAt = &completedAt
+	}
+	return entry
+}
+
+func constraintEntry(constraint *gen.Constraint) ConstraintEntry {
+	loc := model.GetLocation()
+	entry := ConstraintEntry{
+		ID:              constraint.ID,
+		Description:     constraint.Description,
+		PunishmentQuote: constraint.PunishmentQuote,
+		StartDate:       constraint.StartDate.In(loc).Format("2006-01-02"),
+		IsActive:        constraint.IsActive != nil && *constraint.IsActive,
+	}
+	if constraint.CreatedAt != nil {
+		entry.CreatedAt = constraint.CreatedAt.In(loc).Format("2006-01-02 15:04:05")
+	}
+	if constraint.EndDate != nil {
+		endDate := constraint.EndDate.In(loc).Format("2006-01-02")
+		entry.EndDate = &endDate
+	}
+	if constraint.EndReason != nil && *constraint.EndReason != "" {
+		entry.EndReason = *constraint.EndReason
+	}
+	return entry
+}
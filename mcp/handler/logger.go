@@ -1,31 +1,41 @@
-package main
+package handler
 
 import (
 	"os"
 	"path/filepath"
 
 	"github.com/blacksheepaul/timelog/core/config"
+	"github.com/blacksheepaul/timelog/core/logger"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-var mcpLogger *zap.SugaredLogger
+var mcpLogger logger.Logger
 
 // InitMCPLogger creates a file-only logger for MCP debugging
 // This logger will never write to stdout to avoid breaking MCP protocol
 func InitMCPLogger(cfg *config.Config) *zap.SugaredLogger {
+	l := newMCPLogger(cfg)
+	mcpLogger = l
+	return l
+}
+
+// SetLogger makes the handlers log through l, used when MCP is mounted in the main server
+func SetLogger(l logger.Logger) {
+	mcpLogger = l
+}
+
+func newMCPLogger(cfg *config.Config) *zap.SugaredLogger {
 	// Return no-op logger if MCP logging is disabled
 	if cfg == nil || !cfg.MCP.Enabled {
-		mcpLogger = zap.NewNop().Sugar()
-		return mcpLogger
+		return zap.NewNop().Sugar()
 	}
 
 	// Allow environment variable override
 	if os.Getenv("MCP_DEBUG") == "false" {
-		mcpLogger = zap.NewNop().Sugar()
-		return mcpLogger
+		return zap.NewNop().Sugar()
 	}
 
 	// Parse log level (default to debug for troubleshooting)
@@ -44,8 +54,7 @@ func InitMCPLogger(cfg *config.Config) *zap.SugaredLogger {
 	if _, err := os.Stat(logDir); os.IsNotExist(err) {
 		if err := os.MkdirAll(logDir, 0755); err != nil {
 			// If we can't create directory, return no-op logger
-			mcpLogger = zap.NewNop().Sugar()
-			return mcpLogger
+			return zap.NewNop().Sugar()
 		}
 	}
 
@@ -78,16 +87,14 @@ func InitMCPLogger(cfg *config.Config) *zap.SugaredLogger {
 	)
 
 	// Create logger with caller info for debugging
-	mcpLogger = zap.New(core,
+	return zap.New(core,
 		zap.AddCaller(),
 		zap.AddStacktrace(zapcore.ErrorLevel),
 	).Sugar()
-
-	return mcpLogger
 }
 
 // GetMCPLogger returns the MCP logger instance
-func GetMCPLogger() *zap.SugaredLogger {
+func GetMCPLogger() logger.Logger {
 	if mcpLogger == nil {
 		return zap.NewNop().Sugar()
 	}
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Review prompts embed the numbers computed by the server together with the
+// active constraints, so any MCP client can run the daily/weekly review
+// without the model having to call tools or add up durations itself.
+
+func (s *TimelogMCPServer) registerPrompts(mcpServer *mcp.Server) {
+	mcpServer.AddPrompt(&mcp.Prompt{
+		Name:        "daily_review",
+		Title:       "Daily review",
+		Description: "Review one day of time logs against the active constraints",
+		Arguments: []*mcp.PromptArgument{{
+			Name:        "date",
+			Description: "Date to review in YYYY-MM-DD format, defaults to today",
+		}},
+	}, s.DailyReviewPrompt)
+
+	mcpServer.AddPrompt(&mcp.Prompt{
+		Name:        "weekly_review",
+		Title:       "Weekly review",
+		Description: "Review a week of tracked time, compared with the week before, against the active constraints",
+		Arguments: []*mcp.PromptArgument{{
+			Name:        "date",
+			Description: "Any date in the week to review (YYYY-MM-DD), defaults to the current week",
+		}},
+	}, s.WeeklyReviewPrompt)
+
+	mcpServer.AddPrompt(&mcp.Prompt{
+		Name:        "plan_tomorrow",
+		Title:       "Plan tomorrow",
+		Description: "Plan tomorrow from today's time logs, pending tasks and the active constraints",
+	}, s.PlanTomorrowPrompt)
+}
+
+// promptDate resolves the optional date argument, defaulting to the current local date
+func promptDate(req *mcp.GetPromptRequest) (time.Time, error) {
+	if value := strings.TrimSpace(req.Params.Arguments["date"]); value != "" {
+		date, err := model.ParseLocalDate(value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("date must be YYYY-MM-DD")
+		}
+		return date, nil
+	}
+	return model.LocalDateOf(time.Now()), nil
+}
+
+// reviewPrompt builds a single user message from the instructions, the data
+// and the active constraints with their punishment quotes
+func (s *TimelogMCPServer) reviewPrompt(description, instructions string, data map[string]interface{}) (*mcp.GetPromptResult, error) {
+	constraints, err := s.constraintsContext()
+	if err != nil {
+		return nil, err
+	}
+	data["active_constraints"] = constraints
+
+	block, err := strictJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var text strings.Builder
+	text.WriteString(instructions)
+	text.WriteString("\n\nAll totals below were computed by the timelog server; use them as-is and do not recalculate durations.\n\n")
+	text.WriteString(block)
+	if len(constraints) > 0 {
+		text.WriteString("\n\nActive constraints and the punishment quote I chose for breaking each one:\n")
+		for _, c := range constraints {
+			fmt.Fprintf(&text, "- %s — \"%s\"\n", c.Description, c.PunishmentQuote)
+		}
+		text.WriteString("If the data shows a constraint was broken, quote its punishment quote verbatim.")
+	}
+
+	return &mcp.GetPromptResult{
+		Description: description,
+		Messages: []*mcp.PromptMessage{{
+			Role:    "user",
+			Content: &mcp.TextContent{Text: text.String()},
+		}},
+	}, nil
+}
+
+func (s *TimelogMCPServer) DailyReviewPrompt(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	date, err := promptDate(req)
+	if err != nil {
+		return nil, err
+	}
+	dateStr := date.Format("2006-01-02")
+	data, err := s.dayContext(dateStr)
+	if err != nil {
+		return nil, err
+	}
+	instructions := fmt.Sprintf(`Review my day %s (%s).
+1. Summarize where the time went, by category.
+2. Point out gaps, context switches and sessions that ran long.
+3. Check the tasks due today: what got done and what slipped.
+4. Judge the day against each active constraint.
+5. End with one concrete thing to do differently tomorrow.`, dateStr, date.Weekday())
+	return s.reviewPrompt("Daily review for "+dateStr, instructions, data)
+}
+
+func (s *TimelogMCPServer) WeeklyReviewPrompt(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	date, err := promptDate(req)
+	if err != nil {
+		return nil, err
+	}
+	monday, sunday := weekRangeOf(date)
+	data, err := s.weekContext(monday)
+	if err != nil {
+		return nil, err
+	}
+	weekStr := fmt.Sprintf("%s to %s", monday.Format("2006-01-02"), sunday.Format("2006-01-02"))
+	instructions := fmt.Sprintf(`Review my week %s.
+1. Summarize the week by category and call out the biggest changes compared with last week.
+2. Comment on the daily rhythm: which days were strong or weak.
+3. Assess how well my task estimates held up.
+4. Judge the week against each active constraint.
+5. Suggest at most three adjustments for next week.`, weekStr)
+	return s.reviewPrompt("Weekly review for "+weekStr, instructions, data)
+}
+
+func (s *TimelogMCPServer) PlanTomorrowPrompt(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	today := model.LocalDateOf(time.Now())
+	tomorrow := today.AddDate(0, 0, 1)
+	data, err := s.dayContext(today.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := model.GetAllTasks(s.db, false, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending tasks: %w", err)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].DueDate.Before(tasks[j].DueDate) })
+	paths, err := s.categoryPaths()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	pending := make([]TaskEntry, 0, len(tasks))
+	for i := range tasks {
+		entry := taskEntry(&tasks[i])
+		entry.Category = paths[tasks[i].CategoryID]
+		pending = append(pending, entry)
+	}
+
+	tomorrowStr := tomorrow.Format("2006-01-02")
+	plan := map[string]interface{}{
+		"tomorrow":      tomorrowStr,
+		"weekday":       tomorrow.Weekday().String(),
+		"day_starts_at": model.GetDayStartsAt(),
+		"today":         data,
+		"pending_tasks": pending,
+	}
+	instructions := fmt.Sprintf(`Help me plan tomorrow, %s (%s).
+1. Pick the tasks that matter most, starting with overdue and soon-due ones, and keep the plan within a realistic number of hours given how today went.
+2. Lay them out as time blocks with estimated durations.
+3. Make sure the plan respects every active constraint.
+4. Flag anything that should be rescheduled or dropped.`, tomorrowStr, tomorrow.Weekday())
+	return s.reviewPrompt("Plan for "+tomorrowStr, instructions, plan)
+}
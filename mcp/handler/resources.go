@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/service"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Resources give MCP clients read-only context they can attach to a
+// conversation without calling tools. The same data builders back the review
+// prompts in prompts.go.
+
+const (
+	dayResourcePrefix      = "timelog://day/"
+	weekCurrentResourceURI = "timelog://week/current"
+	categoriesResourceURI  = "timelog://categories"
+	resourceMIMEType       = "application/json"
+)
+
+func (s *TimelogMCPServer) registerResources(mcpServer *mcp.Server) {
+	mcpServer.AddResourceTemplate(&mcp.ResourceTemplate{
+		Name:        "day",
+		Title:       "Day summary",
+		Description: "Time logs, per-category totals and tasks due for one local date, e.g. timelog://day/2026-10-17",
+		URITemplate: dayResourcePrefix + "{date}",
+		MIMEType:    resourceMIMEType,
+	}, s.ReadDayResource)
+
+	mcpServer.AddResource(&mcp.Resource{
+		Name:        "week_current",
+		Title:       "Current week",
+		Description: "Per-category and per-day totals of the current week compared with last week, with estimate accuracy and longest sessions",
+		URI:         weekCurrentResourceURI,
+		MIMEType:    resourceMIMEType,
+	}, s.ReadCurrentWeekResource)
+
+	mcpServer.AddResource(&mcp.Resource{
+		Name:        "categories",
+		Title:       "Categories",
+		Description: "The category tree with full paths",
+		URI:         categoriesResourceURI,
+		MIMEType:    resourceMIMEType,
+	}, s.ReadCategoriesResource)
+}
+
+func jsonResource(uri string, data map[string]interface{}) (*mcp.ReadResourceResult, error) {
+	jsonBytes, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal resource: %w", err)
+	}
+	return &mcp.ReadResourceResult{
+		Contents: []*mcp.ResourceContents{{
+			URI:      uri,
+			MIMEType: resourceMIMEType,
+			Text:     string(jsonBytes),
+		}},
+	}, nil
+}
+
+func (s *TimelogMCPServer) ReadDayResource(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := req.Params.URI
+	date := strings.TrimPrefix(uri, dayResourcePrefix)
+	if _, err := model.ParseLocalDate(date); err != nil {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+	data, err := s.dayContext(date)
+	if err != nil {
+		return nil, err
+	}
+	return jsonResource(uri, data)
+}
+
+func (s *TimelogMCPServer) ReadCurrentWeekResource(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	monday, _ := weekRangeOf(model.LocalDateOf(time.Now()))
+	data, err := s.weekContext(monday)
+	if err != nil {
+		return nil, err
+	}
+	return jsonResource(req.Params.URI, data)
+}
+
+func (s *TimelogMCPServer) ReadCategoriesResource(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	data, err := s.categoriesContext()
+	if err != nil {
+		return nil, err
+	}
+	return jsonResource(req.Params.URI, data)
+}
+
+// categoryPaths maps category IDs to their full paths
+func (s *TimelogMCPServer) categoryPaths() (map[int32]string, error) {
+	categories, err := model.ListCategories(s.db)
+	if err != nil {
+		return nil, err
+	}
+	paths := make(map[int32]string, len(categories))
+	for i := range categories {
+		paths[*categories[i].ID] = model.GetFullPath(&categories[i])
+	}
+	return paths, nil
+}
+
+// dayContext collects everything recorded for a local date (YYYY-MM-DD)
+func (s *TimelogMCPServer) dayContext(date string) (map[string]interface{}, error) {
+	day, err := model.ParseLocalDate(date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q: %w", date, err)
+	}
+	paths, err := s.categoryPaths()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	timeLogs, err := model.ListTimeLogsByLocalDateRange(s.db, date, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get time logs: %w", err)
+	}
+	logs := make([]TimeLogEntry, 0, len(timeLogs))
+	for i := range timeLogs {
+		tl := &timeLogs[i]
+		entry := timeLogEntry(tl)
+		entry.Category = paths[tl.CategoryID]
+		if tl.EndTime != nil {
+			entry.Duration = formatMinutes(tl.EndTime.Sub(tl.StartTime).Minutes())
+		} else {
+			entry.Duration = "ongoing"
+		}
+		logs = append(logs, entry)
+	}
+
+	stats, err := service.GetStats(date, date, service.StatsPeriodDay)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category totals: %w", err)
+	}
+
+	tasks, err := model.GetTasksByDate(s.db, day, false, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tasks: %w", err)
+	}
+	dueTasks := make([]TaskEntry, 0, len(tasks))
+	for i := range tasks {
+		entry := taskEntry(&tasks[i])
+		entry.Category = paths[tasks[i].CategoryID]
+		dueTasks = append(dueTasks, entry)
+	}
+
+	return map[string]interface{}{
+		"date":            date,
+		"weekday":         day.Weekday().String(),
+		"timezone":        stats.Timezone,
+		"time_logs":       logs,
+		"total_minutes":   roundMinutes(stats.TotalMinutes),
+		"total":           formatMinutes(stats.TotalMinutes),
+		"category_totals": categoryTotals(stats.Categories),
+		"tasks_due":       dueTasks,
+	}, nil
+}
+
+// weekContext summarizes the Monday-based week starting at monday
+func (s *TimelogMCPServer) weekContext(monday time.Time) (map[string]interface{}, error) {
+	from := monday.Format("2006-01-02")
+	to := monday.AddDate(0, 0, 6).Format("2006-01-02")
+
+	stats, err := service.GetStats(from, to, service.StatsPeriodDay)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category totals: %w", err)
+	}
+	days := make([]map[string]interface{}, 0, len(stats.Periods))
+	for _, p := range stats.Periods {
+		days = append(days, map[string]interface{}{
+			"date":          p.Period,
+			"total_minutes": roundMinutes(p.TotalMinutes),
+			"total":         formatMinutes(p.TotalMinutes),
+		})
+	}
+
+	cmp, err := service.ComparePeriods(from, to, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare with last week: %w", err)
+	}
+
+	accuracy, err := service.GetEstimateAccuracy(from, to, service.StatsPeriodWeek)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get estimate accuracy: %w", err)
+	}
+
+	extremes, err := service.GetSessionExtremes(from, to, 3)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get longest sessions: %w", err)
+	}
+
+	return map[string]interface{}{
+		"week_range":           []string{from, to},
+		"timezone":             stats.Timezone,
+		"total_minutes":        roundMinutes(stats.TotalMinutes),
+		"total":                formatMinutes(stats.TotalMinutes),
+		"previous_week_total":  formatMinutes(cmp.PreviousTotalMinutes),
+		"delta_percent":        roundPercent(cmp.DeltaPercent),
+		"category_totals":      categoryTotals(stats.Categories),
+		"changes_vs_last_week": categoryChanges(cmp.Categories, 1),
+		"daily_totals":         days,
+		"estimate_accuracy":    estimateAccuracyEntry(accuracy.Overall),
+		"session_count":        extremes.Count,
+		"average_session":      formatMinutes(extremes.AverageMinutes),
+		"longest_sessions":     sessionEntries(extremes.Longest),
+	}, nil
+}
+
+func categoryTreeEntries(nodes []*model.CategoryNode) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(nodes))
+	for _, node := range nodes {
+		entry := map[string]interface{}{
+			"id":   node.Category.ID,
+			"name": node.Category.Name,
+			"path": model.GetFullPath(&node.Category),
+		}
+		if node.Category.Color != nil {
+			entry["color"] = *node.Category.Color
+		}
+		if node.Category.ArchivedAt != nil {
+			// archived categories keep their history but no longer accept new entries
+			entry["archived"] = true
+		}
+		if len(node.Children) > 0 {
+			entry["children"] = categoryTreeEntries(node.Children)
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+func (s *TimelogMCPServer) categoriesContext() (map[string]interface{}, error) {
+	tree, err := model.GetCategoryTree(s.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	return map[string]interface{}{
+		"categories": categoryTreeEntries(tree),
+	}, nil
+}
+
+// constraintsContext lists the active constraints with their punishment quotes
+func (s *TimelogMCPServer) constraintsContext() ([]ConstraintEntry, error) {
+	constraints, err := model.GetActiveConstraints(s.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active constraints: %w", err)
+	}
+	result := make([]ConstraintEntry, 0, len(constraints))
+	for i := range constraints {
+		result = append(result, constraintEntry(&constraints[i]))
+	}
+	return result, nil
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"github.com/blacksheepaul/timelog/core/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"gorm.io/gorm"
+)
+
+// NewServer creates an MCP server exposing the timelog tools, resources and
+// prompts backed by db. Write tools are only registered when allowWrites is set.
+// The service layer must already be initialized.
+func NewServer(db *gorm.DB, cfg *config.Config, allowWrites bool) *mcp.Server {
+	s := &TimelogMCPServer{
+		db:     db,
+		config: cfg,
+	}
+
+	// Create MCP server with implementation
+	mcpServer := mcp.NewServer(&mcp.Implementation{
+		Name:    "timelog",
+		Version: "1.0.0",
+	}, nil)
+
+	mcp.AddTool(mcpServer, &mcp.Tool{
+		Name:        "get_timelogs_by_date_range",
+		Description: "Get time logs within a specific date range",
+	}, s.GetTimeLogsByDateRange)
+
+	mcp.AddTool(mcpServer, &mcp.Tool{
+		Name:        "get_tasks_by_status",
+		Description: "Get tasks filtered by completion status",
+	}, s.GetTasksByStatus)
+
+	mcp.AddTool(mcpServer, &mcp.Tool{
+		Name:        "get_current_activity",
+		Description: "Get currently active/running time logs",
+	}, s.GetCurrentActivity)
+
+	mcp.AddTool(mcpServer, &mcp.Tool{
+		Name:        "get_active_constraints",
+		Description: "To know self discipline and external conditions",
+	}, s.GetActiveConstraints)
+
+	mcp.AddTool(mcpServer, &mcp.Tool{
+		Name:        "get_date_info",
+		Description: "Get current date, time, today, yesterday, and this week's date range",
+	}, s.GetDateInfo)
+
+	mcp.AddTool(mcpServer, &mcp.Tool{
+		Name:        "get_category_totals",
+		Description: "Get tracked time per category for a date range, as a category tree where parent totals include subcategories, optionally split by day/week/month/year",
+	}, s.GetCategoryTotals)
+
+	mcp.AddTool(mcpServer, &mcp.Tool{
+		Name:        "compare_periods",
+		Description: "Compare tracked time per category between a date range and the previous range of the same length (or a given one)",
+	}, s.ComparePeriods)
+
+	mcp.AddTool(mcpServer, &mcp.Tool{
+		Name:        "get_estimate_accuracy",
+		Description: "Get how actual tracked time compared with estimates for tasks completed in a date range, overall, per category and per week",
+	}, s.GetEstimateAccuracy)
+
+	mcp.AddTool(mcpServer, &mcp.Tool{
+		Name:        "get_session_extremes",
+		Description: "Get the longest and shortest finished time log sessions in a date range, with average and median durations",
+	}, s.GetSessionExtremes)
+
+	s.registerResources(mcpServer)
+	s.registerPrompts(mcpServer)
+
+	// Mutating tools are opt-in so read-only deployments stay read-only
+	if allowWrites {
+		s.registerWriteTools(mcpServer)
+	}
+
+	return mcpServer
+}
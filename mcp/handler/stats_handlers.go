@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/service"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Stats tools return numbers computed by the service layer so the model never
+// has to add up durations itself. Minutes are rounded to one decimal and every
+// total also comes as a human readable "Xh Ym" string.
+
+func roundMinutes(m float64) float64 {
+	return math.Round(m*10) / 10
+}
+
+func formatMinutes(m float64) string {
+	total := int(math.Round(m))
+	return fmt.Sprintf("%dh %dm", total/60, total%60)
+}
+
+func roundPercent(p *float64) *float64 {
+	if p == nil {
+		return nil
+	}
+	rounded := math.Round(*p*10) / 10
+	return &rounded
+}
+
+// categoryTotals flattens the category tree in tree order with rounded totals
+func categoryTotals(stats []*service.CategoryStat) []CategoryTotal {
+	result := make([]CategoryTotal, 0, len(stats))
+	var walk func(stats []*service.CategoryStat, parentID *int32, level int)
+	walk = func(stats []*service.CategoryStat, parentID *int32, level int) {
+		for _, stat := range stats {
+			result = append(result, CategoryTotal{
+				CategoryID:   stat.CategoryID,
+				ParentID:     parentID,
+				Level:        level,
+				Name:         stat.Name,
+				Path:         stat.Path,
+				TotalMinutes: roundMinutes(stat.TotalMinutes),
+				Total:        formatMinutes(stat.TotalMinutes),
+				OwnMinutes:   roundMinutes(stat.OwnMinutes),
+				Percentage:   math.Round(stat.Percentage*10) / 10,
+			})
+			id := stat.CategoryID
+			walk(stat.Children, &id, level+1)
+		}
+	}
+	walk(stats, nil, 1)
+	return result
+}
+
+func (s *TimelogMCPServer) GetCategoryTotals(ctx context.Context, req *mcp.CallToolRequest, args CategoryTotalsParams) (*mcp.CallToolResult, *CategoryTotalsResult, error) {
+	period := args.Period
+	if period == "" {
+		period = service.StatsPeriodYear
+	}
+	stats, err := service.GetStats(args.StartDate, args.EndDate, period)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get category totals: %w", err)
+	}
+
+	response := &CategoryTotalsResult{
+		DateRange:    fmt.Sprintf("%s to %s", args.StartDate, args.EndDate),
+		Timezone:     stats.Timezone,
+		TotalMinutes: roundMinutes(stats.TotalMinutes),
+		Total:        formatMinutes(stats.TotalMinutes),
+		Categories:   categoryTotals(stats.Categories),
+	}
+	if args.Period != "" {
+		periods := make([]PeriodTotals, 0, len(stats.Periods))
+		for _, p := range stats.Periods {
+			periods = append(periods, PeriodTotals{
+				Period:       p.Period,
+				TotalMinutes: roundMinutes(p.TotalMinutes),
+				Total:        formatMinutes(p.TotalMinutes),
+				Categories:   categoryTotals(p.Categories),
+			})
+		}
+		response.Period = args.Period
+		response.Periods = periods
+	}
+
+	summaryText := fmt.Sprintf("Tracked %s from %s to %s across %d top-level categories; parent totals include subcategories",
+		formatMinutes(stats.TotalMinutes), args.StartDate, args.EndDate, len(stats.Categories))
+	return formatMCPResponse(summaryText, response)
+}
+
+func (s *TimelogMCPServer) ComparePeriods(ctx context.Context, req *mcp.CallToolRequest, args ComparePeriodsParams) (*mcp.CallToolResult, *PeriodComparisonResult, error) {
+	cmp, err := service.ComparePeriods(args.StartDate, args.EndDate, args.PreviousStartDate, args.PreviousEndDate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to compare periods: %w", err)
+	}
+
+	response := &PeriodComparisonResult{
+		Current:              fmt.Sprintf("%s to %s", cmp.Current.From, cmp.Current.To),
+		Previous:             fmt.Sprintf("%s to %s", cmp.Previous.From, cmp.Previous.To),
+		CurrentTotalMinutes:  roundMinutes(cmp.CurrentTotalMinutes),
+		PreviousTotalMinutes: roundMinutes(cmp.PreviousTotalMinutes),
+		CurrentTotal:         formatMinutes(cmp.CurrentTotalMinutes),
+		PreviousTotal:        formatMinutes(cmp.PreviousTotalMinutes),
+		DeltaMinutes:         roundMinutes(cmp.DeltaMinutes),
+		DeltaPercent:         roundPercent(cmp.DeltaPercent),
+		Categories:           categoryChanges(cmp.Categories, 0),
+	}
+
+	summaryText := fmt.Sprintf("Tracked %s from %s to %s vs %s from %s to %s (delta %+.1f minutes); delta_percent is null when the previous value is 0",
+		formatMinutes(cmp.CurrentTotalMinutes), cmp.Current.From, cmp.Current.To,
+		formatMinutes(cmp.PreviousTotalMinutes), cmp.Previous.From, cmp.Previous.To, roundMinutes(cmp.DeltaMinutes))
+	return formatMCPResponse(summaryText, response)
+}
+
+// categoryChanges renders the comparison, limited to categories up to maxLevel (0 means all)
+func categoryChanges(categories []service.CategoryComparison, maxLevel int) []CategoryChange {
+	result := make([]CategoryChange, 0, len(categories))
+	for _, c := range categories {
+		if maxLevel > 0 && c.Level > maxLevel {
+			continue
+		}
+		result = append(result, CategoryChange{
+			CategoryID:      c.CategoryID,
+			Path:            c.Path,
+			Level:           c.Level,
+			CurrentMinutes:  roundMinutes(c.CurrentMinutes),
+			PreviousMinutes: roundMinutes(c.PreviousMinutes),
+			DeltaMinutes:    roundMinutes(c.DeltaMinutes),
+			DeltaPercent:    roundPercent(c.DeltaPercent),
+		})
+	}
+	return result
+}
+
+func estimateAccuracyEntry(a service.EstimateAccuracy) EstimateAccuracyEntry {
+	return EstimateAccuracyEntry{
+		TaskCount:           a.TaskCount,
+		OnTargetCount:       a.OnTargetCount,
+		EstimatedMinutes:    roundMinutes(a.EstimatedMinutes),
+		ActualMinutes:       roundMinutes(a.ActualMinutes),
+		VarianceMinutes:     roundMinutes(a.VarianceMinutes),
+		Ratio:               math.Round(a.Ratio*100) / 100,
+		MeanAbsPercentError: math.Round(a.MeanAbsPercentError*10) / 10,
+	}
+}
+
+func (s *TimelogMCPServer) GetEstimateAccuracy(ctx context.Context, req *mcp.CallToolRequest, args EstimateAccuracyParams) (*mcp.CallToolResult, *EstimateAccuracyResult, error) {
+	report, err := service.GetEstimateAccuracy(args.StartDate, args.EndDate, service.StatsPeriodWeek)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get estimate accuracy: %w", err)
+	}
+
+	categories := make([]EstimateAccuracyEntry, 0, len(report.Categories))
+	for _, c := range report.Categories {
+		entry := estimateAccuracyEntry(c.EstimateAccuracy)
+		id := c.CategoryID
+		entry.CategoryID = &id
+		entry.Path = c.Path
+		categories = append(categories, entry)
+	}
+	weeks := make([]EstimateAccuracyEntry, 0, len(report.Periods))
+	for _, p := range report.Periods {
+		entry := estimateAccuracyEntry(p.EstimateAccuracy)
+		entry.Week = p.Period
+		weeks = append(weeks, entry)
+	}
+
+	response := &EstimateAccuracyResult{
+		DateRange:  fmt.Sprintf("%s to %s", args.StartDate, args.EndDate),
+		Overall:    estimateAccuracyEntry(report.Overall),
+		Categories: categories,
+		Weeks:      weeks,
+	}
+
+	summaryText := fmt.Sprintf("%d completed tasks with estimates, %d within ±20%%; ratio = actual/estimated, above 1 means underestimated",
+		report.Overall.TaskCount, report.Overall.OnTargetCount)
+	return formatMCPResponse(summaryText, response)
+}
+
+func sessionEntries(sessions []service.TrackedSession) []SessionEntry {
+	loc := model.GetLocation()
+	result := make([]SessionEntry, 0, len(sessions))
+	for _, s := range sessions {
+		result = append(result, SessionEntry{
+			ID:              s.TimeLogID,
+			Category:        s.CategoryPath,
+			Task:            s.TaskTitle,
+			Remarks:         s.Remark,
+			StartTime:       s.StartTime.In(loc).Format("2006-01-02 15:04:05"),
+			EndTime:         s.EndTime.In(loc).Format("2006-01-02 15:04:05"),
+			DurationMinutes: roundMinutes(s.DurationMinutes),
+			Duration:        formatMinutes(s.DurationMinutes),
+		})
+	}
+	return result
+}
+
+func (s *TimelogMCPServer) GetSessionExtremes(ctx context.Context, req *mcp.CallToolRequest, args SessionExtremesParams) (*mcp.CallToolResult, *SessionExtremesResult, error) {
+	extremes, err := service.GetSessionExtremes(args.StartDate, args.EndDate, args.Limit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get session extremes: %w", err)
+	}
+
+	response := &SessionExtremesResult{
+		DateRange:      fmt.Sprintf("%s to %s", args.StartDate, args.EndDate),
+		Count:          extremes.Count,
+		TotalMinutes:   roundMinutes(extremes.TotalMinutes),
+		AverageMinutes: roundMinutes(extremes.AverageMinutes),
+		MedianMinutes:  roundMinutes(extremes.MedianMinutes),
+		Longest:        sessionEntries(extremes.Longest),
+		Shortest:       sessionEntries(extremes.Shortest),
+	}
+
+	summaryText := fmt.Sprintf("%d finished sessions from %s to %s, average %s, median %s",
+		extremes.Count, args.StartDate, args.EndDate, formatMinutes(extremes.AverageMinutes), formatMinutes(extremes.MedianMinutes))
+	return formatMCPResponse(summaryText, response)
+}
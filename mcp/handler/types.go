@@ -0,0 +1,297 @@
+package handler
+
+import (
+	"github.com/blacksheepaul/timelog/core/config"
+	"gorm.io/gorm"
+)
+
+// TimelogMCPServer holds the per-instance state shared by the tool, resource and prompt handlers
+type TimelogMCPServer struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+// Tool parameter structs
+type DateRangeParams struct {
+	StartDate string `json:"start_date" jsonschema:"Start date in YYYY-MM-DD format,required"`
+	EndDate   string `json:"end_date" jsonschema:"End date in YYYY-MM-DD format,required"`
+}
+
+type TaskStatusParams struct {
+	Status string `json:"status" jsonschema:"Task status filter (completed/pending/all),required"`
+}
+
+type CurrentActivityParams struct {
+	// No parameters needed
+}
+
+type ConstraintParams struct {
+	// No parameters needed
+}
+
+// Write tool parameter structs
+type CreateTimeLogParams struct {
+	CategoryID int32   `json:"category_id" jsonschema:"Category ID of the activity,required"`
+	StartTime  string  `json:"start_time" jsonschema:"Start time in local time zone, YYYY-MM-DD HH:MM[:SS] or RFC3339,required"`
+	EndTime    *string `json:"end_time,omitempty" jsonschema:"End time in the same format, omit for an ongoing activity"`
+	TaskID     *int32  `json:"task_id,omitempty" jsonschema:"Related task ID"`
+	Remark     *string `json:"remark,omitempty" jsonschema:"Remark"`
+}
+
+type UpdateTimeLogParams struct {
+	ID         int32   `json:"id" jsonschema:"Time log ID,required"`
+	CategoryID *int32  `json:"category_id,omitempty" jsonschema:"New category ID"`
+	StartTime  *string `json:"start_time,omitempty" jsonschema:"New start time, YYYY-MM-DD HH:MM[:SS] or RFC3339"`
+	EndTime    *string `json:"end_time,omitempty" jsonschema:"New end time, empty string reopens the time log"`
+	TaskID     *int32  `json:"task_id,omitempty" jsonschema:"New related task ID, 0 clears it"`
+	Remark     *string `json:"remark,omitempty" jsonschema:"New remark"`
+}
+
+type StartActivityParams struct {
+	CategoryID int32   `json:"category_id" jsonschema:"Category ID of the activity,required"`
+	TaskID     *int32  `json:"task_id,omitempty" jsonschema:"Related task ID"`
+	Remark     *string `json:"remark,omitempty" jsonschema:"Remark"`
+	Switch     bool    `json:"switch,omitempty" jsonschema:"Stop the current activity first instead of failing when one is running"`
+}
+
+type StopActivityParams struct {
+	// No parameters needed
+}
+
+type CreateTaskParams struct {
+	Title            string  `json:"title" jsonschema:"Task title,required"`
+	CategoryID       int32   `json:"category_id" jsonschema:"Category ID,required"`
+	DueDate          string  `json:"due_date" jsonschema:"Due date in YYYY-MM-DD format,required"`
+	EstimatedMinutes int32   `json:"estimated_minutes,omitempty" jsonschema:"Estimated minutes"`
+	Description      *string `json:"description,omitempty" jsonschema:"Description"`
+}
+
+type TaskIDParams struct {
+	ID int32 `json:"id" jsonschema:"Task ID,required"`
+}
+
+type CloseConstraintParams struct {
+	ID        int32  `json:"id" jsonschema:"Constraint ID,required"`
+	EndReason string `json:"end_reason" jsonschema:"Why the constraint ends,required"`
+}
+
+// Stats tool parameter structs
+type CategoryTotalsParams struct {
+	StartDate string `json:"start_date" jsonschema:"Start date in YYYY-MM-DD format,required"`
+	EndDate   string `json:"end_date" jsonschema:"End date in YYYY-MM-DD format,required"`
+	Period    string `json:"period,omitempty" jsonschema:"Also split totals by day/week/month/year, omit for range totals only"`
+}
+
+type ComparePeriodsParams struct {
+	StartDate         string `json:"start_date" jsonschema:"Start date of the current range in YYYY-MM-DD format,required"`
+	EndDate           string `json:"end_date" jsonschema:"End date of the current range in YYYY-MM-DD format,required"`
+	PreviousStartDate string `json:"previous_start_date,omitempty" jsonschema:"Start date of the range to compare against, defaults to the same number of days right before"`
+	PreviousEndDate   string `json:"previous_end_date,omitempty" jsonschema:"End date of the range to compare against"`
+}
+
+type EstimateAccuracyParams struct {
+	StartDate string `json:"start_date" jsonschema:"Start date in YYYY-MM-DD format (by task completion date),required"`
+	EndDate   string `json:"end_date" jsonschema:"End date in YYYY-MM-DD format,required"`
+}
+
+type SessionExtremesParams struct {
+	StartDate string `json:"start_date" jsonschema:"Start date in YYYY-MM-DD format,required"`
+	EndDate   string `json:"end_date" jsonschema:"End date in YYYY-MM-DD format,required"`
+	Limit     int    `json:"limit,omitempty" jsonschema:"Number of longest and shortest sessions to return (default 5)"`
+}
+
+// Tool result structs. Each tool publishes the output schema inferred from its
+// result type and returns it as structured content next to the <STRICT_JSON>
+// text. Slices are always non-nil since the schemas do not allow null arrays.
+
+type TimeLogEntry struct {
+	ID         *int32  `json:"id"`
+	CategoryID int32   `json:"category_id"`
+	Category   string  `json:"category,omitempty" jsonschema:"Full category path"`
+	TaskID     *int32  `json:"task_id"`
+	StartTime  string  `json:"start_time" jsonschema:"Local time, YYYY-MM-DD HH:MM:SS"`
+	EndTime    *string `json:"end_time" jsonschema:"Local time, null while the activity is running"`
+	Duration   string  `json:"duration,omitempty" jsonschema:"Xh Ym, or ongoing"`
+	Remarks    *string `json:"remarks"`
+}
+
+type TaskEntry struct {
+	ID               *int32   `json:"id"`
+	Title            string   `json:"title"`
+	Description      *string  `json:"description"`
+	CategoryID       int32    `json:"category_id"`
+	Category         string   `json:"category,omitempty"`
+	CategoryColor    string   `json:"category_color,omitempty"`
+	DueDate          string   `json:"due_date" jsonschema:"Local date, YYYY-MM-DD"`
+	EstimatedMinutes int32    `json:"estimated_minutes"`
+	ActualMinutes    *float64 `json:"actual_minutes,omitempty"`
+	IsCompleted      bool     `json:"is_completed"`
+	IsSuspended      bool     `json:"is_suspended"`
+	CompletedAt      *string  `json:"completed_at,omitempty"`
+	CreatedAt        string   `json:"created_at,omitempty"`
+}
+
+type ConstraintEntry struct {
+	ID              *int32  `json:"id"`
+	Description     string  `json:"description"`
+	PunishmentQuote string  `json:"punishment_quote"`
+	StartDate       string  `json:"start_date"`
+	EndDate         *string `json:"end_date,omitempty"`
+	EndReason       string  `json:"end_reason,omitempty"`
+	IsActive        bool    `json:"is_active"`
+	CreatedAt       string  `json:"created_at"`
+}
+
+type DateInfoResult struct {
+	Timezone    string   `json:"timezone"`
+	DayStartsAt string   `json:"day_starts_at" jsonschema:"Time of day (HH:MM) a logical day starts"`
+	Now         string   `json:"now"`
+	Today       string   `json:"today"`
+	Yesterday   string   `json:"yesterday"`
+	Weekday     string   `json:"weekday"`
+	WeekRange   []string `json:"week_range" jsonschema:"Monday and Sunday of this week"`
+}
+
+type TimeLogsResult struct {
+	TimeLogs      []TimeLogEntry `json:"time_logs"`
+	Count         int            `json:"count"`
+	DateRange     string         `json:"date_range"`
+	TotalDuration string         `json:"total_duration"`
+}
+
+type TasksResult struct {
+	Tasks  []TaskEntry `json:"tasks"`
+	Count  int         `json:"count"`
+	Status string      `json:"status"`
+}
+
+type CurrentActivityResult struct {
+	ActiveLogs []TimeLogEntry `json:"active_logs"`
+	Count      int            `json:"count"`
+}
+
+type ConstraintsResult struct {
+	Constraints []ConstraintEntry `json:"constraints"`
+	Count       int               `json:"count"`
+}
+
+// CategoryTotal is one node of the category tree; entries are listed in tree
+// order (parents before their children) with parent_id and level
+type CategoryTotal struct {
+	CategoryID   int32   `json:"category_id"`
+	ParentID     *int32  `json:"parent_id"`
+	Level        int     `json:"level" jsonschema:"Depth in the tree, top-level categories are 1"`
+	Name         string  `json:"name"`
+	Path         string  `json:"path"`
+	TotalMinutes float64 `json:"total_minutes" jsonschema:"Minutes including all subcategories"`
+	Total        string  `json:"total"`
+	OwnMinutes   float64 `json:"own_minutes" jsonschema:"Minutes logged directly on this category"`
+	Percentage   float64 `json:"percentage" jsonschema:"Share of the whole range, including subcategories"`
+}
+
+type PeriodTotals struct {
+	Period       string          `json:"period"`
+	TotalMinutes float64         `json:"total_minutes"`
+	Total        string          `json:"total"`
+	Categories   []CategoryTotal `json:"categories"`
+}
+
+type CategoryTotalsResult struct {
+	DateRange    string          `json:"date_range"`
+	Timezone     string          `json:"timezone"`
+	TotalMinutes float64         `json:"total_minutes"`
+	Total        string          `json:"total"`
+	Categories   []CategoryTotal `json:"categories"`
+	Period       string          `json:"period,omitempty"`
+	Periods      []PeriodTotals  `json:"periods,omitempty"`
+}
+
+type CategoryChange struct {
+	CategoryID      int32    `json:"category_id"`
+	Path            string   `json:"path"`
+	Level           int      `json:"level"`
+	CurrentMinutes  float64  `json:"current_minutes"`
+	PreviousMinutes float64  `json:"previous_minutes"`
+	DeltaMinutes    float64  `json:"delta_minutes"`
+	DeltaPercent    *float64 `json:"delta_percent" jsonschema:"Null when the previous value is 0"`
+}
+
+type PeriodComparisonResult struct {
+	Current              string           `json:"current"`
+	Previous             string           `json:"previous"`
+	CurrentTotalMinutes  float64          `json:"current_total_minutes"`
+	PreviousTotalMinutes float64          `json:"previous_total_minutes"`
+	CurrentTotal         string           `json:"current_total"`
+	PreviousTotal        string           `json:"previous_total"`
+	DeltaMinutes         float64          `json:"delta_minutes"`
+	DeltaPercent         *float64         `json:"delta_percent" jsonschema:"Null when the previous value is 0"`
+	Categories           []CategoryChange `json:"categories"`
+}
+
+// EstimateAccuracyEntry is used for the overall, per-category and per-week figures
+type EstimateAccuracyEntry struct {
+	CategoryID          *int32  `json:"category_id,omitempty"`
+	Path                string  `json:"path,omitempty"`
+	Week                string  `json:"week,omitempty"`
+	TaskCount           int     `json:"task_count"`
+	OnTargetCount       int     `json:"on_target_count" jsonschema:"Tasks within ±20% of the estimate"`
+	EstimatedMinutes    float64 `json:"estimated_minutes"`
+	ActualMinutes       float64 `json:"actual_minutes"`
+	VarianceMinutes     float64 `json:"variance_minutes"`
+	Ratio               float64 `json:"ratio" jsonschema:"Actual / estimated, above 1 means underestimated"`
+	MeanAbsPercentError float64 `json:"mean_abs_percent_error"`
+}
+
+type EstimateAccuracyResult struct {
+	DateRange  string                  `json:"date_range"`
+	Overall    EstimateAccuracyEntry   `json:"overall"`
+	Categories []EstimateAccuracyEntry `json:"categories"`
+	Weeks      []EstimateAccuracyEntry `json:"weeks"`
+}
+
+type SessionEntry struct {
+	ID              int32   `json:"id"`
+	Category        string  `json:"category"`
+	Task            string  `json:"task"`
+	Remarks         *string `json:"remarks"`
+	StartTime       string  `json:"start_time"`
+	EndTime         string  `json:"end_time"`
+	DurationMinutes float64 `json:"duration_minutes"`
+	Duration        string  `json:"duration"`
+}
+
+type SessionExtremesResult struct {
+	DateRange      string         `json:"date_range"`
+	Count          int            `json:"count"`
+	TotalMinutes   float64        `json:"total_minutes"`
+	AverageMinutes float64        `json:"average_minutes"`
+	MedianMinutes  float64        `json:"median_minutes"`
+	Longest        []SessionEntry `json:"longest"`
+	Shortest       []SessionEntry `json:"shortest"`
+}
+
+// Write tool result structs
+type TimeLogResult struct {
+	TimeLog TimeLogEntry `json:"time_log"`
+}
+
+type StartActivityResult struct {
+	Running TimeLogEntry   `json:"running"`
+	Stopped []TimeLogEntry `json:"stopped" jsonschema:"Time logs stopped by switch"`
+}
+
+type StopActivityResult struct {
+	Stopped []TimeLogEntry `json:"stopped"`
+	Count   int            `json:"count"`
+}
+
+type TaskResult struct {
+	Task TaskEntry `json:"task"`
+}
+
+type CloseConstraintResult struct {
+	ID          int32  `json:"id"`
+	Description string `json:"description"`
+	EndReason   string `json:"end_reason"`
+	IsActive    bool   `json:"is_active"`
+}
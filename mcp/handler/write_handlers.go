@@ -0,0 +1,340 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/model/gen"
+	"github.com/blacksheepaul/timelog/service"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Write tool handlers, registered only when mcp.allow_writes is enabled.
+// All writes go through the same service functions as the HTTP API so overlap,
+// timer and time range validation behave identically.
+// The checks and the write share one transaction, so SQLite's write lock also
+// serializes them against the main server when both use the same database.
+
+// localTimeLayouts are the accepted formats for times given in the configured time zone
+var localTimeLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+}
+
+// parseMCPTime parses RFC3339, or a local wall-clock time in the configured time zone
+func parseMCPTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range localTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, model.GetLocation()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, expected YYYY-MM-DD HH:MM[:SS] or RFC3339", s)
+}
+
+// ensureCategory returns a tool error when the category does not exist
+func ensureCategory(id int32) error {
+	if _, err := service.GetCategoryByID(id); err != nil {
+		return fmt.Errorf("category %d not found", id)
+	}
+	return nil
+}
+
+func ensureTask(id int32) (*gen.Task, error) {
+	task, err := service.GetTaskByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("task %d not found", id)
+	}
+	return task, nil
+}
+
+func (s *TimelogMCPServer) CreateTimeLog(ctx context.Context, req *mcp.CallToolRequest, args CreateTimeLogParams) (*mcp.CallToolResult, *TimeLogResult, error) {
+	if err := ensureCategory(args.CategoryID); err != nil {
+		return nil, nil, err
+	}
+	if args.TaskID != nil {
+		if _, err := ensureTask(*args.TaskID); err != nil {
+			return nil, nil, err
+		}
+	}
+	start, err := parseMCPTime(args.StartTime)
+	if err != nil {
+		return nil, nil, err
+	}
+	tl := &gen.Timelog{
+		StartTime:  start,
+		CategoryID: args.CategoryID,
+		TaskID:     args.TaskID,
+		Remark:     args.Remark,
+	}
+	if args.EndTime != nil && *args.EndTime != "" {
+		end, err := parseMCPTime(*args.EndTime)
+		if err != nil {
+			return nil, nil, err
+		}
+		tl.EndTime = &end
+	}
+
+	if err := service.CreateTimeLog(tl); err != nil {
+		LogMCPError("create_timelog", err, map[string]interface{}{"args": args})
+		return nil, nil, fmt.Errorf("failed to create time log: %w", err)
+	}
+	created, err := service.GetTimeLogByID(*tl.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load created time log: %w", err)
+	}
+
+	response := &TimeLogResult{TimeLog: timeLogEntry(created)}
+	summaryText := fmt.Sprintf("Created time log %d", *created.ID)
+	return formatMCPResponse(summaryText, response)
+}
+
+func (s *TimelogMCPServer) UpdateTimeLog(ctx context.Context, req *mcp.CallToolRequest, args UpdateTimeLogParams) (*mcp.CallToolResult, *TimeLogResult, error) {
+	tl, err := service.GetTimeLogByID(args.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("time log %d not found", args.ID)
+	}
+
+	if args.CategoryID != nil {
+		if err := ensureCategory(*args.CategoryID); err != nil {
+			return nil, nil, err
+		}
+		tl.CategoryID = *args.CategoryID
+	}
+	if args.TaskID != nil {
+		if *args.TaskID == 0 {
+			tl.TaskID = nil
+		} else {
+			if _, err := ensureTask(*args.TaskID); err != nil {
+				return nil, nil, err
+			}
+			tl.TaskID = args.TaskID
+		}
+	}
+	if args.StartTime != nil {
+		if tl.StartTime, err = parseMCPTime(*args.StartTime); err != nil {
+			return nil, nil, err
+		}
+	}
+	if args.EndTime != nil {
+		if *args.EndTime == "" {
+			tl.EndTime = nil
+		} else {
+			end, err := parseMCPTime(*args.EndTime)
+			if err != nil {
+				return nil, nil, err
+			}
+			tl.EndTime = &end
+		}
+	}
+	if args.Remark != nil {
+		tl.Remark = args.Remark
+	}
+
+	if err := service.UpdateTimeLog(tl); err != nil {
+		LogMCPError("update_timelog", err, map[string]interface{}{"args": args})
+		return nil, nil, fmt.Errorf("failed to update time log: %w", err)
+	}
+	updated, err := service.GetTimeLogByID(args.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load updated time log: %w", err)
+	}
+
+	response := &TimeLogResult{TimeLog: timeLogEntry(updated)}
+	summaryText := fmt.Sprintf("Updated time log %d", args.ID)
+	return formatMCPResponse(summaryText, response)
+}
+
+func (s *TimelogMCPServer) StartActivity(ctx context.Context, req *mcp.CallToolRequest, args StartActivityParams) (*mcp.CallToolResult, *StartActivityResult, error) {
+	timerReq := service.TimerRequest{CategoryID: args.CategoryID, TaskID: args.TaskID, Remark: args.Remark}
+
+	response := &StartActivityResult{Stopped: []TimeLogEntry{}}
+	var running *service.RunningTimer
+	if args.Switch {
+		result, err := service.SwitchTimer(timerReq)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to switch activity: %w", err)
+		}
+		for i := range result.Stopped {
+			response.Stopped = append(response.Stopped, timeLogEntry(&result.Stopped[i]))
+		}
+		running = result.Running
+	} else {
+		var err error
+		if running, err = service.StartTimer(timerReq); err != nil {
+			return nil, nil, fmt.Errorf("failed to start activity: %w", err)
+		}
+	}
+
+	response.Running = timeLogEntry(running.Timelog)
+	summaryText := fmt.Sprintf("Started activity as time log %d", *running.Timelog.ID)
+	return formatMCPResponse(summaryText, response)
+}
+
+func (s *TimelogMCPServer) StopActivity(ctx context.Context, req *mcp.CallToolRequest, args StopActivityParams) (*mcp.CallToolResult, *StopActivityResult, error) {
+	stopped, err := service.StopTimer()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to stop activity: %w", err)
+	}
+
+	result := make([]TimeLogEntry, 0, len(stopped))
+	for i := range stopped {
+		result = append(result, timeLogEntry(&stopped[i]))
+	}
+	response := &StopActivityResult{
+		Stopped: result,
+		Count:   len(result),
+	}
+	summaryText := fmt.Sprintf("Stopped %d running time logs", len(result))
+	return formatMCPResponse(summaryText, response)
+}
+
+func (s *TimelogMCPServer) CreateTask(ctx context.Context, req *mcp.CallToolRequest, args CreateTaskParams) (*mcp.CallToolResult, *TaskResult, error) {
+	title := strings.TrimSpace(args.Title)
+	if title == "" {
+		return nil, nil, fmt.Errorf("title is required")
+	}
+	if args.EstimatedMinutes < 0 {
+		return nil, nil, fmt.Errorf("estimated_minutes must not be negative")
+	}
+	if err := ensureCategory(args.CategoryID); err != nil {
+		return nil, nil, err
+	}
+	due, err := time.Parse("2006-01-02", args.DueDate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid due_date %q, expected YYYY-MM-DD", args.DueDate)
+	}
+
+	task := &gen.Task{
+		Title:            title,
+		Description:      args.Description,
+		CategoryID:       args.CategoryID,
+		DueDate:          due.Add(12 * time.Hour), // 与 Web 端一致，截止日期存为当天 12:00 UTC
+		EstimatedMinutes: args.EstimatedMinutes,
+	}
+	if err := service.CreateTask(task); err != nil {
+		LogMCPError("create_task", err, map[string]interface{}{"args": args})
+		return nil, nil, fmt.Errorf("failed to create task: %w", err)
+	}
+	created, err := service.GetTaskByID(*task.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load created task: %w", err)
+	}
+
+	response := &TaskResult{Task: taskEntry(created)}
+	summaryText := fmt.Sprintf("Created task %d: %s", *created.ID, created.Title)
+	return formatMCPResponse(summaryText, response)
+}
+
+func (s *TimelogMCPServer) CompleteTask(ctx context.Context, req *mcp.CallToolRequest, args TaskIDParams) (*mcp.CallToolResult, *TaskResult, error) {
+	if _, err := ensureTask(args.ID); err != nil {
+		return nil, nil, err
+	}
+	if err := service.MarkTaskAsCompleted(args.ID); err != nil {
+		return nil, nil, fmt.Errorf("failed to complete task: %w", err)
+	}
+	task, err := service.GetTaskWithActualByID(args.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load task: %w", err)
+	}
+
+	entry := taskEntry(&task.Task)
+	entry.ActualMinutes = &task.ActualMinutes
+	response := &TaskResult{Task: entry}
+	summaryText := fmt.Sprintf("Completed task %d: %s", args.ID, task.Title)
+	return formatMCPResponse(summaryText, response)
+}
+
+func (s *TimelogMCPServer) SuspendTask(ctx context.Context, req *mcp.CallToolRequest, args TaskIDParams) (*mcp.CallToolResult, *TaskResult, error) {
+	if _, err := ensureTask(args.ID); err != nil {
+		return nil, nil, err
+	}
+	if err := service.SuspendTask(args.ID); err != nil {
+		return nil, nil, fmt.Errorf("failed to suspend task: %w", err)
+	}
+	task, err := service.GetTaskByID(args.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load task: %w", err)
+	}
+
+	response := &TaskResult{Task: taskEntry(task)}
+	summaryText := fmt.Sprintf("Suspended task %d: %s", args.ID, task.Title)
+	return formatMCPResponse(summaryText, response)
+}
+
+func (s *TimelogMCPServer) CloseConstraint(ctx context.Context, req *mcp.CallToolRequest, args CloseConstraintParams) (*mcp.CallToolResult, *CloseConstraintResult, error) {
+	reason := strings.TrimSpace(args.EndReason)
+	if reason == "" {
+		return nil, nil, fmt.Errorf("end_reason is required")
+	}
+	constraint, err := service.GetConstraintByID(args.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("constraint %d not found", args.ID)
+	}
+	if constraint.IsActive != nil && !*constraint.IsActive {
+		return nil, nil, fmt.Errorf("constraint %d is already closed", args.ID)
+	}
+	if err := service.MarkConstraintAsCompleted(args.ID, reason); err != nil {
+		return nil, nil, fmt.Errorf("failed to close constraint: %w", err)
+	}
+
+	response := &CloseConstraintResult{
+		ID:          args.ID,
+		Description: constraint.Description,
+		EndReason:   reason,
+		IsActive:    false,
+	}
+	summaryText := fmt.Sprintf("Closed constraint %d", args.ID)
+	return formatMCPResponse(summaryText, response)
+}
+
+// registerWriteTools adds the mutating tools to the MCP server
+func (s *TimelogMCPServer) registerWriteTools(mcpServer *mcp.Server) {
+	mcp.AddTool(mcpServer, &mcp.Tool{
+		Name:        "create_timelog",
+		Description: "Create a time log. Rejected when it overlaps existing logs (per timelog.overlap_policy) or when another log is still running and end_time is omitted",
+	}, s.CreateTimeLog)
+
+	mcp.AddTool(mcpServer, &mcp.Tool{
+		Name:        "update_timelog",
+		Description: "Edit a time log; only the given fields change",
+	}, s.UpdateTimeLog)
+
+	mcp.AddTool(mcpServer, &mcp.Tool{
+		Name:        "start_activity",
+		Description: "Start tracking an activity now. Fails if one is running unless switch is true, which stops it first",
+	}, s.StartActivity)
+
+	mcp.AddTool(mcpServer, &mcp.Tool{
+		Name:        "stop_activity",
+		Description: "Stop the currently running activity",
+	}, s.StopActivity)
+
+	mcp.AddTool(mcpServer, &mcp.Tool{
+		Name:        "create_task",
+		Description: "Create a task with a due date and optional estimate",
+	}, s.CreateTask)
+
+	mcp.AddTool(mcpServer, &mcp.Tool{
+		Name:        "complete_task",
+		Description: "Mark a task as completed",
+	}, s.CompleteTask)
+
+	mcp.AddTool(mcpServer, &mcp.Tool{
+		Name:        "suspend_task",
+		Description: "Suspend a task so it no longer shows up as pending",
+	}, s.SuspendTask)
+
+	mcp.AddTool(mcpServer, &mcp.Tool{
+		Name:        "close_constraint",
+		Description: "End an active constraint with a reason",
+	}, s.CloseConstraint)
+}
@@ -4,11 +4,14 @@ import (
 	"os"
 
 	"github.com/blacksheepaul/timelog/core/config"
+	"github.com/blacksheepaul/timelog/mcp/handler"
 	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/service"
+	"gorm.io/gorm"
 )
 
-// NewTimelogMCPServer creates and initializes a new TimelogMCPServer instance
-func NewTimelogMCPServer() *TimelogMCPServer {
+// initMCP loads the configuration and opens the database for the standalone MCP server
+func initMCP() (*config.Config, *gorm.DB) {
 	// Initialize configuration
 	// Check for config path from environment variable first
 	configPath := os.Getenv("TIMELOG_CONFIG_PATH")
@@ -19,8 +22,8 @@ func NewTimelogMCPServer() *TimelogMCPServer {
 	cfg := config.GetConfig(configPath)
 
 	// Initialize MCP logger (file-only, configurable)
-	InitMCPLogger(cfg)
-	LogMCPDebug("MCP server initializing", map[string]interface{}{
+	mcpLogger := handler.InitMCPLogger(cfg)
+	handler.LogMCPDebug("MCP server initializing", map[string]interface{}{
 		"config_path":         configPath,
 		"mcp_logging_enabled": cfg.MCP.Enabled,
 	})
@@ -32,13 +35,12 @@ func NewTimelogMCPServer() *TimelogMCPServer {
 	// Pass nil for logger to avoid stdout output that interferes with MCP protocol
 	model.InitDao(cfg, nil)
 	dao := model.GetDao()
+	// Write tools reuse the service layer, which logs to the file-only MCP logger
+	service.InitService(mcpLogger, cfg)
 
-	LogMCPDebug("Database initialized", map[string]interface{}{
+	handler.LogMCPDebug("Database initialized", map[string]interface{}{
 		"database_path": cfg.Database.Host,
 	})
 
-	return &TimelogMCPServer{
-		db:     dao.Db(),
-		config: cfg,
-	}
+	return cfg, dao.Db()
 }
@@ -8,50 +8,20 @@ import (
 	"os"
 	"strings"
 
+	"github.com/blacksheepaul/timelog/mcp/handler"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-var server *TimelogMCPServer
-
 func main() {
-	server = NewTimelogMCPServer()
-
-	// Create MCP server with implementation
-	mcpServer := mcp.NewServer(&mcp.Implementation{
-		Name:    "timelog",
-		Version: "1.0.0",
-	}, nil)
-
-	mcp.AddTool(mcpServer, &mcp.Tool{
-		Name:        "get_timelogs_by_date_range",
-		Description: "Get time logs within a specific date range",
-	}, GetTimeLogsByDateRange)
-
-	mcp.AddTool(mcpServer, &mcp.Tool{
-		Name:        "get_tasks_by_status",
-		Description: "Get tasks filtered by completion status",
-	}, GetTasksByStatus)
-
-	mcp.AddTool(mcpServer, &mcp.Tool{
-		Name:        "get_current_activity",
-		Description: "Get currently active/running time logs",
-	}, GetCurrentActivity)
-
-	mcp.AddTool(mcpServer, &mcp.Tool{
-		Name:        "get_active_constraints",
-		Description: "To know self discipline and external conditions",
-	}, GetActiveConstraints)
+	cfg, db := initMCP()
 
-	mcp.AddTool(mcpServer, &mcp.Tool{
-		Name:        "get_date_info",
-		Description: "Get current date, time, today, yesterday, and this week's date range",
-	}, GetDateInfo)
+	mcpServer := handler.NewServer(db, cfg, cfg.MCP.AllowWrites)
 
-	transportMode := server.config.MCP.Transport
+	transportMode := cfg.MCP.Transport
 	switch transportMode {
 	case "http":
-		listenAddr := server.config.MCP.ListenAddr
-		token := server.config.MCP.Token
+		listenAddr := cfg.MCP.ListenAddr
+		token := cfg.MCP.Token
 
 		// Fail fast: require authentication token for HTTP transport
 		if token == "" {
@@ -60,7 +30,7 @@ func main() {
 			os.Exit(1)
 		}
 
-		handler := mcp.NewStreamableHTTPHandler(func(_ *http.Request) *mcp.Server {
+		streamHandler := mcp.NewStreamableHTTPHandler(func(_ *http.Request) *mcp.Server {
 			return mcpServer
 		}, nil)
 
@@ -85,7 +55,7 @@ func main() {
 				}
 			}
 
-			handler.ServeHTTP(w, r)
+			streamHandler.ServeHTTP(w, r)
 		})
 
 		httpServer := &http.Server{
@@ -94,14 +64,14 @@ func main() {
 		}
 
 		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			LogMCPError("http_server", err, map[string]interface{}{"addr": listenAddr})
+			handler.LogMCPError("http_server", err, map[string]interface{}{"addr": listenAddr})
 		}
 	default:
 		// Run MCP server - no logging to avoid stdout contamination
 		ctx := context.Background()
 		transport := &mcp.StdioTransport{}
 		if err := mcpServer.Run(ctx, transport); err != nil {
-			LogMCPError("stdio_server", err, nil)
+			handler.LogMCPError("stdio_server", err, nil)
 		}
 	}
 }
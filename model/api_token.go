@@ -0,0 +1,66 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIToken 长期有效的个人访问令牌，只保存令牌的 SHA-256
+type APIToken struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Name        string     `gorm:"column:name;not null" json:"name"`
+	TokenHash   string     `gorm:"column:token_hash;not null" json:"-"`
+	TokenPrefix string     `gorm:"column:token_prefix;not null" json:"token_prefix"` // 令牌开头几位，便于识别
+	Scope       string     `gorm:"column:scope;not null" json:"scope"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `gorm:"column:expires_at" json:"expires_at"`
+	LastUsedAt  *time.Time `gorm:"column:last_used_at" json:"last_used_at"`
+}
+
+func (APIToken) TableName() string {
+	return "api_tokens"
+}
+
+func CreateAPIToken(db *gorm.DB, token *APIToken) error {
+	return db.Create(token).Error
+}
+
+func ListAPITokens(db *gorm.DB) ([]APIToken, error) {
+	var tokens []APIToken
+	err := db.Order("created_at DESC, id DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+// GetAPITokenByHash 获取未过期的令牌
+func GetAPITokenByHash(db *gorm.DB, hash string, now time.Time) (*APIToken, error) {
+	var token APIToken
+	err := db.Where("token_hash = ? AND (expires_at IS NULL OR expires_at > ?)", hash, now.UTC()).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func TouchAPIToken(db *gorm.DB, id uint, lastUsedAt time.Time) error {
+	return db.Model(&APIToken{}).Where("id = ?", id).Update("last_used_at", lastUsedAt.UTC()).Error
+}
+
+// DeleteAPIToken 删除令牌，令牌不存在时返回 ErrRecordNotFound
+func DeleteAPIToken(db *gorm.DB, id uint) error {
+	result := db.Delete(&APIToken{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteStaleAPITokens 删除已过期的令牌，以及在 unusedSince 之后没有使用过的令牌，返回删除数量
+func DeleteStaleAPITokens(db *gorm.DB, now, unusedSince time.Time) (int64, error) {
+	result := db.Where("(expires_at IS NOT NULL AND expires_at <= ?) OR COALESCE(last_used_at, created_at) < ?",
+		now.UTC(), unusedSince.UTC()).Delete(&APIToken{})
+	return result.RowsAffected, result.Error
+}
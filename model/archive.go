@@ -0,0 +1,448 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model/gen"
+	"gorm.io/gorm"
+)
+
+// 归档导入模式
+const (
+	ArchiveImportRestore = "restore" // 保留原始ID恢复到空数据库
+	ArchiveImportMerge   = "merge"   // 合并到现有数据库，重新分配ID
+)
+
+// ErrInvalidArchive 归档内容不合法（ID 重复、引用缺失、分类成环等）
+var ErrInvalidArchive = errors.New("invalid archive")
+
+// ErrArchiveTargetNotEmpty 恢复模式要求数据库中没有时间日志、任务与约束
+var ErrArchiveTargetNotEmpty = errors.New("database is not empty")
+
+// ErrArchiveConflicts 合并的时间日志与现有日志冲突，整个导入已回滚
+var ErrArchiveConflicts = errors.New("archive conflicts with existing time logs")
+
+// errArchiveDryRun 用于在试运行结束时回滚事务
+var errArchiveDryRun = errors.New("archive import dry run")
+
+// archiveBatchSize 恢复模式批量插入的条数
+const archiveBatchSize = 100
+
+// ArchiveData 归档包含的全部数据（包括已软删除的记录），不包含 WebAuthn 凭据、会话与令牌
+type ArchiveData struct {
+	Categories  []gen.Category   `json:"categories"`
+	Constraints []gen.Constraint `json:"constraints"`
+	Tasks       []gen.Task       `json:"tasks"`
+	TimeLogs    []gen.Timelog    `json:"timelogs"`
+}
+
+// ArchiveImportCounts 单张表的导入结果
+// Existing 为合并模式下与现有记录匹配而未重复导入的条数，其中 Restored 为匹配到已软删除的分类并将其恢复的条数；
+// Remapped 为ID发生变化的条数
+type ArchiveImportCounts struct {
+	Total    int `json:"total"`
+	Created  int `json:"created"`
+	Existing int `json:"existing"`
+	Restored int `json:"restored,omitempty"`
+	Remapped int `json:"remapped"`
+}
+
+// ArchiveTimeLogConflict 合并时与现有数据冲突的时间日志
+// Overlaps 为重叠日志在数据库中的ID，也可能是本次导入先插入的记录；Rejected 为 true 时该日志不会被导入
+type ArchiveTimeLogConflict struct {
+	TimeLogID int32      `json:"timelog_id"` // 归档中的ID
+	StartTime time.Time  `json:"start_time"`
+	EndTime   *time.Time `json:"end_time"`
+	Message   string     `json:"message"`
+	Overlaps  []int32    `json:"overlaps,omitempty"`
+	Rejected  bool       `json:"rejected"`
+}
+
+// ArchiveTimeLogCheck 合并模式下插入每条未删除的时间日志前调用，没有冲突时返回 nil
+// 只有数据库错误才返回 error
+type ArchiveTimeLogCheck func(tx *gorm.DB, tl *gen.Timelog) (*ArchiveTimeLogConflict, error)
+
+// ArchiveImportReport 归档导入结果
+type ArchiveImportReport struct {
+	Mode             string                   `json:"mode"`
+	DryRun           bool                     `json:"dry_run"`
+	Categories       ArchiveImportCounts      `json:"categories"`
+	Constraints      ArchiveImportCounts      `json:"constraints"`
+	Tasks            ArchiveImportCounts      `json:"tasks"`
+	TimeLogs         ArchiveImportCounts      `json:"timelogs"`
+	TimeLogConflicts []ArchiveTimeLogConflict `json:"timelog_conflicts,omitempty"`
+}
+
+// rejectedTimeLogs 返回被拒绝导入的时间日志条数
+func (r *ArchiveImportReport) rejectedTimeLogs() int {
+	n := 0
+	for _, conflict := range r.TimeLogConflicts {
+		if conflict.Rejected {
+			n++
+		}
+	}
+	return n
+}
+
+// LoadArchiveData 读取所有分类、约束、任务与时间日志（包括已软删除的），按ID排序
+func LoadArchiveData(db *gorm.DB) (*ArchiveData, error) {
+	data := &ArchiveData{}
+	db = db.Unscoped().Order("id ASC").Session(&gorm.Session{})
+	if err := db.Find(&data.Categories).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Find(&data.Constraints).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Find(&data.Tasks).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Find(&data.TimeLogs).Error; err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ImportArchiveData 在单个事务中导入归档数据，dryRun 为 true 时执行全部检查与写入后回滚，只返回结果
+//   - restore：数据库中不能有时间日志、任务与约束（迁移预置的默认分类会被替换），所有记录保留原始ID
+//   - merge：按 名称+父分类 匹配分类，按 标题+分类+截止时间 匹配任务，按 开始时间+分类 匹配时间日志，
+//     按 描述+开始日期 匹配约束；已存在的记录不会被修改，其余记录以新ID插入，因此重复导入同一归档是幂等的。
+//     新插入的未删除时间日志先经过 check 检查，有被拒绝的日志时整个导入回滚并返回 ErrArchiveConflicts 与报告
+func ImportArchiveData(db *gorm.DB, data *ArchiveData, mode string, dryRun bool, check ArchiveTimeLogCheck) (*ArchiveImportReport, error) {
+	if mode != ArchiveImportRestore && mode != ArchiveImportMerge {
+		return nil, fmt.Errorf("unknown import mode %q", mode)
+	}
+	categories, err := validateArchiveData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ArchiveImportReport{
+		Mode:        mode,
+		DryRun:      dryRun,
+		Categories:  ArchiveImportCounts{Total: len(data.Categories)},
+		Constraints: ArchiveImportCounts{Total: len(data.Constraints)},
+		Tasks:       ArchiveImportCounts{Total: len(data.Tasks)},
+		TimeLogs:    ArchiveImportCounts{Total: len(data.TimeLogs)},
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if mode == ArchiveImportRestore {
+			err = restoreArchive(tx, data, categories, report)
+		} else {
+			err = mergeArchive(tx, data, categories, report, check)
+		}
+		if err != nil {
+			return err
+		}
+		if n := report.rejectedTimeLogs(); n > 0 && !dryRun {
+			return fmt.Errorf("%w: %d of %d time logs cannot be merged", ErrArchiveConflicts, n, report.TimeLogs.Total)
+		}
+		if dryRun {
+			return errArchiveDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, ErrArchiveConflicts) {
+		return report, err
+	}
+	if err != nil && !errors.Is(err, errArchiveDryRun) {
+		return nil, err
+	}
+	return report, nil
+}
+
+// validateArchiveData 检查ID唯一、引用完整且分类不成环，返回父分类在前的分类顺序
+func validateArchiveData(data *ArchiveData) ([]*gen.Category, error) {
+	categoryIDs := make(map[int32]*gen.Category, len(data.Categories))
+	for i := range data.Categories {
+		category := &data.Categories[i]
+		if category.ID == nil || categoryIDs[*category.ID] != nil {
+			return nil, fmt.Errorf("%w: category #%d has a missing or duplicate id", ErrInvalidArchive, i)
+		}
+		if category.Name == "" {
+			return nil, fmt.Errorf("%w: category %d has no name", ErrInvalidArchive, *category.ID)
+		}
+		categoryIDs[*category.ID] = category
+	}
+
+	children := make(map[int32][]*gen.Category)
+	var roots []*gen.Category
+	for i := range data.Categories {
+		category := &data.Categories[i]
+		if pid := category.ParentID; pid != nil && *pid != 0 {
+			if categoryIDs[*pid] == nil {
+				return nil, fmt.Errorf("%w: category %d references missing parent %d", ErrInvalidArchive, *category.ID, *pid)
+			}
+			children[*pid] = append(children[*pid], category)
+			continue
+		}
+		roots = append(roots, category)
+	}
+	ordered := make([]*gen.Category, 0, len(data.Categories))
+	for len(roots) > 0 {
+		category := roots[0]
+		roots = roots[1:]
+		ordered = append(ordered, category)
+		roots = append(roots, children[*category.ID]...)
+	}
+	if len(ordered) != len(data.Categories) {
+		return nil, fmt.Errorf("%w: category parents form a cycle", ErrInvalidArchive)
+	}
+
+	constraintIDs := make(map[int32]bool, len(data.Constraints))
+	for i, constraint := range data.Constraints {
+		if constraint.ID == nil || constraintIDs[*constraint.ID] {
+			return nil, fmt.Errorf("%w: constraint #%d has a missing or duplicate id", ErrInvalidArchive, i)
+		}
+		constraintIDs[*constraint.ID] = true
+	}
+
+	taskIDs := make(map[int32]bool, len(data.Tasks))
+	for i, task := range data.Tasks {
+		if task.ID == nil || taskIDs[*task.ID] {
+			return nil, fmt.Errorf("%w: task #%d has a missing or duplicate id", ErrInvalidArchive, i)
+		}
+		if categoryIDs[task.CategoryID] == nil {
+			return nil, fmt.Errorf("%w: task %d references missing category %d", ErrInvalidArchive, *task.ID, task.CategoryID)
+		}
+		taskIDs[*task.ID] = true
+	}
+
+	timelogIDs := make(map[int32]bool, len(data.TimeLogs))
+	for i, tl := range data.TimeLogs {
+		if tl.ID == nil || timelogIDs[*tl.ID] {
+			return nil, fmt.Errorf("%w: timelog #%d has a missing or duplicate id", ErrInvalidArchive, i)
+		}
+		if categoryIDs[tl.CategoryID] == nil {
+			return nil, fmt.Errorf("%w: timelog %d references missing category %d", ErrInvalidArchive, *tl.ID, tl.CategoryID)
+		}
+		if tl.TaskID != nil && !taskIDs[*tl.TaskID] {
+			return nil, fmt.Errorf("%w: timelog %d references missing task %d", ErrInvalidArchive, *tl.ID, *tl.TaskID)
+		}
+		timelogIDs[*tl.ID] = true
+	}
+	return ordered, nil
+}
+
+// placeArchiveCategory 按父分类计算 level 与 path，超过最大层级时返回错误
+func placeArchiveCategory(category *gen.Category, parent *gen.Category) error {
+	level := int32(0)
+	path := "/"
+	if parent != nil {
+		level = *parent.Level + 1
+		path = GetFullPath(parent)
+	}
+	if level > MaxCategoryLevel {
+		return fmt.Errorf("%w: category %d (%s) would be at level %d, exceeding max level %d (raise category.max_depth)",
+			ErrInvalidArchive, *category.ID, category.Name, level, MaxCategoryLevel)
+	}
+	category.Level = &level
+	category.Path = &path
+	return nil
+}
+
+// restoreArchive 替换默认分类后按原始ID插入全部记录
+func restoreArchive(tx *gorm.DB, data *ArchiveData, categories []*gen.Category, report *ArchiveImportReport) error {
+	for _, table := range []interface{}{&gen.Timelog{}, &gen.Task{}, &gen.Constraint{}} {
+		var count int64
+		if err := tx.Unscoped().Model(table).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("%w: restore requires a database without time logs, tasks or constraints, use merge instead", ErrArchiveTargetNotEmpty)
+		}
+	}
+	if err := tx.Unscoped().Where("1 = 1").Delete(&gen.Category{}).Error; err != nil {
+		return err
+	}
+
+	placed := make(map[int32]*gen.Category, len(categories))
+	for _, source := range categories {
+		category := *source
+		var parent *gen.Category
+		if category.ParentID != nil && *category.ParentID != 0 {
+			parent = placed[*category.ParentID]
+		} else {
+			category.ParentID = nil
+		}
+		if err := placeArchiveCategory(&category, parent); err != nil {
+			return err
+		}
+		if err := tx.Create(&category).Error; err != nil {
+			return fmt.Errorf("failed to restore category %d: %w", *category.ID, err)
+		}
+		placed[*category.ID] = &category
+	}
+	report.Categories.Created = len(categories)
+
+	if len(data.Constraints) > 0 {
+		if err := tx.CreateInBatches(data.Constraints, archiveBatchSize).Error; err != nil {
+			return fmt.Errorf("failed to restore constraints: %w", err)
+		}
+	}
+	if len(data.Tasks) > 0 {
+		if err := tx.CreateInBatches(data.Tasks, archiveBatchSize).Error; err != nil {
+			return fmt.Errorf("failed to restore tasks: %w", err)
+		}
+	}
+	if len(data.TimeLogs) > 0 {
+		if err := tx.CreateInBatches(data.TimeLogs, archiveBatchSize).Error; err != nil {
+			return fmt.Errorf("failed to restore timelogs: %w", err)
+		}
+	}
+	report.Constraints.Created = len(data.Constraints)
+	report.Tasks.Created = len(data.Tasks)
+	report.TimeLogs.Created = len(data.TimeLogs)
+	return nil
+}
+
+// mergeArchive 按自然键匹配现有记录，未匹配的记录以新ID插入并重写引用
+func mergeArchive(tx *gorm.DB, data *ArchiveData, categories []*gen.Category, report *ArchiveImportReport, check ArchiveTimeLogCheck) error {
+	// 冲突检查只针对未删除的日志，使用不含 Unscoped 的事务
+	scoped := tx
+	// 软删除的记录同样参与匹配：(name, parent_id) 的唯一约束对其同样生效
+	// 时间列用 julianday 比较，旧数据中 2026-10-01 与 2026-10-01T00:00:00Z 等不同的文本格式视为同一时间
+	tx = tx.Unscoped().Session(&gorm.Session{})
+
+	categoryMap := make(map[int32]*gen.Category, len(categories))
+	for _, source := range categories {
+		var parent *gen.Category
+		if source.ParentID != nil && *source.ParentID != 0 {
+			parent = categoryMap[*source.ParentID]
+		}
+		var parentID *int32
+		if parent != nil {
+			parentID = parent.ID
+		}
+
+		// 优先匹配未删除的分类；只有已软删除的同名分类时恢复它，否则导入的数据会挂在不可见的分类下
+		existing, err := GetCategoryByName(tx.Order("deleted_at IS NOT NULL"), source.Name, parentID)
+		if err == nil {
+			if existing.DeletedAt.Valid && !source.DeletedAt.Valid {
+				if err := tx.Model(&gen.Category{}).Where("id = ?", *existing.ID).Update("deleted_at", nil).Error; err != nil {
+					return err
+				}
+				existing.DeletedAt = gorm.DeletedAt{}
+				report.Categories.Restored++
+			}
+			categoryMap[*source.ID] = existing
+			countArchiveRecord(&report.Categories, false, *source.ID, *existing.ID)
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		category := *source
+		category.ParentID = parentID
+		if err := placeArchiveCategory(&category, parent); err != nil {
+			return err
+		}
+		category.ID = nil
+		if err := tx.Create(&category).Error; err != nil {
+			return fmt.Errorf("failed to import category %d: %w", *source.ID, err)
+		}
+		categoryMap[*source.ID] = &category
+		countArchiveRecord(&report.Categories, true, *source.ID, *category.ID)
+	}
+
+	for _, source := range data.Constraints {
+		var existing gen.Constraint
+		err := tx.Where("description = ? AND julianday(start_date) = julianday(?)", source.Description, source.StartDate).First(&existing).Error
+		if err == nil {
+			countArchiveRecord(&report.Constraints, false, *source.ID, *existing.ID)
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		constraint := source
+		constraint.ID = nil
+		if err := tx.Create(&constraint).Error; err != nil {
+			return fmt.Errorf("failed to import constraint %d: %w", *source.ID, err)
+		}
+		countArchiveRecord(&report.Constraints, true, *source.ID, *constraint.ID)
+	}
+
+	taskMap := make(map[int32]int32, len(data.Tasks))
+	for _, source := range data.Tasks {
+		categoryID := *categoryMap[source.CategoryID].ID
+		var existing gen.Task
+		err := tx.Where("title = ? AND category_id = ? AND julianday(due_date) = julianday(?)", source.Title, categoryID, source.DueDate).First(&existing).Error
+		if err == nil {
+			taskMap[*source.ID] = *existing.ID
+			countArchiveRecord(&report.Tasks, false, *source.ID, *existing.ID)
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		task := source
+		task.ID = nil
+		task.CategoryID = categoryID
+		if err := tx.Create(&task).Error; err != nil {
+			return fmt.Errorf("failed to import task %d: %w", *source.ID, err)
+		}
+		taskMap[*source.ID] = *task.ID
+		countArchiveRecord(&report.Tasks, true, *source.ID, *task.ID)
+	}
+
+	// 时间日志按开始时间导入，便于阅读试运行结果
+	timelogs := make([]gen.Timelog, len(data.TimeLogs))
+	copy(timelogs, data.TimeLogs)
+	sort.SliceStable(timelogs, func(i, j int) bool { return timelogs[i].StartTime.Before(timelogs[j].StartTime) })
+	for _, source := range timelogs {
+		categoryID := *categoryMap[source.CategoryID].ID
+		var existing gen.Timelog
+		err := tx.Where("julianday(start_time) = julianday(?) AND category_id = ?", source.StartTime, categoryID).First(&existing).Error
+		if err == nil {
+			countArchiveRecord(&report.TimeLogs, false, *source.ID, *existing.ID)
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		tl := source
+		tl.ID = nil
+		tl.CategoryID = categoryID
+		if source.TaskID != nil {
+			taskID := taskMap[*source.TaskID]
+			tl.TaskID = &taskID
+		}
+		if check != nil && !tl.DeletedAt.Valid {
+			conflict, err := check(scoped, &tl)
+			if err != nil {
+				return err
+			}
+			if conflict != nil {
+				conflict.TimeLogID = *source.ID
+				conflict.StartTime = source.StartTime
+				conflict.EndTime = source.EndTime
+				report.TimeLogConflicts = append(report.TimeLogConflicts, *conflict)
+				if conflict.Rejected {
+					continue
+				}
+			}
+		}
+		if err := tx.Create(&tl).Error; err != nil {
+			return fmt.Errorf("failed to import timelog %d: %w", *source.ID, err)
+		}
+		countArchiveRecord(&report.TimeLogs, true, *source.ID, *tl.ID)
+	}
+	return nil
+}
+
+func countArchiveRecord(counts *ArchiveImportCounts, created bool, oldID, newID int32) {
+	if created {
+		counts.Created++
+	} else {
+		counts.Existing++
+	}
+	if oldID != newID {
+		counts.Remapped++
+	}
+}
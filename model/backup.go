@@ -0,0 +1,51 @@
+package model
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"gorm.io/gorm"
+)
+
+// VacuumInto 使用 VACUUM INTO 将数据库在线写出为一个完整、紧凑的快照文件，path 必须不存在
+func VacuumInto(db *gorm.DB, path string) error {
+	return db.Exec("VACUUM INTO ?", path).Error
+}
+
+// VerifySQLiteFile 以只读方式打开 SQLite 文件，检查完整性以及迁移版本是否可被当前二进制使用
+func VerifySQLiteFile(path string) error {
+	db, err := sql.Open("sqlite3", "file:"+url.PathEscape(path)+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var result string
+	if err := db.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return fmt.Errorf("integrity check failed: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("integrity check failed: %s", result)
+	}
+
+	var version uint
+	var dirty bool
+	// 没有版本记录的数据库视为版本 0
+	err = db.QueryRow("SELECT version, dirty FROM "+migrationsTable+" LIMIT 1").Scan(&version, &dirty)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if dirty {
+		return fmt.Errorf("%w (version %d)", ErrSchemaDirty, version)
+	}
+	latest, err := LatestMigrationVersion()
+	if err != nil {
+		return err
+	}
+	if version > latest {
+		return fmt.Errorf("%w (snapshot version %d, binary supports up to %d)", ErrSchemaTooNew, version, latest)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CalendarFeed 可订阅的 iCalendar 订阅源，订阅地址中的令牌只保存 SHA-256
+type CalendarFeed struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Name        string     `gorm:"column:name;not null" json:"name"`
+	Kind        string     `gorm:"column:kind;not null" json:"kind"` // timelogs|tasks
+	TokenHash   string     `gorm:"column:token_hash;not null" json:"-"`
+	TokenPrefix string     `gorm:"column:token_prefix;not null" json:"token_prefix"` // 令牌开头几位，便于识别
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  *time.Time `gorm:"column:last_used_at" json:"last_used_at"`
+}
+
+func (CalendarFeed) TableName() string {
+	return "calendar_feeds"
+}
+
+func CreateCalendarFeed(db *gorm.DB, feed *CalendarFeed) error {
+	return db.Create(feed).Error
+}
+
+func ListCalendarFeeds(db *gorm.DB) ([]CalendarFeed, error) {
+	var feeds []CalendarFeed
+	err := db.Order("created_at DESC, id DESC").Find(&feeds).Error
+	return feeds, err
+}
+
+// GetCalendarFeedByHash 获取指定类型的订阅源，令牌属于其他类型的订阅源时同样返回 ErrRecordNotFound
+func GetCalendarFeedByHash(db *gorm.DB, hash, kind string) (*CalendarFeed, error) {
+	var feed CalendarFeed
+	if err := db.Where("token_hash = ? AND kind = ?", hash, kind).First(&feed).Error; err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+func TouchCalendarFeed(db *gorm.DB, id uint, lastUsedAt time.Time) error {
+	return db.Model(&CalendarFeed{}).Where("id = ?", id).Update("last_used_at", lastUsedAt.UTC()).Error
+}
+
+// DeleteCalendarFeed 删除订阅源，订阅源不存在时返回 ErrRecordNotFound
+func DeleteCalendarFeed(db *gorm.DB, id uint) error {
+	result := db.Delete(&CalendarFeed{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
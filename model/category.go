@@ -1,13 +1,32 @@
 package model
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/blacksheepaul/timelog/model/gen"
 	"gorm.io/gorm"
 )
 
-const MaxCategoryLevel = 2 // 最大层级：0, 1, 2
+// DefaultCategoryMaxDepth 未配置时分类树的层数
+const DefaultCategoryMaxDepth = 3
+
+// MaxCategoryLevel 允许的最大层级（根分类为 0），由 category.max_depth 配置
+var MaxCategoryLevel int32 = DefaultCategoryMaxDepth - 1
+
+// SetCategoryMaxDepth 设置分类树的最大层数，小于 1 时使用默认值
+func SetCategoryMaxDepth(depth int) {
+	if depth < 1 {
+		depth = DefaultCategoryMaxDepth
+	}
+	MaxCategoryLevel = int32(depth - 1)
+}
+
+// ErrInvalidCategoryTarget 合并/删除分类时目标分类不合法（为自身或自身的后代，或转移后超过最大层级）
+var ErrInvalidCategoryTarget = errors.New("invalid target category")
+
+// ErrInvalidCategoryOrder 排序列表不是同一父分类下的完整子分类集合
+var ErrInvalidCategoryOrder = errors.New("invalid category order")
 
 // ValidateLevel 验证分类层级是否合法
 func ValidateLevel(level int32) error {
@@ -101,19 +120,29 @@ func GetCategoriesByParentID(db *gorm.DB, parentID *int32) ([]gen.Category, erro
 }
 
 // UpdateCategory 更新分类（不允许更改层级结构）
+// 名称变更时同步更新所有后代分类的 path
 func UpdateCategory(db *gorm.DB, category *gen.Category) error {
-	// 获取原分类信息，防止更改层级
-	existing, err := GetCategoryByID(db, *category.ID)
-	if err != nil {
-		return err
-	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		// 获取原分类信息，防止更改层级
+		existing, err := GetCategoryByID(tx, *category.ID)
+		if err != nil {
+			return err
+		}
 
-	// 保留层级相关信息，只允许更新基本属性
-	category.Level = existing.Level
-	category.ParentID = existing.ParentID
-	category.Path = existing.Path
+		// 保留层级相关信息与归档状态，只允许更新基本属性；归档状态只能通过归档/取消归档修改
+		category.Level = existing.Level
+		category.ParentID = existing.ParentID
+		category.Path = existing.Path
+		category.ArchivedAt = existing.ArchivedAt
 
-	return db.Save(category).Error
+		if err := tx.Save(category).Error; err != nil {
+			return err
+		}
+		if category.Name == existing.Name {
+			return nil
+		}
+		return relocateChildren(tx, category)
+	})
 }
 
 // getAllDescendantIDs 获取分类及其所有后代的ID（使用ID-based递归查询）
@@ -136,6 +165,11 @@ func getAllDescendantIDs(db *gorm.DB, categoryID int32) ([]int32, error) {
 	return ids, nil
 }
 
+// GetCategoryDescendantIDs 获取分类及其整棵子树的ID
+func GetCategoryDescendantIDs(db *gorm.DB, categoryID int32) ([]int32, error) {
+	return getAllDescendantIDs(db, categoryID)
+}
+
 // isDescendantOf 检查targetID是否是ancestorID的后代
 func isDescendantOf(db *gorm.DB, targetID, ancestorID int32) (bool, error) {
 	if targetID == ancestorID {
@@ -184,14 +218,18 @@ func MoveCategory(db *gorm.DB, categoryID int32, newParentID *int32) error {
 			}
 
 			newLevel = *parent.Level + 1
-			if newLevel > MaxCategoryLevel {
-				return fmt.Errorf("cannot move category: would exceed max level %d", MaxCategoryLevel)
-			}
 			parentPath := GetFullPath(parent)
 			newPath = parentPath
 		}
 
-		oldPath := GetFullPath(category)
+		// 整棵子树都要落在最大层级内
+		height, err := subtreeHeight(tx, categoryID)
+		if err != nil {
+			return err
+		}
+		if newLevel+height > MaxCategoryLevel {
+			return fmt.Errorf("cannot move category: would exceed max level %d", MaxCategoryLevel)
+		}
 
 		category.ParentID = newParentID
 		category.Level = &newLevel
@@ -200,12 +238,219 @@ func MoveCategory(db *gorm.DB, categoryID int32, newParentID *int32) error {
 		if err := tx.Save(category).Error; err != nil {
 			return err
 		}
+		return relocateChildren(tx, category)
+	})
+}
+
+// ReorderCategories 按给定顺序重写兄弟分类的 sort_order（单个事务）
+// ids 必须包含同一父分类下全部未归档的子分类，已归档的子分类可选；靠前的分类 sort_order 更大，与列表的 sort_order DESC 排序一致
+func ReorderCategories(db *gorm.DB, ids []int32) error {
+	if len(ids) == 0 {
+		return fmt.Errorf("%w: ids must not be empty", ErrInvalidCategoryOrder)
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		first, err := GetCategoryByID(tx, ids[0])
+		if err != nil {
+			return err
+		}
+		siblings, err := GetCategoriesByParentID(tx, first.ParentID)
+		if err != nil {
+			return err
+		}
+		hidden, err := ArchivedCategoryIDs(tx)
+		if err != nil {
+			return err
+		}
+		pending := make(map[int32]bool, len(siblings))
+		for _, sibling := range siblings {
+			pending[*sibling.ID] = true
+		}
+		for _, id := range ids {
+			if !pending[id] {
+				return fmt.Errorf("%w: category %d is not a sibling or is listed twice", ErrInvalidCategoryOrder, id)
+			}
+			delete(pending, id)
+		}
+		for id := range pending {
+			if !hidden[id] {
+				return fmt.Errorf("%w: sibling %d is missing", ErrInvalidCategoryOrder, id)
+			}
+		}
 
-		newFullPath := GetFullPath(category)
-		return tx.Model(&gen.Category{}).Where("path LIKE ?", oldPath+"%").Update("path", gorm.Expr("REPLACE(path, ?, ?)", oldPath, newFullPath)).Error
+		for i, id := range ids {
+			if err := tx.Model(&gen.Category{}).Where("id = ?", id).Update("sort_order", len(ids)-i).Error; err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 }
 
+// CategoryMergeResult 合并分类时转移的数据量
+type CategoryMergeResult struct {
+	TimeLogs         int64 `json:"timelogs"`
+	Tasks            int64 `json:"tasks"`
+	MovedCategories  int   `json:"moved_categories"`  // 移动到目标分类下的子分类数
+	MergedCategories int   `json:"merged_categories"` // 被合并并删除的分类数（包含源分类）
+}
+
+// MergeCategory 将源分类合并到目标分类（单个事务）
+// 源分类的时间日志、任务转移到目标分类，子分类移动到目标分类下；
+// 目标分类已有同名子分类时递归合并，最后物理删除源分类
+func MergeCategory(db *gorm.DB, sourceID, targetID int32) (*CategoryMergeResult, error) {
+	result := &CategoryMergeResult{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if sourceID == targetID {
+			return fmt.Errorf("%w: cannot merge category into itself", ErrInvalidCategoryTarget)
+		}
+		if _, err := GetCategoryByID(tx, sourceID); err != nil {
+			return err
+		}
+		if _, err := GetCategoryByID(tx, targetID); err != nil {
+			return err
+		}
+		isDescendant, err := isDescendantOf(tx, targetID, sourceID)
+		if err != nil {
+			return err
+		}
+		if isDescendant {
+			return fmt.Errorf("%w: target is a descendant of the source category", ErrInvalidCategoryTarget)
+		}
+		return mergeCategory(tx, sourceID, targetID, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func mergeCategory(tx *gorm.DB, sourceID, targetID int32, result *CategoryMergeResult) error {
+	// 递归合并同名子分类时目标可能是已软删除的分类
+	var target gen.Category
+	if err := tx.Unscoped().First(&target, targetID).Error; err != nil {
+		return err
+	}
+
+	// 已软删除的记录同样引用该分类，一并转移
+	moved := tx.Unscoped().Model(&gen.Timelog{}).Where("category_id = ?", sourceID).Update("category_id", targetID)
+	if moved.Error != nil {
+		return moved.Error
+	}
+	result.TimeLogs += moved.RowsAffected
+	moved = tx.Unscoped().Model(&gen.Task{}).Where("category_id = ?", sourceID).Update("category_id", targetID)
+	if moved.Error != nil {
+		return moved.Error
+	}
+	result.Tasks += moved.RowsAffected
+
+	// 已软删除的子分类也要转移，否则删除源分类时会被级联删除
+	var children []gen.Category
+	if err := tx.Unscoped().Where("parent_id = ?", sourceID).Find(&children).Error; err != nil {
+		return err
+	}
+	// 先摘下子分类再删除源分类，避免 parent_id 的级联删除以及 UNIQUE(name, parent_id) 冲突
+	if err := tx.Unscoped().Model(&gen.Category{}).Where("parent_id = ?", sourceID).Update("parent_id", nil).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Delete(&gen.Category{}, sourceID).Error; err != nil {
+		return err
+	}
+	result.MergedCategories++
+
+	for i := range children {
+		child := &children[i]
+		// UNIQUE(name, parent_id) 同样约束已软删除的分类，按名称匹配时需要包含它们
+		existing, err := GetCategoryByName(tx.Unscoped(), child.Name, target.ID)
+		if err == nil {
+			// 同名分类已软删除而子分类仍在使用时恢复它，否则合并过去的数据会从所有列表中消失
+			if existing.DeletedAt.Valid && !child.DeletedAt.Valid {
+				if err := tx.Unscoped().Model(&gen.Category{}).Where("id = ?", *existing.ID).Update("deleted_at", nil).Error; err != nil {
+					return err
+				}
+			}
+			if err := mergeCategory(tx, *child.ID, *existing.ID, result); err != nil {
+				return err
+			}
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		// 已软删除的子分类不再显示，只需跟随转移，不受最大层级限制
+		if !child.DeletedAt.Valid {
+			height, err := subtreeHeight(tx, *child.ID)
+			if err != nil {
+				return err
+			}
+			if *target.Level+1+height > MaxCategoryLevel {
+				return fmt.Errorf("%w: cannot move category %q under %q: would exceed max level %d",
+					ErrInvalidCategoryTarget, child.Name, target.Name, MaxCategoryLevel)
+			}
+			result.MovedCategories++
+		}
+		child.ParentID = target.ID
+		if err := relocateSubtree(tx.Unscoped(), child, &target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// subtreeHeight 返回分类子树的高度，叶子分类为 0
+func subtreeHeight(db *gorm.DB, categoryID int32) (int32, error) {
+	var children []gen.Category
+	if err := db.Where("parent_id = ?", categoryID).Find(&children).Error; err != nil {
+		return 0, err
+	}
+	height := int32(0)
+	for _, child := range children {
+		h, err := subtreeHeight(db, *child.ID)
+		if err != nil {
+			return 0, err
+		}
+		if h+1 > height {
+			height = h + 1
+		}
+	}
+	return height, nil
+}
+
+// relocateSubtree 按父分类重新计算分类及其所有后代（包括已软删除的）的 level 与 path
+func relocateSubtree(db *gorm.DB, category *gen.Category, parent *gen.Category) error {
+	level := int32(0)
+	path := "/"
+	if parent != nil {
+		level = *parent.Level + 1
+		path = GetFullPath(parent)
+	}
+	category.Level = &level
+	category.Path = &path
+	if err := db.Unscoped().Model(&gen.Category{}).Where("id = ?", *category.ID).Updates(map[string]interface{}{
+		"parent_id": category.ParentID,
+		"level":     level,
+		"path":      path,
+	}).Error; err != nil {
+		return err
+	}
+	return relocateChildren(db, category)
+}
+
+// relocateChildren 按 parent 当前的 level 与 path 重新计算其所有后代（按 parent_id 逐层定位，而非按 path 前缀匹配）
+// 已软删除的后代同样更新，否则它们被恢复时会带着过期的 path
+func relocateChildren(db *gorm.DB, parent *gen.Category) error {
+	var children []gen.Category
+	if err := db.Unscoped().Where("parent_id = ?", *parent.ID).Find(&children).Error; err != nil {
+		return err
+	}
+	for i := range children {
+		if err := relocateSubtree(db, &children[i], parent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetCategoryTree 获取分类树
 func GetCategoryTree(db *gorm.DB) ([]*CategoryNode, error) {
 	categories, err := ListCategories(db)
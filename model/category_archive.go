@@ -0,0 +1,111 @@
+package model
+
+import (
+	"time"
+
+	"github.com/blacksheepaul/timelog/model/gen"
+	"gorm.io/gorm"
+)
+
+// ArchiveCategory 归档分类，已归档的分类保留原归档时间
+func ArchiveCategory(db *gorm.DB, id int32, now time.Time) (*gen.Category, error) {
+	category, err := GetCategoryByID(db, id)
+	if err != nil {
+		return nil, err
+	}
+	if category.ArchivedAt != nil {
+		return category, nil
+	}
+	if err := db.Model(&gen.Category{}).Where("id = ?", id).Update("archived_at", now).Error; err != nil {
+		return nil, err
+	}
+	return GetCategoryByID(db, id)
+}
+
+// UnarchiveCategory 取消归档；祖先分类仍处于归档状态时该分类依然隐藏
+func UnarchiveCategory(db *gorm.DB, id int32) (*gen.Category, error) {
+	if _, err := GetCategoryByID(db, id); err != nil {
+		return nil, err
+	}
+	if err := db.Model(&gen.Category{}).Where("id = ?", id).Update("archived_at", nil).Error; err != nil {
+		return nil, err
+	}
+	return GetCategoryByID(db, id)
+}
+
+// ArchivedCategoryIDs 返回已归档的分类及其整棵子树的ID
+func ArchivedCategoryIDs(db *gorm.DB) (map[int32]bool, error) {
+	var categories []gen.Category
+	if err := db.Select("id", "parent_id", "archived_at").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	children := make(map[int32][]int32)
+	var archived []int32
+	for _, category := range categories {
+		if category.ParentID != nil {
+			children[*category.ParentID] = append(children[*category.ParentID], *category.ID)
+		}
+		if category.ArchivedAt != nil {
+			archived = append(archived, *category.ID)
+		}
+	}
+
+	hidden := make(map[int32]bool)
+	for len(archived) > 0 {
+		id := archived[len(archived)-1]
+		archived = archived[:len(archived)-1]
+		if hidden[id] {
+			continue
+		}
+		hidden[id] = true
+		archived = append(archived, children[id]...)
+	}
+	return hidden, nil
+}
+
+// IsCategoryArchived 判断分类本身或其任一祖先是否已归档
+func IsCategoryArchived(db *gorm.DB, id int32) (bool, error) {
+	category, err := GetCategoryByID(db, id)
+	if err != nil {
+		return false, err
+	}
+	if category.ArchivedAt != nil {
+		return true, nil
+	}
+	if category.ParentID == nil || *category.ParentID == 0 {
+		return false, nil
+	}
+	return IsCategoryArchived(db, *category.ParentID)
+}
+
+// ExcludeArchivedCategories 去掉已归档的分类及其子树
+func ExcludeArchivedCategories(db *gorm.DB, categories []gen.Category) ([]gen.Category, error) {
+	hidden, err := ArchivedCategoryIDs(db)
+	if err != nil {
+		return nil, err
+	}
+	if len(hidden) == 0 {
+		return categories, nil
+	}
+	active := make([]gen.Category, 0, len(categories))
+	for _, category := range categories {
+		if !hidden[*category.ID] {
+			active = append(active, category)
+		}
+	}
+	return active, nil
+}
+
+// GetActiveCategoryTree 获取不含已归档分类（及其子树）的分类树
+func GetActiveCategoryTree(db *gorm.DB) ([]*CategoryNode, error) {
+	categories, err := ListCategories(db)
+	if err != nil {
+		return nil, err
+	}
+	active, err := ExcludeArchivedCategories(db, categories)
+	if err != nil {
+		return nil, err
+	}
+	return buildCategoryTree(active), nil
+}
@@ -0,0 +1,169 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/blacksheepaul/timelog/model/gen"
+	"gorm.io/gorm"
+)
+
+// CategoryRepair 一个分类修复前后的层级信息
+type CategoryRepair struct {
+	CategoryID  int32  `json:"category_id"`
+	Name        string `json:"name"`
+	Reason      string `json:"reason"`
+	OldParentID *int32 `json:"old_parent_id"`
+	NewParentID *int32 `json:"new_parent_id"`
+	OldLevel    int32  `json:"old_level"`
+	NewLevel    int32  `json:"new_level"`
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	Deleted     bool   `json:"deleted,omitempty"` // 已软删除的分类
+}
+
+// CategoryRepairReport 分类层级完整性检查结果
+type CategoryRepairReport struct {
+	Checked int              `json:"checked"`
+	DryRun  bool             `json:"dry_run"`
+	Repairs []CategoryRepair `json:"repairs"`
+}
+
+// 修复原因
+const (
+	CategoryRepairStaleLevelPath = "stale_level_or_path" // level/path 与 parent_id 不一致
+	CategoryRepairMissingParent  = "missing_parent"      // 父分类不存在或已删除，提升为根分类
+	CategoryRepairCycle          = "cycle"               // parent_id 成环，断开后提升为根分类
+)
+
+// RepairCategoryHierarchy 以 parent_id 为准重新计算所有分类（包括已软删除的）的 level 与 path（单个事务）
+// 父分类缺失或成环的分类提升为根分类，未删除的分类挂在已删除的父分类下时同样视为父分类缺失；
+// dryRun 为 true 时只返回差异不写入
+func RepairCategoryHierarchy(db *gorm.DB, dryRun bool) (*CategoryRepairReport, error) {
+	report := &CategoryRepairReport{DryRun: dryRun, Repairs: []CategoryRepair{}}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var categories []gen.Category
+		if err := tx.Unscoped().Order("id ASC").Find(&categories).Error; err != nil {
+			return err
+		}
+		report.Checked = len(categories)
+
+		byID := make(map[int32]*gen.Category, len(categories))
+		children := make(map[int32][]*gen.Category)
+		for i := range categories {
+			byID[*categories[i].ID] = &categories[i]
+		}
+		// hasParent 判断分类能否挂在 parent_id 指向的分类下
+		hasParent := func(category *gen.Category) bool {
+			parent, ok := byID[*category.ParentID]
+			return ok && (!parent.DeletedAt.Valid || category.DeletedAt.Valid)
+		}
+		for i := range categories {
+			if pid := categories[i].ParentID; pid != nil && *pid != 0 && hasParent(&categories[i]) {
+				children[*pid] = append(children[*pid], &categories[i])
+			}
+		}
+		for _, list := range children {
+			sort.Slice(list, func(i, j int) bool { return *list[i].ID < *list[j].ID })
+		}
+
+		visited := make(map[int32]bool, len(categories))
+		var walk func(category *gen.Category, parent *gen.Category, reason string) error
+		walk = func(category *gen.Category, parent *gen.Category, reason string) error {
+			visited[*category.ID] = true
+
+			var newParentID *int32
+			newLevel := int32(0)
+			newPath := "/"
+			if parent != nil {
+				newParentID = parent.ID
+				newLevel = *parent.Level + 1
+				newPath = GetFullPath(parent)
+			}
+			if newLevel > MaxCategoryLevel {
+				return fmt.Errorf("category %d (%s) would be at level %d, exceeding max level %d", *category.ID, category.Name, newLevel, MaxCategoryLevel)
+			}
+
+			oldLevel := int32(0)
+			if category.Level != nil {
+				oldLevel = *category.Level
+			}
+			oldPath := ""
+			if category.Path != nil {
+				oldPath = *category.Path
+			}
+			if reason == "" && (oldLevel != newLevel || oldPath != newPath) {
+				reason = CategoryRepairStaleLevelPath
+			}
+			if reason != "" {
+				report.Repairs = append(report.Repairs, CategoryRepair{
+					CategoryID:  *category.ID,
+					Name:        category.Name,
+					Reason:      reason,
+					OldParentID: category.ParentID,
+					NewParentID: newParentID,
+					OldLevel:    oldLevel,
+					NewLevel:    newLevel,
+					OldPath:     oldPath,
+					NewPath:     newPath,
+					Deleted:     category.DeletedAt.Valid,
+				})
+				if !dryRun {
+					if err := tx.Unscoped().Model(&gen.Category{}).Where("id = ?", *category.ID).Updates(map[string]interface{}{
+						"parent_id": newParentID,
+						"level":     newLevel,
+						"path":      newPath,
+					}).Error; err != nil {
+						return err
+					}
+				}
+			}
+
+			category.ParentID = newParentID
+			category.Level = &newLevel
+			category.Path = &newPath
+			for _, child := range children[*category.ID] {
+				if visited[*child.ID] {
+					continue
+				}
+				if err := walk(child, category, ""); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		for i := range categories {
+			category := &categories[i]
+			if visited[*category.ID] {
+				continue
+			}
+			pid := category.ParentID
+			if pid == nil || *pid == 0 {
+				if err := walk(category, nil, ""); err != nil {
+					return err
+				}
+				continue
+			}
+			if !hasParent(category) {
+				if err := walk(category, nil, CategoryRepairMissingParent); err != nil {
+					return err
+				}
+			}
+		}
+
+		// 剩下未访问到的分类都在环上，从 ID 最小的一个断开
+		for i := range categories {
+			if !visited[*categories[i].ID] {
+				if err := walk(&categories[i], nil, CategoryRepairCycle); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
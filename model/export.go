@@ -0,0 +1,88 @@
+package model
+
+import (
+	"time"
+
+	"github.com/blacksheepaul/timelog/model/gen"
+	"gorm.io/gorm"
+)
+
+// exportBatchSize 逐批读取时每批的行数
+// 每批读完即释放游标，回调写出数据时不占用连接，也不阻塞其他写入
+const exportBatchSize = 500
+
+// EachTimeLog 按开始时间顺序逐条读取 [start, end) 内开始的时间日志，不一次性载入内存
+// start/end 为空时不限制对应一端；fn 返回错误时停止读取
+func EachTimeLog(db *gorm.DB, start, end *time.Time, fn func(*gen.Timelog) error) error {
+	query := db.Model(&gen.Timelog{})
+	if start != nil {
+		query = query.Where("start_time >= ?", start.UTC())
+	}
+	if end != nil {
+		query = query.Where("start_time < ?", end.UTC())
+	}
+	return eachBatch(query, "start_time", fn, func(tl *gen.Timelog) (time.Time, int32) {
+		return tl.StartTime, *tl.ID
+	})
+}
+
+// EachTask 按截止时间顺序逐条读取截止时间在 [start, end) 内的任务，不一次性载入内存
+// start/end 为空时不限制对应一端；fn 返回错误时停止读取
+func EachTask(db *gorm.DB, start, end *time.Time, fn func(*gen.Task) error) error {
+	query := db.Model(&gen.Task{})
+	if start != nil {
+		query = query.Where("due_date >= ?", start.UTC())
+	}
+	if end != nil {
+		query = query.Where("due_date < ?", end.UTC())
+	}
+	return eachBatch(query, "due_date", fn, func(task *gen.Task) (time.Time, int32) {
+		return task.DueDate, *task.ID
+	})
+}
+
+// eachBatch 按 (column, id) 分页读取 query 的结果，每批读完后再逐条交给 fn
+// key 返回一行的排序键，作为下一批的起点
+func eachBatch[T any](query *gorm.DB, column string, fn func(*T) error, key func(*T) (time.Time, int32)) error {
+	var (
+		lastTime time.Time
+		lastID   int32
+		started  bool
+	)
+	for {
+		batch := query.Session(&gorm.Session{})
+		if started {
+			batch = batch.Where("("+column+", id) > (?, ?)", lastTime.UTC(), lastID)
+		}
+		var rows []T
+		if err := batch.Order(column + " ASC, id ASC").Limit(exportBatchSize).Find(&rows).Error; err != nil {
+			return err
+		}
+		for i := range rows {
+			if err := fn(&rows[i]); err != nil {
+				return err
+			}
+		}
+		if len(rows) < exportBatchSize {
+			return nil
+		}
+		lastTime, lastID = key(&rows[len(rows)-1])
+		started = true
+	}
+}
+
+// ListTaskTitles 返回所有任务（包括已删除的）ID 到标题的映射，只读取这两列
+func ListTaskTitles(db *gorm.DB) (map[int32]string, error) {
+	var tasks []struct {
+		ID    int32
+		Title string
+	}
+	if err := db.Unscoped().Model(&gen.Task{}).Select("id", "title").Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	titles := make(map[int32]string, len(tasks))
+	for _, task := range tasks {
+		titles[task.ID] = task.Title
+	}
+	return titles, nil
+}
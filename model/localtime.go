@@ -0,0 +1,99 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultTimezone    = "Asia/Singapore"
+	DefaultDayStartsAt = "00:00"
+)
+
+// 用户所在时区及每天的开始时间（相对本地零点的偏移），由 InitDao 根据配置设置
+var (
+	localLocation  = loadDefaultLocation()
+	dayStartOffset time.Duration
+)
+
+func loadDefaultLocation() *time.Location {
+	loc, err := time.LoadLocation(DefaultTimezone)
+	if err != nil {
+		// Fallback to UTC+8 if timezone data is not available
+		return time.FixedZone("SGT", 8*60*60)
+	}
+	return loc
+}
+
+// parseDayStartsAt 解析 "HH:MM" 格式的每天开始时间
+func parseDayStartsAt(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid day_starts_at %q, expected HH:MM: %w", s, err)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// SetLocalTime 设置用户时区（IANA 名称，如 America/New_York）和每天开始时间（HH:MM）
+// 空值使用默认值 Asia/Singapore 和 00:00
+func SetLocalTime(timezone, dayStartsAt string) error {
+	loc := loadDefaultLocation()
+	if timezone != "" && timezone != DefaultTimezone {
+		var err error
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return fmt.Errorf("invalid timezone %q: %w", timezone, err)
+		}
+	}
+
+	offset := time.Duration(0)
+	if dayStartsAt != "" {
+		var err error
+		if offset, err = parseDayStartsAt(dayStartsAt); err != nil {
+			return err
+		}
+	}
+
+	localLocation = loc
+	dayStartOffset = offset
+	return nil
+}
+
+// GetLocation 返回配置的用户时区，供其他包使用
+func GetLocation() *time.Location {
+	return localLocation
+}
+
+// GetDayStartsAt 返回每天开始时间（HH:MM）
+func GetDayStartsAt() string {
+	return fmt.Sprintf("%02d:%02d", int(dayStartOffset.Hours()), int(dayStartOffset.Minutes())%60)
+}
+
+// ParseLocalDate 将 "YYYY-MM-DD" 解析为用户时区中该日期的零点
+func ParseLocalDate(dateStr string) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02", dateStr, localLocation)
+}
+
+// LocalDayBounds 返回 date 所在日期（只取年月日）在用户时区中的 [开始, 结束) 区间
+// 开始时间为当天的 day_starts_at，结束时间为次日的 day_starts_at；按墙上时间计算，夏令时切换的日子可能是 23 或 25 小时
+func LocalDayBounds(date time.Time) (time.Time, time.Time) {
+	h, m := int(dayStartOffset.Hours()), int(dayStartOffset.Minutes())%60
+	start := time.Date(date.Year(), date.Month(), date.Day(), h, m, 0, 0, localLocation)
+	end := time.Date(date.Year(), date.Month(), date.Day()+1, h, m, 0, 0, localLocation)
+	return start, end
+}
+
+// LocalDateOf 返回时间点 t 所属的逻辑日期（用户时区中的零点）
+// 早于 day_starts_at 的时间归入前一天，例如 day_starts_at 为 04:00 时凌晨 2 点仍算作前一天
+func LocalDateOf(t time.Time) time.Time {
+	lt := t.In(localLocation)
+	day := time.Date(lt.Year(), lt.Month(), lt.Day(), 0, 0, 0, 0, localLocation)
+	if start, _ := LocalDayBounds(day); lt.Before(start) {
+		day = day.AddDate(0, 0, -1)
+	}
+	return day
+}
+
+// LocalToday 返回当前的逻辑日期，格式为 "YYYY-MM-DD"
+func LocalToday() string {
+	return LocalDateOf(time.Now()).Format("2006-01-02")
+}
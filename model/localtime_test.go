@@ -0,0 +1,73 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLocalDayBoundsAcrossDST(t *testing.T) {
+	if err := SetLocalTime("America/New_York", "04:00"); err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	defer SetLocalTime("", "")
+
+	cases := []struct {
+		date  string
+		hours float64
+	}{
+		// 切换发生在凌晨 2 点，早于 04:00，因此落在前一个逻辑日
+		{"2026-03-07", 23}, // 夏令时开始
+		{"2026-10-31", 25}, // 夏令时结束
+		{"2026-03-08", 24},
+		{"2026-06-15", 24},
+	}
+	for _, c := range cases {
+		date, err := ParseLocalDate(c.date)
+		if err != nil {
+			t.Fatalf("ParseLocalDate(%q) failed: %v", c.date, err)
+		}
+		start, end := LocalDayBounds(date)
+		if start.Hour() != 4 || end.Hour() != 4 {
+			t.Errorf("%s: expected day to run 04:00-04:00 local, got %s - %s", c.date, start, end)
+		}
+		if got := end.Sub(start).Hours(); got != c.hours {
+			t.Errorf("%s: expected %v hour day, got %v", c.date, c.hours, got)
+		}
+	}
+}
+
+func TestLocalDateOfRespectsDayStart(t *testing.T) {
+	if err := SetLocalTime("Asia/Singapore", "04:00"); err != nil {
+		t.Fatalf("SetLocalTime failed: %v", err)
+	}
+	defer SetLocalTime("", "")
+
+	cases := []struct {
+		utc  string
+		date string
+	}{
+		{"2026-10-17T17:30:00Z", "2026-10-17"}, // 18日 01:30 SGT，仍属于 17 日
+		{"2026-10-17T20:00:00Z", "2026-10-18"}, // 18日 04:00 SGT
+		{"2026-10-18T15:59:00Z", "2026-10-18"}, // 18日 23:59 SGT
+	}
+	for _, c := range cases {
+		ts, _ := time.Parse(time.RFC3339, c.utc)
+		if got := LocalDateOf(ts).Format("2006-01-02"); got != c.date {
+			t.Errorf("LocalDateOf(%s) = %s, expected %s", c.utc, got, c.date)
+		}
+	}
+}
+
+func TestSetLocalTimeRejectsInvalidValues(t *testing.T) {
+	defer SetLocalTime("", "")
+
+	if err := SetLocalTime("Mars/Olympus_Mons", ""); err == nil {
+		t.Error("Expected unknown time zone to be rejected")
+	}
+	if err := SetLocalTime("", "25:00"); err == nil {
+		t.Error("Expected invalid day_starts_at to be rejected")
+	}
+	if GetLocation().String() != DefaultTimezone || GetDayStartsAt() != DefaultDayStartsAt {
+		t.Errorf("Invalid values must not change settings, got %s %s", GetLocation(), GetDayStartsAt())
+	}
+}
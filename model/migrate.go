@@ -0,0 +1,289 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"regexp"
+	"sync/atomic"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationsTable 与 migrate CLI 的 sqlite3 驱动保持一致，已有数据库可直接沿用
+const migrationsTable = "schema_migrations"
+
+var (
+	ErrSchemaDirty   = errors.New("database schema is dirty, a previous migration failed and must be fixed manually")
+	ErrSchemaTooNew  = errors.New("database schema is newer than this binary")
+	ErrSchemaPending = errors.New("database schema has pending migrations")
+)
+
+// sqliteMigrateDriver 基于已打开的 *sql.DB 实现 golang-migrate 的 database.Driver
+// 复用 gorm 使用的 ncruces 驱动，避免引入 migrate 自带的 cgo sqlite3 驱动
+type sqliteMigrateDriver struct {
+	db     *sql.DB
+	locked atomic.Bool
+}
+
+func newSqliteMigrateDriver(db *sql.DB) (*sqliteMigrateDriver, error) {
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (version uint64, dirty bool);
+CREATE UNIQUE INDEX IF NOT EXISTS version_unique ON %s (version);`, migrationsTable, migrationsTable)
+	if _, err := db.Exec(query); err != nil {
+		return nil, err
+	}
+	return &sqliteMigrateDriver{db: db}, nil
+}
+
+func (d *sqliteMigrateDriver) Open(url string) (database.Driver, error) {
+	return nil, errors.New("sqlite migrate driver only supports existing connections")
+}
+
+// Close 连接由 Dao 管理，这里不关闭
+func (d *sqliteMigrateDriver) Close() error {
+	return nil
+}
+
+func (d *sqliteMigrateDriver) Lock() error {
+	if !d.locked.CompareAndSwap(false, true) {
+		return database.ErrLocked
+	}
+	return nil
+}
+
+func (d *sqliteMigrateDriver) Unlock() error {
+	if !d.locked.CompareAndSwap(true, false) {
+		return database.ErrNotLocked
+	}
+	return nil
+}
+
+// foreignKeysOffPattern 迁移文件中关闭外键检查的语句，重建被引用的表时需要
+var foreignKeysOffPattern = regexp.MustCompile(`(?im)^\s*PRAGMA\s+foreign_keys\s*=\s*(OFF|0|FALSE)\s*;`)
+
+// Run 在事务中执行一个迁移文件
+// PRAGMA foreign_keys 在事务内不生效：迁移文件包含 PRAGMA foreign_keys = OFF 时，
+// 在同一连接上于事务外关闭外键检查，提交前用 foreign_key_check 确认没有破坏引用，结束后恢复原设置
+func (d *sqliteMigrateDriver) Run(migration io.Reader) error {
+	body, err := io.ReadAll(migration)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return &database.Error{OrigErr: err, Err: "failed to acquire connection"}
+	}
+	defer conn.Close()
+
+	disableFK := foreignKeysOffPattern.Match(body)
+	if disableFK {
+		var enabled bool
+		if err := conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&enabled); err != nil {
+			return &database.Error{OrigErr: err, Err: "failed to read foreign_keys"}
+		}
+		if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+			return &database.Error{OrigErr: err, Err: "failed to disable foreign_keys"}
+		}
+		if enabled {
+			defer conn.ExecContext(ctx, "PRAGMA foreign_keys = ON")
+		}
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return &database.Error{OrigErr: err, Err: "transaction start failed"}
+	}
+	if _, err := tx.Exec(string(body)); err != nil {
+		_ = tx.Rollback()
+		return &database.Error{OrigErr: err, Query: body}
+	}
+	if disableFK {
+		if err := checkForeignKeys(tx); err != nil {
+			_ = tx.Rollback()
+			return &database.Error{OrigErr: err, Err: "foreign key check failed"}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return &database.Error{OrigErr: err, Err: "transaction commit failed"}
+	}
+	return nil
+}
+
+// checkForeignKeys 外键检查关闭期间执行的迁移不能留下悬空引用
+func checkForeignKeys(tx *sql.Tx) error {
+	rows, err := tx.Query("PRAGMA foreign_key_check")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if rows.Next() {
+		var table, parent string
+		var rowid sql.NullInt64
+		var fkid int
+		if err := rows.Scan(&table, &rowid, &parent, &fkid); err != nil {
+			return err
+		}
+		return fmt.Errorf("row %d in %s references a missing row in %s", rowid.Int64, table, parent)
+	}
+	return rows.Err()
+}
+
+func (d *sqliteMigrateDriver) SetVersion(version int, dirty bool) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return &database.Error{OrigErr: err, Err: "transaction start failed"}
+	}
+	if _, err := tx.Exec("DELETE FROM " + migrationsTable); err != nil {
+		_ = tx.Rollback()
+		return &database.Error{OrigErr: err, Err: "failed to reset schema version"}
+	}
+	if version >= 0 || (version == database.NilVersion && dirty) {
+		query := fmt.Sprintf("INSERT INTO %s (version, dirty) VALUES (?, ?)", migrationsTable)
+		if _, err := tx.Exec(query, version, dirty); err != nil {
+			_ = tx.Rollback()
+			return &database.Error{OrigErr: err, Query: []byte(query)}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return &database.Error{OrigErr: err, Err: "transaction commit failed"}
+	}
+	return nil
+}
+
+func (d *sqliteMigrateDriver) Version() (int, bool, error) {
+	var version int
+	var dirty bool
+	err := d.db.QueryRow("SELECT version, dirty FROM "+migrationsTable+" LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.NilVersion, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return version, dirty, nil
+}
+
+// Drop 删除所有表，仅供测试重建数据库使用
+func (d *sqliteMigrateDriver) Drop() error {
+	rows, err := d.db.Query("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'")
+	if err != nil {
+		return err
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		tables = append(tables, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, t := range tables {
+		if _, err := d.db.Exec("DROP TABLE IF EXISTS " + t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LatestMigrationVersion 返回二进制中嵌入的最新迁移版本
+func LatestMigrationVersion() (uint, error) {
+	entries, err := fs.Glob(migrationFiles, "migrations/*.up.sql")
+	if err != nil {
+		return 0, err
+	}
+	var latest uint
+	for _, name := range entries {
+		var version uint
+		if _, err := fmt.Sscanf(name, "migrations/%d_", &version); err == nil && version > latest {
+			latest = version
+		}
+	}
+	return latest, nil
+}
+
+// NewMigrate 基于嵌入的迁移文件创建 migrate 实例
+func NewMigrate(db *sql.DB) (*migrate.Migrate, error) {
+	src, err := iofs.New(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	driver, err := newSqliteMigrateDriver(db)
+	if err != nil {
+		return nil, err
+	}
+	return migrate.NewWithInstance("iofs", src, "sqlite", driver)
+}
+
+// SchemaVersion 返回数据库当前的迁移版本，尚未迁移时 version 为 0
+func SchemaVersion(db *sql.DB) (uint, bool, error) {
+	driver, err := newSqliteMigrateDriver(db)
+	if err != nil {
+		return 0, false, err
+	}
+	version, dirty, err := driver.Version()
+	if err != nil || version == database.NilVersion {
+		return 0, dirty, err
+	}
+	return uint(version), dirty, nil
+}
+
+// CheckSchema 检查数据库是否可以被当前二进制使用：处于 dirty 状态或版本比二进制更新时返回错误
+// 有未执行的迁移时返回 ErrSchemaPending
+func CheckSchema(db *sql.DB) error {
+	version, dirty, err := SchemaVersion(db)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("%w (version %d)", ErrSchemaDirty, version)
+	}
+	latest, err := LatestMigrationVersion()
+	if err != nil {
+		return err
+	}
+	if version > latest {
+		return fmt.Errorf("%w (database version %d, binary supports up to %d)", ErrSchemaTooNew, version, latest)
+	}
+	if version < latest {
+		return fmt.Errorf("%w (database version %d, latest %d)", ErrSchemaPending, version, latest)
+	}
+	return nil
+}
+
+// Migrate 执行所有未执行的嵌入迁移，数据库处于 dirty 状态或比二进制更新时拒绝执行
+// 返回迁移前后的版本
+func Migrate(db *sql.DB) (uint, uint, error) {
+	if err := CheckSchema(db); err != nil && !errors.Is(err, ErrSchemaPending) {
+		return 0, 0, err
+	}
+	from, _, err := SchemaVersion(db)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	m, err := NewMigrate(db)
+	if err != nil {
+		return from, from, err
+	}
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return from, from, err
+	}
+
+	to, _, err := SchemaVersion(db)
+	return from, to, err
+}
@@ -0,0 +1,116 @@
+package model
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func openMigrateTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMigrateAppliesEmbeddedMigrations(t *testing.T) {
+	db := openMigrateTestDB(t)
+
+	latest, err := LatestMigrationVersion()
+	if err != nil || latest == 0 {
+		t.Fatalf("Expected embedded migrations, got %d (%v)", latest, err)
+	}
+	if err := CheckSchema(db); !errors.Is(err, ErrSchemaPending) {
+		t.Errorf("Expected pending migrations on an empty database, got %v", err)
+	}
+
+	from, to, err := Migrate(db)
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if from != 0 || to != latest {
+		t.Errorf("Expected migration from 0 to %d, got %d to %d", latest, from, to)
+	}
+	if err := CheckSchema(db); err != nil {
+		t.Errorf("Expected schema to be current, got %v", err)
+	}
+
+	// 再次执行不应有任何变化
+	if from, to, err := Migrate(db); err != nil || from != latest || to != latest {
+		t.Errorf("Expected no-op migration, got %d to %d (%v)", from, to, err)
+	}
+}
+
+func TestMigrateRefusesDirtyOrNewerSchema(t *testing.T) {
+	db := openMigrateTestDB(t)
+	if _, _, err := Migrate(db); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	latest, _ := LatestMigrationVersion()
+
+	if _, err := db.Exec("UPDATE schema_migrations SET dirty = 1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Migrate(db); !errors.Is(err, ErrSchemaDirty) {
+		t.Errorf("Expected ErrSchemaDirty, got %v", err)
+	}
+
+	if _, err := db.Exec("UPDATE schema_migrations SET dirty = 0, version = ?", latest+1); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Migrate(db); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("Expected ErrSchemaTooNew, got %v", err)
+	}
+}
+
+func TestMigrateLiftsCategoryLevelCheckKeepingReferences(t *testing.T) {
+	db := openMigrateTestDB(t)
+	m, err := NewMigrate(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Migrate(15); err != nil {
+		t.Fatalf("Migrate to 15 failed: %v", err)
+	}
+
+	seed := `
+INSERT INTO categories (id, name, parent_id, level, path) VALUES (101, 'Work Area', NULL, 0, '/');
+INSERT INTO categories (id, name, parent_id, level, path) VALUES (102, 'Project', 101, 1, '/Work Area');
+INSERT INTO categories (id, name, parent_id, level, path) VALUES (103, 'Component', 102, 2, '/Work Area/Project');
+INSERT INTO timelogs (start_time, category_id) VALUES ('2025-01-06 09:00:00', 103);
+INSERT INTO tasks (title, category_id, due_date, estimated_minutes) VALUES ('Ship', 102, '2025-01-06', 30);`
+	if _, err := db.Exec(seed); err != nil {
+		t.Fatalf("Failed to seed: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO categories (name, parent_id, level, path) VALUES ('Activity', 103, 3, '/Work Area/Project/Component')"); err == nil {
+		t.Fatal("Expected level 3 to be rejected before migration 16")
+	}
+
+	if _, _, err := Migrate(db); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO categories (name, parent_id, level, path) VALUES ('Activity', 103, 3, '/Work Area/Project/Component')"); err != nil {
+		t.Errorf("Expected level 3 to be allowed, got %v", err)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM timelogs t JOIN categories c ON c.id = t.category_id").Scan(&count); err != nil || count != 1 {
+		t.Errorf("Expected timelog to keep its category, got %d (%v)", count, err)
+	}
+	// 外键仍然生效
+	if _, err := db.Exec("INSERT INTO timelogs (start_time, category_id) VALUES ('2025-01-06 10:00:00', 999)"); err == nil {
+		t.Error("Expected foreign key on timelogs.category_id to still be enforced")
+	}
+	rows, err := db.Query("PRAGMA foreign_key_check")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	if rows.Next() {
+		t.Error("Expected no foreign key violations after migration")
+	}
+}
@@ -0,0 +1,3 @@
+-- Drop archived_at from categories
+DROP INDEX IF EXISTS idx_categories_archived_at;
+ALTER TABLE categories DROP COLUMN archived_at;
@@ -0,0 +1,4 @@
+-- Archived categories are hidden from pickers but keep their history
+ALTER TABLE categories ADD COLUMN archived_at DATETIME;
+
+CREATE INDEX idx_categories_archived_at ON categories(archived_at);
@@ -0,0 +1,2 @@
+-- Drop calendar_feeds table
+DROP TABLE IF EXISTS calendar_feeds;
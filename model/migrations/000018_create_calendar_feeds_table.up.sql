@@ -0,0 +1,12 @@
+-- Calendar feeds are authenticated by a secret token in the subscription URL
+CREATE TABLE calendar_feeds (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('timelogs', 'tasks')),
+    token_hash VARCHAR(64) NOT NULL,
+    token_prefix VARCHAR(20) NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    last_used_at DATETIME
+);
+
+CREATE UNIQUE INDEX idx_calendar_feeds_token_hash ON calendar_feeds(token_hash);
@@ -2,6 +2,7 @@ package model
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -66,6 +67,11 @@ var _ DBProvider = (*TxDao)(nil)
 
 func InitDao(cfg *config.Config, loggerInstance logger.Logger) {
 	once.Do(func() {
+		if err := SetLocalTime(cfg.Timelog.Timezone, cfg.Timelog.DayStartsAt); err != nil {
+			panic(err)
+		}
+		SetCategoryMaxDepth(cfg.Category.MaxDepth)
+
 		if db, err := gorm.Open(sqlite.Open(cfg.Database.Host), &gorm.Config{
 			Logger: gl.Default.LogMode(gl.LogLevel(cfg.Log.ORMLogLevel)),
 		}); err != nil {
@@ -76,11 +82,41 @@ func InitDao(cfg *config.Config, loggerInstance logger.Logger) {
 			dao = &Dao{db: db, RawDB: raw}
 		}
 
+		// 每个连接打开的 :memory: 都是独立的数据库，只保留一个连接
+		if cfg.Database.Host == ":memory:" {
+			dao.RawDB.SetMaxOpenConns(1)
+		}
+		prepareSchema(cfg)
+
 		dao.cache = cache.New(5*time.Minute, 10*time.Minute)
 
 	})
 }
 
+// prepareSchema 启用自动迁移时执行嵌入的迁移，否则只检查数据库版本
+// 数据库处于 dirty 状态或比二进制更新时拒绝启动
+func prepareSchema(cfg *config.Config) {
+	if cfg.Database.AutoMigrate {
+		from, to, err := Migrate(dao.RawDB)
+		if err != nil {
+			panic(fmt.Errorf("database migration failed: %w", err))
+		}
+		if from != to && log != nil {
+			log.Info(fmt.Sprintf("[Startup] Database migrated from version %d to %d", from, to))
+		}
+		return
+	}
+
+	if err := CheckSchema(dao.RawDB); err != nil {
+		if !errors.Is(err, ErrSchemaPending) {
+			panic(err)
+		}
+		if log != nil {
+			log.Warn(fmt.Sprintf("%v, run with --migrate-only or enable database.auto_migrate", err))
+		}
+	}
+}
+
 func GetDao() *Dao {
 	if dao == nil {
 		panic("dao is nil, please call InitDao first")
@@ -101,6 +137,10 @@ func (d *Dao) GetCache(key string) (any, bool) {
 	return d.cache.Get(key)
 }
 
+func (d *Dao) DeleteCache(key string) {
+	d.cache.Delete(key)
+}
+
 func (d *Dao) AdminGetAllCache() {
 	items := d.cache.Items()
 
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const ReportKindDaily = "daily"
+
+// Report 持久化的报告，Content 为报告的 JSON 内容
+type Report struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	Kind       string    `gorm:"column:kind;not null" json:"kind"`
+	ReportDate string    `gorm:"column:report_date;not null" json:"report_date"`
+	Content    string    `gorm:"column:content;not null" json:"-"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (Report) TableName() string {
+	return "reports"
+}
+
+// SaveReport 保存报告，同一类型同一日期的报告已存在时覆盖内容
+func SaveReport(db *gorm.DB, report *Report) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "kind"}, {Name: "report_date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"content", "updated_at"}),
+	}).Create(report).Error
+}
+
+// GetReport 获取指定类型和日期（YYYY-MM-DD）的报告
+func GetReport(db *gorm.DB, kind, date string) (*Report, error) {
+	var report Report
+	err := db.Where("kind = ? AND report_date = ?", kind, date).First(&report).Error
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ListReports 按日期倒序查询报告，from/to 为空时不限制，limit 大于 0 时限制条数
+func ListReports(db *gorm.DB, kind, from, to string, limit int) ([]Report, error) {
+	var reports []Report
+	query := db.Where("kind = ?", kind)
+	if from != "" {
+		query = query.Where("report_date >= ?", from)
+	}
+	if to != "" {
+		query = query.Where("report_date <= ?", to)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	err := query.Order("report_date DESC").Find(&reports).Error
+	return reports, err
+}
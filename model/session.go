@@ -0,0 +1,77 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Session 登录会话，只保存令牌的 SHA-256
+type Session struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	TokenHash   string    `gorm:"column:token_hash;not null" json:"-"`
+	DeviceLabel string    `gorm:"column:device_label;not null" json:"device_label"`
+	IP          string    `gorm:"column:ip;not null" json:"ip"`
+	UserAgent   string    `gorm:"column:user_agent;not null" json:"user_agent"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastSeenAt  time.Time `gorm:"column:last_seen_at;not null" json:"last_seen_at"`
+	ExpiresAt   time.Time `gorm:"column:expires_at;not null" json:"expires_at"`
+}
+
+func (Session) TableName() string {
+	return "sessions"
+}
+
+func CreateSession(db *gorm.DB, session *Session) error {
+	return db.Create(session).Error
+}
+
+// GetSessionByTokenHash 获取未过期的会话
+func GetSessionByTokenHash(db *gorm.DB, hash string, now time.Time) (*Session, error) {
+	var session Session
+	err := db.Where("token_hash = ? AND expires_at > ?", hash, now.UTC()).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ListSessions 按最近活动时间倒序列出未过期的会话
+func ListSessions(db *gorm.DB, now time.Time) ([]Session, error) {
+	var sessions []Session
+	err := db.Where("expires_at > ?", now.UTC()).Order("last_seen_at DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+// TouchSession 更新会话的最近活动时间与过期时间，会话不存在时返回 ErrRecordNotFound
+func TouchSession(db *gorm.DB, id uint, lastSeenAt, expiresAt time.Time, ip, userAgent string) error {
+	result := db.Model(&Session{}).Where("id = ?", id).Updates(map[string]any{
+		"last_seen_at": lastSeenAt.UTC(),
+		"expires_at":   expiresAt.UTC(),
+		"ip":           ip,
+		"user_agent":   userAgent,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteSession 删除会话，会话不存在时返回 ErrRecordNotFound
+func DeleteSession(db *gorm.DB, id uint) error {
+	result := db.Delete(&Session{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+func DeleteExpiredSessions(db *gorm.DB, now time.Time) error {
+	return db.Where("expires_at <= ?", now.UTC()).Delete(&Session{}).Error
+}
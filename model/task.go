@@ -43,15 +43,14 @@ func GetAllTasks(db *gorm.DB, includeSuspended bool, includeCompleted bool) ([]g
 	return tasks, err
 }
 
-// GetTasksByDate 根据日期获取任务
+// GetTasksByDate 根据日期获取任务，只使用 date 的年月日，按配置的时区和每天开始时间划分
 // includeSuspended: 是否包含暂停的任务
 // includeCompleted: 是否包含已完成的任务
 func GetTasksByDate(db *gorm.DB, date time.Time, includeSuspended bool, includeCompleted bool) ([]gen.Task, error) {
 	var tasks []gen.Task
-	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-	endOfDay := startOfDay.Add(24 * time.Hour)
+	startOfDay, endOfDay := LocalDayBounds(date)
 
-	query := db.Where("due_date >= ? AND due_date < ?", startOfDay, endOfDay)
+	query := db.Where("due_date >= ? AND due_date < ?", startOfDay.UTC(), endOfDay.UTC())
 
 	if !includeSuspended {
 		query = query.Where("is_suspended = ?", false)
@@ -121,10 +120,33 @@ func GetCompletedTasksInDateRange(db *gorm.DB, startDate, endDate time.Time) ([]
 	return tasks, err
 }
 
-// GetTaskStats 获取任务统计信息
+// SumTrackedMinutesByTask 汇总每个任务关联的时间日志时长（分钟），未结束的日志计算到 now
+func SumTrackedMinutesByTask(db *gorm.DB, taskIDs []int32, now time.Time) (map[int32]float64, error) {
+	result := make(map[int32]float64, len(taskIDs))
+	if len(taskIDs) == 0 {
+		return result, nil
+	}
+
+	var tls []gen.Timelog
+	if err := db.Where("task_id IN ?", taskIDs).Find(&tls).Error; err != nil {
+		return nil, err
+	}
+	for _, tl := range tls {
+		end := now
+		if tl.EndTime != nil {
+			end = *tl.EndTime
+		}
+		if end.After(tl.StartTime) {
+			result[*tl.TaskID] += end.Sub(tl.StartTime).Minutes()
+		}
+	}
+	return result, nil
+}
+
+// GetTaskStats 获取任务统计信息，日期划分同 GetTasksByDate
 func GetTaskStats(db *gorm.DB, date time.Time) (map[string]interface{}, error) {
-	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-	endOfDay := startOfDay.Add(24 * time.Hour)
+	startOfDay, endOfDay := LocalDayBounds(date)
+	startOfDay, endOfDay = startOfDay.UTC(), endOfDay.UTC()
 
 	var totalTasks, completedTasks int64
 
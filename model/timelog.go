@@ -1,6 +1,8 @@
 package model
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/blacksheepaul/timelog/model/gen"
@@ -49,53 +51,176 @@ func ListTimeLogsWithOptions(db *gorm.DB, limit int, orderBy string, conds ...in
 	return tls, err
 }
 
-// UpdateTimeLog 更新时间日志
-func UpdateTimeLog(db *gorm.DB, tl *gen.Timelog) error {
-	return db.Save(tl).Error
+// timeLogSortFields 允许用于排序的列，防止任意字符串进入 ORDER BY
+var timeLogSortFields = map[string]bool{
+	"id":         true,
+	"start_time": true,
+	"end_time":   true,
+	"created_at": true,
+	"updated_at": true,
 }
 
-// DeleteTimeLog 删除时间日志
-func DeleteTimeLog(db *gorm.DB, id int32) error {
-	return db.Delete(&gen.Timelog{}, id).Error
+// IsTimeLogSortField 判断列名是否允许用于时间日志排序
+func IsTimeLogSortField(field string) bool {
+	return timeLogSortFields[field]
 }
 
-// 定义新加坡时区
-var singaporeLocation *time.Location
+// TimeLogQuery 时间日志的过滤、排序与分页条件，时间均按 UTC 比较
+type TimeLogQuery struct {
+	StartFrom   *time.Time // start_time >= StartFrom
+	StartBefore *time.Time // start_time < StartBefore
+	CategoryIDs []int32
+	TaskID      *int32
+	Remark      string // 备注包含该文本（不区分大小写）
+	Open        *bool  // true 只查未结束的日志，false 只查已结束的日志
+	SortField   string // 必须是 timeLogSortFields 中的列
+	SortDesc    bool
+	AfterID     *int32 // 游标分页：只返回按排序排在该日志之后的记录
+	Limit       int
+	Offset      int
+}
 
-func init() {
-	var err error
-	singaporeLocation, err = time.LoadLocation("Asia/Singapore")
-	if err != nil {
-		// Fallback to UTC+8 if timezone data is not available
-		singaporeLocation = time.FixedZone("SGT", 8*60*60)
+// where 应用过滤条件（不含游标与分页）
+func (q *TimeLogQuery) where(db *gorm.DB) *gorm.DB {
+	query := db.Model(&gen.Timelog{})
+	if q.StartFrom != nil {
+		query = query.Where("start_time >= ?", q.StartFrom.UTC())
+	}
+	if q.StartBefore != nil {
+		query = query.Where("start_time < ?", q.StartBefore.UTC())
+	}
+	if len(q.CategoryIDs) > 0 {
+		query = query.Where("category_id IN ?", q.CategoryIDs)
+	}
+	if q.TaskID != nil {
+		query = query.Where("task_id = ?", *q.TaskID)
 	}
+	if q.Remark != "" {
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(q.Remark)
+		query = query.Where(`remark LIKE ? ESCAPE '\'`, "%"+escaped+"%")
+	}
+	if q.Open != nil {
+		if *q.Open {
+			query = query.Where("end_time IS NULL")
+		} else {
+			query = query.Where("end_time IS NOT NULL")
+		}
+	}
+	return query
+}
+
+// QueryTimeLogs 按 TimeLogQuery 查询时间日志，排序总是以 id 作为第二键保证结果稳定
+func QueryTimeLogs(db *gorm.DB, q TimeLogQuery) ([]gen.Timelog, error) {
+	field := q.SortField
+	if field == "" {
+		field = "start_time"
+	}
+	if !IsTimeLogSortField(field) {
+		return nil, fmt.Errorf("invalid sort field: %s", field)
+	}
+	dir, cmp := "ASC", ">"
+	if q.SortDesc {
+		dir, cmp = "DESC", "<"
+	}
+
+	query := q.where(db)
+	if q.AfterID != nil {
+		if field == "id" {
+			query = query.Where("id "+cmp+" ?", *q.AfterID)
+		} else {
+			// 通过子查询取游标行的排序值，避免数据库中时间字符串格式与 Go 序列化结果不一致
+			ref := "(SELECT " + field + " FROM timelogs WHERE id = ?)"
+			query = query.Where(field+" "+cmp+" "+ref+" OR ("+field+" = "+ref+" AND id "+cmp+" ?)",
+				*q.AfterID, *q.AfterID, *q.AfterID)
+		}
+	}
+	if field != "id" {
+		query = query.Order(field + " " + dir)
+	}
+	query = query.Order("id " + dir)
+	if q.Limit > 0 {
+		query = query.Limit(q.Limit)
+	}
+	if q.Offset > 0 {
+		query = query.Offset(q.Offset)
+	}
+
+	var tls []gen.Timelog
+	err := query.Find(&tls).Error
+	return tls, err
 }
 
-// GetSingaporeLocation 返回新加坡时区，供其他包使用
-func GetSingaporeLocation() *time.Location {
-	return singaporeLocation
+// CountTimeLogs 统计满足过滤条件的时间日志数量（忽略游标与分页）
+func CountTimeLogs(db *gorm.DB, q TimeLogQuery) (int64, error) {
+	var total int64
+	err := q.where(db).Count(&total).Error
+	return total, err
+}
+
+// ListOpenTimeLogs 查询所有未结束（end_time 为空）的时间日志，按开始时间倒序
+func ListOpenTimeLogs(db *gorm.DB) ([]gen.Timelog, error) {
+	return ListTimeLogsWithOptions(db, 0, "start_time DESC", "end_time IS NULL")
+}
+
+// ListTimeLogsOverlapping 查询与 [start, end) 有交集的时间日志
+// end 为 nil 表示区间没有上界；未结束的日志视为一直延续；excludeID 用于更新时排除自身
+func ListTimeLogsOverlapping(db *gorm.DB, start time.Time, end *time.Time, excludeID *int32) ([]gen.Timelog, error) {
+	var tls []gen.Timelog
+	query := db.Where("end_time IS NULL OR end_time > ?", start.UTC())
+	if end != nil {
+		query = query.Where("start_time < ?", end.UTC())
+	}
+	if excludeID != nil {
+		query = query.Where("id <> ?", *excludeID)
+	}
+	err := query.Order("start_time ASC").Find(&tls).Error
+	return tls, err
+}
+
+// FindTimeLogByRange 查找开始与结束时间完全相同的时间日志（用于导入时去重），end 为 nil 时匹配未结束的日志
+func FindTimeLogByRange(db *gorm.DB, start time.Time, end *time.Time) (*gen.Timelog, error) {
+	var tl gen.Timelog
+	query := db.Where("start_time = ?", start.UTC())
+	if end != nil {
+		query = query.Where("end_time = ?", end.UTC())
+	} else {
+		query = query.Where("end_time IS NULL")
+	}
+	err := query.First(&tl).Error
+	return &tl, err
+}
+
+// CloseTimeLog 将指定时间日志的结束时间设置为 endTime
+func CloseTimeLog(db *gorm.DB, id int32, endTime time.Time) error {
+	return db.Model(&gen.Timelog{}).Where("id = ?", id).Update("end_time", endTime).Error
+}
+
+// UpdateTimeLog 更新时间日志
+func UpdateTimeLog(db *gorm.DB, tl *gen.Timelog) error {
+	return db.Save(tl).Error
+}
+
+// DeleteTimeLog 删除时间日志
+func DeleteTimeLog(db *gorm.DB, id int32) error {
+	return db.Delete(&gen.Timelog{}, id).Error
 }
 
 // ListTimeLogsByLocalDateRange 根据本地日期范围查询时间日志
-// startDateStr 和 endDateStr 格式为 "YYYY-MM-DD"，会被解析为新加坡时区
+// startDateStr 和 endDateStr 格式为 "YYYY-MM-DD"，按配置的时区和每天开始时间解析，两端均包含
 // 数据库存储的是 UTC 时间，该函数会自动转换
 func ListTimeLogsByLocalDateRange(db *gorm.DB, startDateStr, endDateStr string) ([]gen.Timelog, error) {
-	startDate, err := time.ParseInLocation("2006-01-02", startDateStr, singaporeLocation)
+	startDate, err := ParseLocalDate(startDateStr)
 	if err != nil {
 		return nil, err
 	}
 
-	endDate, err := time.ParseInLocation("2006-01-02", endDateStr, singaporeLocation)
+	endDate, err := ParseLocalDate(endDateStr)
 	if err != nil {
 		return nil, err
 	}
 
-	// endDate 设置为当天 23:59:59 SGT
-	endDate = endDate.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
-
-	// 转换为 UTC 进行数据库查询
-	startUTC := startDate.UTC()
-	endUTC := endDate.UTC()
+	start, _ := LocalDayBounds(startDate)
+	_, end := LocalDayBounds(endDate)
 
-	return ListTimeLogsWithOptions(db, 0, "start_time ASC", "start_time >= ? AND start_time <= ?", startUTC, endUTC)
+	return ListTimeLogsWithOptions(db, 0, "start_time ASC", "start_time >= ? AND start_time < ?", start.UTC(), end.UTC())
 }
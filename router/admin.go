@@ -0,0 +1,168 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/service"
+	"github.com/gin-gonic/gin"
+)
+
+// 添加管理相关路由
+func setupAdminRoutes(group *gin.RouterGroup) {
+	admin := group.Group("/admin")
+	admin.POST("/backup", createBackupHandler)
+	admin.GET("/backups", listBackupsHandler)
+	admin.POST("/categories/repair", repairCategoriesHandler)
+	admin.GET("/archive", exportArchiveHandler)
+	admin.POST("/archive/import", importArchiveHandler)
+}
+
+// CreateBackupHandler godoc
+// @Summary 立即备份数据库
+// @Description 在线写出数据库快照（VACUUM INTO），同时写入 SHA-256 校验和文件，并按保留数量清理旧快照
+// @Tags admin
+// @Produce json
+// @Success 200 {object} service.BackupInfo
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/backup [post]
+func createBackupHandler(c *gin.Context) {
+	backup, err := service.CreateBackup()
+	if err != nil {
+		log.Errorw("Failed to back up database", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, SuccessResponse(backup, "Backup created successfully"))
+}
+
+// ListBackupsHandler godoc
+// @Summary 查询数据库快照列表
+// @Description 按创建时间倒序返回备份目录中的快照
+// @Tags admin
+// @Produce json
+// @Success 200 {array} service.BackupInfo
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/backups [get]
+func listBackupsHandler(c *gin.Context) {
+	backups, err := service.ListBackups()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, SuccessResponse(backups, "Backups retrieved successfully"))
+}
+
+// RepairCategoriesHandler godoc
+// @Summary 修复分类层级
+// @Description 以 parent_id 为准重新计算所有分类的 level 与 path，父分类缺失或成环的分类提升为根分类，返回修复的差异
+// @Tags admin
+// @Produce json
+// @Param dry_run query bool false "只检查不写入"
+// @Success 200 {object} model.CategoryRepairReport
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/categories/repair [post]
+func repairCategoriesHandler(c *gin.Context) {
+	dryRun, ok := parseDryRun(c)
+	if !ok {
+		return
+	}
+
+	report, err := service.RepairCategoryHierarchy(dryRun)
+	if err != nil {
+		log.Errorw("Failed to repair category hierarchy", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return
+	}
+	if !dryRun && len(report.Repairs) > 0 {
+		log.Infow("Repaired category hierarchy", "repairs", len(report.Repairs))
+	}
+	c.JSON(http.StatusOK, SuccessResponse(report, "Category hierarchy checked successfully"))
+}
+
+// parseDryRun 解析 dry_run 查询参数，不合法时写入 400 响应并返回 false
+func parseDryRun(c *gin.Context) (bool, bool) {
+	value := c.Query("dry_run")
+	if value == "" {
+		return false, true
+	}
+	dryRun, err := strconv.ParseBool(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, "invalid dry_run parameter"))
+		return false, false
+	}
+	return dryRun, true
+}
+
+// ExportArchiveHandler godoc
+// @Summary 导出完整归档
+// @Description 以带版本号的 JSON 归档导出所有分类、约束、任务与时间日志（包括已软删除的记录），保留原始ID与引用关系；不包含 WebAuthn 凭据、会话与令牌
+// @Tags admin
+// @Produce json
+// @Success 200 {object} service.Archive
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/archive [get]
+func exportArchiveHandler(c *gin.Context) {
+	name := fmt.Sprintf("timelog-archive-%s.json", time.Now().In(model.GetLocation()).Format("20060102"))
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, name))
+	c.Status(http.StatusOK)
+	// 响应头已经发出，导出中途出错只能记录日志并中断输出
+	if err := service.ExportArchive(c.Writer); err != nil {
+		log.Errorw("Failed to export archive", "error", err)
+		c.Abort()
+	}
+}
+
+// ImportArchiveHandler godoc
+// @Summary 导入完整归档
+// @Description 请求体为 GET /api/admin/archive 导出的归档。restore 保留原始ID恢复到没有时间日志、任务与约束的数据库（替换默认分类）；
+// @Description merge 按自然键匹配已有记录，其余记录以新ID插入并重写引用，重复导入是幂等的。整个导入在一个事务中完成
+// @Description merge 插入的时间日志与新建日志一样检查未结束的计时与重叠（按 timelog.overlap_policy），冲突列在 timelog_conflicts 中；
+// @Description 有被拒绝的日志时返回 409 与报告，不写入任何数据
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param mode query string false "导入模式 restore|merge (默认 merge)"
+// @Param dry_run query bool false "只返回导入结果不写入"
+// @Param data body service.Archive true "归档"
+// @Success 200 {object} model.ArchiveImportReport
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/archive/import [post]
+func importArchiveHandler(c *gin.Context) {
+	dryRun, ok := parseDryRun(c)
+	if !ok {
+		return
+	}
+
+	report, err := service.ImportArchive(c.Request.Body, c.Query("mode"), dryRun)
+	if errors.Is(err, model.ErrArchiveConflicts) {
+		c.JSON(http.StatusConflict, ApiResponse{Data: report, Message: err.Error(), Status: http.StatusConflict})
+		return
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, model.ErrInvalidArchive):
+			status = http.StatusBadRequest
+		case errors.Is(err, model.ErrArchiveTargetNotEmpty):
+			status = http.StatusConflict
+		default:
+			log.Errorw("Failed to import archive", "error", err)
+		}
+		c.JSON(status, ErrorResponse(status, err.Error()))
+		return
+	}
+	if !dryRun {
+		log.Infow("Imported archive", "mode", report.Mode,
+			"categories", report.Categories.Created, "tasks", report.Tasks.Created, "timelogs", report.TimeLogs.Created)
+	}
+	c.JSON(http.StatusOK, SuccessResponse(report, "Archive imported successfully"))
+}
@@ -0,0 +1,105 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/service"
+	"github.com/gin-gonic/gin"
+)
+
+type apiTokenCreateRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Scope     string     `json:"scope"`      // read|read-write，默认 read
+	ExpiresAt *time.Time `json:"expires_at"` // 为空表示永不过期
+}
+
+// apiTokenCreateResponse 创建令牌的响应，Token 为明文令牌，只返回这一次
+type apiTokenCreateResponse struct {
+	model.APIToken
+	Token string `json:"token"`
+}
+
+// 添加个人访问令牌相关路由
+func setupAPITokenRoutes(group *gin.RouterGroup) {
+	group.GET("/tokens", listAPITokensHandler)
+	group.POST("/tokens", createAPITokenHandler)
+	group.DELETE("/tokens/:id", revokeAPITokenHandler)
+}
+
+// ListAPITokensHandler godoc
+// @Summary 查询个人访问令牌
+// @Description 返回所有个人访问令牌（不含明文），包括最近使用时间
+// @Tags token
+// @Produce json
+// @Success 200 {array} model.APIToken
+// @Failure 500 {object} map[string]string
+// @Router /api/tokens [get]
+func listAPITokensHandler(c *gin.Context) {
+	tokens, err := service.ListAPITokens()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, SuccessResponse(tokens, "API tokens retrieved successfully"))
+}
+
+// CreateAPITokenHandler godoc
+// @Summary 创建个人访问令牌
+// @Description 创建长期有效的令牌，用于脚本等非浏览器客户端。只读令牌只能发起 GET 请求，明文令牌只在创建时返回
+// @Tags token
+// @Accept json
+// @Produce json
+// @Param token body apiTokenCreateRequest true "令牌信息"
+// @Success 200 {object} apiTokenCreateResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/tokens [post]
+func createAPITokenHandler(c *gin.Context) {
+	var request apiTokenCreateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	record, token, err := service.CreateAPIToken(request.Name, request.Scope, request.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidAPITokenRequest) {
+			c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, SuccessResponse(apiTokenCreateResponse{APIToken: *record, Token: token}, "API token created successfully"))
+}
+
+// RevokeAPITokenHandler godoc
+// @Summary 撤销个人访问令牌
+// @Tags token
+// @Produce json
+// @Param id path int true "令牌ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/tokens/{id} [delete]
+func revokeAPITokenHandler(c *gin.Context) {
+	var id uint
+	if err := parseUintParam(c, "id", &id); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	if err := service.RevokeAPIToken(id); err != nil {
+		if errors.Is(err, model.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse(http.StatusNotFound, "API token not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, SuccessResponse(nil, "API token revoked successfully"))
+}
@@ -0,0 +1,65 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/blacksheepaul/timelog/service"
+	"github.com/gin-gonic/gin"
+)
+
+// 添加导出相关路由
+func setupExportRoutes(group *gin.RouterGroup) {
+	group.GET("/export", exportHandler)
+}
+
+// flushWriter 每次写入后立即把数据推送给客户端
+type flushWriter struct {
+	w gin.ResponseWriter
+}
+
+func (f flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	f.w.Flush()
+	return n, err
+}
+
+// ExportHandler godoc
+// @Summary 导出数据
+// @Description 流式导出时间日志（含分类路径与任务标题）、任务、分类树与约束，时间使用配置的本地时区。
+// @Description json 输出一个对象，每种类型为一个数组；ndjson 每行一条带 type 字段的记录；csv 一次只导出一种类型（默认 timelogs）。
+// @Description from/to 限定时间日志的开始日期与任务的截止日期，分类与约束总是全量导出
+// @Tags export
+// @Produce json
+// @Produce text/csv
+// @Param format query string false "导出格式 csv|json|ndjson (默认 json)"
+// @Param type query string false "导出类型，逗号分隔 categories,constraints,tasks,timelogs (默认全部，csv 默认 timelogs)"
+// @Param from query string false "开始日期 (YYYY-MM-DD)"
+// @Param to query string false "结束日期 (YYYY-MM-DD)"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Router /api/export [get]
+func exportHandler(c *gin.Context) {
+	opts := service.ExportOptions{
+		Format: c.Query("format"),
+		From:   c.Query("from"),
+		To:     c.Query("to"),
+	}
+	if types := c.Query("type"); types != "" {
+		opts.Types = strings.Split(types, ",")
+	}
+	if err := opts.Normalize(); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.Header("Content-Type", opts.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, opts.FileName()))
+	c.Status(http.StatusOK)
+	// 响应头已经发出，导出中途出错只能记录日志并中断输出
+	if err := service.Export(flushWriter{c.Writer}, opts); err != nil {
+		log.Errorw("Failed to export data", "format", opts.Format, "error", err)
+		c.Abort()
+	}
+}
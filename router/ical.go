@@ -0,0 +1,197 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/service"
+	"github.com/gin-gonic/gin"
+)
+
+type calendarFeedCreateRequest struct {
+	Name string `json:"name" binding:"required"`
+	Kind string `json:"kind" binding:"required"` // timelogs|tasks
+}
+
+// calendarFeedCreateResponse 创建订阅源的响应，Token 与 URL 只返回这一次
+type calendarFeedCreateResponse struct {
+	model.CalendarFeed
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+// 添加 iCalendar 订阅相关路由
+// 订阅地址不经过 Auth，由地址中的令牌认证；订阅源的管理只允许登录会话访问
+func setupICalRoutes(public, account *gin.RouterGroup) {
+	public.GET("/ical/timelogs.ics", icalTimeLogsHandler)
+	public.GET("/ical/tasks.ics", icalTasksHandler)
+
+	account.GET("/ical/feeds", listCalendarFeedsHandler)
+	account.POST("/ical/feeds", createCalendarFeedHandler)
+	account.DELETE("/ical/feeds/:id", revokeCalendarFeedHandler)
+}
+
+// ICalTimeLogsHandler godoc
+// @Summary 时间日志日历订阅
+// @Description 以 RFC 5545 VEVENT 输出已结束的时间日志，分类路径为 CATEGORIES，备注为 DESCRIPTION，时间为 UTC。
+// @Description 日历客户端无法使用 passkey，订阅地址中的 token 即为凭据，只能读取 timelogs 类型的订阅源
+// @Tags ical
+// @Produce text/calendar
+// @Param token query string true "订阅源令牌"
+// @Param days query int false "包含最近多少天的记录 (默认 90，0 表示全部)"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /api/ical/timelogs.ics [get]
+func icalTimeLogsHandler(c *gin.Context) {
+	feed, ok := authenticateCalendarFeed(c, service.CalendarFeedTimeLogs)
+	if !ok {
+		return
+	}
+	days := service.DefaultICalTimeLogDays
+	if raw := c.Query("days"); raw != "" {
+		var err error
+		if days, err = strconv.Atoi(raw); err != nil || days < 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, "days must be a non-negative integer"))
+			return
+		}
+	}
+	writeICal(c, feed, func() error {
+		return service.WriteTimeLogsICal(c.Writer, feed, days)
+	})
+}
+
+// ICalTasksHandler godoc
+// @Summary 任务日历订阅
+// @Description 以 RFC 5545 VTODO 输出未删除的任务，截止时间为 DUE，分类路径为 CATEGORIES，任务描述为 DESCRIPTION。
+// @Description 订阅地址中的 token 即为凭据，只能读取 tasks 类型的订阅源
+// @Tags ical
+// @Produce text/calendar
+// @Param token query string true "订阅源令牌"
+// @Success 200 {file} file
+// @Failure 401 {object} map[string]string
+// @Router /api/ical/tasks.ics [get]
+func icalTasksHandler(c *gin.Context) {
+	feed, ok := authenticateCalendarFeed(c, service.CalendarFeedTasks)
+	if !ok {
+		return
+	}
+	writeICal(c, feed, func() error {
+		return service.WriteTasksICal(c.Writer, feed)
+	})
+}
+
+// authenticateCalendarFeed 校验订阅地址中的令牌，失败时已写出响应
+func authenticateCalendarFeed(c *gin.Context, kind string) (*model.CalendarFeed, bool) {
+	feed, err := service.ValidateCalendarFeed(c.Query("token"), kind)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCalendarFeed) {
+			c.JSON(http.StatusUnauthorized, ErrorResponse(http.StatusUnauthorized, err.Error()))
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return nil, false
+	}
+	return feed, true
+}
+
+func writeICal(c *gin.Context, feed *model.CalendarFeed, write func() error) {
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.ics"`, feed.Kind))
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Status(http.StatusOK)
+	// 响应头已经发出，输出中途出错只能记录日志并中断输出
+	if err := write(); err != nil {
+		log.Errorw("Failed to write calendar feed", "feed_id", feed.ID, "kind", feed.Kind, "error", err)
+		c.Abort()
+	}
+}
+
+// ListCalendarFeedsHandler godoc
+// @Summary 查询日历订阅源
+// @Description 返回所有日历订阅源（不含令牌），包括最近被日历客户端拉取的时间
+// @Tags ical
+// @Produce json
+// @Success 200 {array} model.CalendarFeed
+// @Failure 500 {object} map[string]string
+// @Router /api/ical/feeds [get]
+func listCalendarFeedsHandler(c *gin.Context) {
+	feeds, err := service.ListCalendarFeeds()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, SuccessResponse(feeds, "Calendar feeds retrieved successfully"))
+}
+
+// CreateCalendarFeedHandler godoc
+// @Summary 创建日历订阅源
+// @Description 创建 timelogs 或 tasks 订阅源，返回带令牌的订阅地址，只返回这一次。撤销订阅源即可让地址失效
+// @Tags ical
+// @Accept json
+// @Produce json
+// @Param feed body calendarFeedCreateRequest true "订阅源信息"
+// @Success 200 {object} calendarFeedCreateResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/ical/feeds [post]
+func createCalendarFeedHandler(c *gin.Context) {
+	var request calendarFeedCreateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	feed, token, err := service.CreateCalendarFeed(request.Name, request.Kind)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCalendarFeedRequest) {
+			c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return
+	}
+	response := calendarFeedCreateResponse{CalendarFeed: *feed, Token: token, URL: calendarFeedURL(c, feed.Kind, token)}
+	c.JSON(http.StatusOK, SuccessResponse(response, "Calendar feed created successfully"))
+}
+
+// calendarFeedURL 根据当前请求的地址拼出订阅地址，反向代理后面需要传递 X-Forwarded-Proto
+func calendarFeedURL(c *gin.Context, kind, token string) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/api/ical/%s.ics?token=%s", scheme, c.Request.Host, kind, url.QueryEscape(token))
+}
+
+// RevokeCalendarFeedHandler godoc
+// @Summary 撤销日历订阅源
+// @Tags ical
+// @Produce json
+// @Param id path int true "订阅源ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/ical/feeds/{id} [delete]
+func revokeCalendarFeedHandler(c *gin.Context) {
+	var id uint
+	if err := parseUintParam(c, "id", &id); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	if err := service.RevokeCalendarFeed(id); err != nil {
+		if errors.Is(err, model.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse(http.StatusNotFound, "Calendar feed not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, SuccessResponse(nil, "Calendar feed revoked successfully"))
+}
@@ -0,0 +1,93 @@
+package router
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/blacksheepaul/timelog/service"
+	"github.com/gin-gonic/gin"
+)
+
+// 添加导入相关路由
+func setupImportRoutes(group *gin.RouterGroup) {
+	group.POST("/import/timelogs", importTimeLogsHandler)
+}
+
+// ImportTimeLogsHandler godoc
+// @Summary 导入外部时间记录
+// @Description 导入 Toggl Track / Clockify 导出的明细 CSV、timew export 输出的 JSON 或 org-mode 文件中的 CLOCK 记录。
+// @Description 客户/项目/标签（Timewarrior 为标签，org-mode 为所在标题层级）映射为分类路径（缺失的分类逐级创建，已删除的同名分类被恢复，超过最大层级的部分被截断），
+// @Description 描述作为备注，任务列或描述与已有任务标题相同时关联该任务。先以 dry_run=true 预览映射，确认后再提交；整个导入在一个事务中完成，
+// @Description 有无法导入的记录时不写入任何数据并返回 422 与逐条原因。开始与结束时间都与已有日志相同的记录视为重复跳过
+// @Tags import
+// @Accept text/csv
+// @Accept multipart/form-data
+// @Produce json
+// @Param source query string false "来源 toggl|clockify|timewarrior|org (默认根据文件内容识别)"
+// @Param dry_run query bool false "只预览不写入"
+// @Param timezone query string false "文件中时间所在的时区 (IANA 名称，默认为配置的时区)"
+// @Param day_first query bool false "按 日/月/年 解析斜杠分隔的日期"
+// @Param default_category query string false "没有客户、项目与标签的记录放入的分类路径 (默认 Imported)"
+// @Param file formData file false "导出文件（也可以直接作为请求体发送）"
+// @Success 200 {object} service.TimeLogImportReport
+// @Failure 400 {object} map[string]string
+// @Failure 422 {object} service.TimeLogImportReport
+// @Failure 500 {object} map[string]string
+// @Router /api/import/timelogs [post]
+func importTimeLogsHandler(c *gin.Context) {
+	dryRun, ok := parseDryRun(c)
+	if !ok {
+		return
+	}
+	opts := service.TimeLogImportOptions{
+		Source:          c.Query("source"),
+		DryRun:          dryRun,
+		Timezone:        c.Query("timezone"),
+		DefaultCategory: c.Query("default_category"),
+	}
+	if value := c.Query("day_first"); value != "" {
+		dayFirst, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, "invalid day_first parameter"))
+			return
+		}
+		opts.DayFirst = dayFirst
+	}
+
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, "file is required"))
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, err.Error()))
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	report, err := service.ImportTimeLogs(body, opts)
+	switch {
+	case errors.Is(err, service.ErrImportRowsInvalid):
+		c.JSON(http.StatusUnprocessableEntity, ApiResponse{Data: report, Message: err.Error(), Status: http.StatusUnprocessableEntity})
+		return
+	case errors.Is(err, service.ErrInvalidImport):
+		c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, err.Error()))
+		return
+	case err != nil:
+		log.Errorw("Failed to import time logs", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return
+	}
+	if !dryRun {
+		log.Infow("Imported time logs", "source", report.Source, "imported", report.Imported, "duplicates", report.Duplicates)
+	}
+	c.JSON(http.StatusOK, SuccessResponse(report, "Time logs imported successfully"))
+}
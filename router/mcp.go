@@ -0,0 +1,36 @@
+package router
+
+import (
+	"net/http"
+
+	mcphandler "github.com/blacksheepaul/timelog/mcp/handler"
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/router/middleware"
+	"github.com/blacksheepaul/timelog/service"
+	"github.com/gin-gonic/gin"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// 在主服务上挂载 streamable HTTP MCP 端点，与 REST API 共用数据库连接、日志和认证，
+// 避免独立的 MCP 进程与主服务同时写同一个 SQLite 文件
+func setupMCPRoutes(r *gin.Engine) {
+	db := model.GetDao().Db()
+	mcphandler.SetLogger(log)
+
+	// 只读令牌只能拿到只读工具；登录会话和读写令牌在开启 allow_writes 时可以使用写工具
+	readServer := mcphandler.NewServer(db, appConfig, false)
+	writeServer := readServer
+	if appConfig.MCP.AllowWrites {
+		writeServer = mcphandler.NewServer(db, appConfig, true)
+	}
+	readHandler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return readServer }, nil)
+	writeHandler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return writeServer }, nil)
+
+	r.Any("/mcp", middleware.AuthAnyMethod(), func(c *gin.Context) {
+		if scope, ok := c.Get(middleware.APITokenScopeKey); ok && scope != service.APITokenScopeReadWrite {
+			readHandler.ServeHTTP(c.Writer, c.Request)
+			return
+		}
+		writeHandler.ServeHTTP(c.Writer, c.Request)
+	})
+}
@@ -3,14 +3,23 @@ package middleware
 import (
 	"errors"
 	"strings"
-	"sync"
 
-	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/service"
 
 	"github.com/gin-gonic/gin"
 )
 
 func Auth() gin.HandlerFunc {
+	return authenticate(true)
+}
+
+// AuthAnyMethod 与 Auth 相同，但不按请求方法校验个人访问令牌的权限范围，
+// 由调用方根据 APITokenScopeKey 自行限制（MCP 的读写请求都是 POST）
+func AuthAnyMethod() gin.HandlerFunc {
+	return authenticate(false)
+}
+
+func authenticate(checkScope bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		session, err := GetSessionFromHeader(c)
 		if err != nil {
@@ -18,31 +27,65 @@ func Auth() gin.HandlerFunc {
 			return
 		}
 
-		if !isValidUserToken(session) {
+		// 个人访问令牌按权限范围校验请求方法
+		if service.IsAPIToken(session) {
+			token, err := service.ValidateAPIToken(session)
+			if err != nil {
+				c.AbortWithStatusJSON(401, gin.H{
+					"msg": "Invalid or expired token",
+				})
+				return
+			}
+			if checkScope && !service.APITokenAllows(token, c.Request.Method) {
+				c.AbortWithStatusJSON(403, gin.H{
+					"msg": "Token scope does not allow this request",
+				})
+				return
+			}
+			c.Set(APITokenIDKey, token.ID)
+			c.Set(APITokenScopeKey, token.Scope)
+			c.Next()
+			return
+		}
+
+		// 只接受 sessions 表中的会话令牌，passkey 登录流程的 session id 不能用于认证
+		id, err := service.ValidateSessionToken(session, service.SessionMeta{
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		})
+		if err != nil {
 			c.AbortWithStatusJSON(401, gin.H{
 				"msg": "Invalid or expired token",
 			})
 			return
 		}
 
+		c.Set(SessionIDKey, id)
 		c.Next()
 	}
 }
 
-var dao *model.Dao
-var once sync.Once
-
-func isValidUserToken(token string) bool {
-	once.Do(func() {
-		dao = model.GetDao()
-	})
-	// Only accept keys with auth_token: prefix to prevent passkey session misuse
-	if _, ok := dao.GetCache("auth_token:" + token); ok {
-		return true
+// SessionOnly 只允许通过登录会话认证的请求，用于管理会话与令牌，避免令牌自行签发新令牌
+// 必须放在 Auth 之后
+func SessionOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get(SessionIDKey); !ok {
+			c.AbortWithStatusJSON(403, gin.H{
+				"msg": "This endpoint requires a login session",
+			})
+			return
+		}
+		c.Next()
 	}
-	return false
 }
 
+// 认证通过后当前会话ID或个人访问令牌ID在 gin.Context 中的键
+const (
+	SessionIDKey     = "session_id"
+	APITokenIDKey    = "api_token_id"
+	APITokenScopeKey = "api_token_scope"
+)
+
 var (
 	ErrNoSession      = errors.New("no session found")
 	ErrInvalidSession = errors.New("invalid session")
@@ -28,6 +28,7 @@ func (l FakeLogger) Fatalw(msg string, keysAndValues ...interface{}) {}
 func setupTestEnvironment() {
 	cfg := &config.Config{}
 	cfg.Database.Host = ":memory:"
+	cfg.Database.AutoMigrate = true
 	cfg.Log.ORMLogLevel = 1
 	model.InitDao(cfg, FakeLogger{})
 	service.InitService(FakeLogger{}, cfg)
@@ -113,3 +114,99 @@ func TestAuthMiddlewareRejectsUnprefixedCacheKey(t *testing.T) {
 
 	t.Log("✓ Auth middleware correctly rejects unprefixed cache keys")
 }
+
+func TestAuthMiddlewareAPITokenScope(t *testing.T) {
+	setupTestEnvironment()
+	gin.SetMode(gin.TestMode)
+
+	_, readToken, err := service.CreateAPIToken("read only", service.APITokenScopeRead, nil)
+	if err != nil {
+		t.Fatalf("Failed to create api token: %v", err)
+	}
+
+	cases := []struct {
+		method string
+		code   int
+	}{
+		{"GET", 200},
+		{"POST", 403},
+		{"DELETE", 403},
+	}
+	for _, tc := range cases {
+		r := gin.New()
+		r.Use(Auth())
+		r.Handle(tc.method, "/test", func(c *gin.Context) { c.Status(200) })
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tc.method, "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+readToken)
+		r.ServeHTTP(w, req)
+		if w.Code != tc.code {
+			t.Errorf("%s with read token: expected %d, got %d", tc.method, tc.code, w.Code)
+		}
+	}
+}
+
+func TestSessionOnlyRejectsAPIToken(t *testing.T) {
+	setupTestEnvironment()
+	gin.SetMode(gin.TestMode)
+
+	_, apiToken, err := service.CreateAPIToken("read-write", service.APITokenScopeReadWrite, nil)
+	if err != nil {
+		t.Fatalf("Failed to create api token: %v", err)
+	}
+	sessionToken := "session-only-token"
+	if err := service.StoreSessionToken(sessionToken, 300); err != nil {
+		t.Fatalf("Failed to store session token: %v", err)
+	}
+
+	r := gin.New()
+	r.Use(Auth(), SessionOnly())
+	r.POST("/tokens", func(c *gin.Context) { c.Status(200) })
+
+	for token, code := range map[string]int{apiToken: 403, sessionToken: 200} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/tokens", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+		if w.Code != code {
+			t.Errorf("Expected %d for token %q, got %d", code, token[:8], w.Code)
+		}
+	}
+}
+
+func TestAuthAnyMethodExposesTokenScope(t *testing.T) {
+	setupTestEnvironment()
+	gin.SetMode(gin.TestMode)
+
+	_, readToken, err := service.CreateAPIToken("mcp read", service.APITokenScopeRead, nil)
+	if err != nil {
+		t.Fatalf("Failed to create api token: %v", err)
+	}
+
+	var scope interface{}
+	r := gin.New()
+	r.Use(AuthAnyMethod())
+	r.POST("/mcp", func(c *gin.Context) {
+		scope, _ = c.Get(APITokenScopeKey)
+		c.Status(200)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/mcp", nil)
+	req.Header.Set("Authorization", "Bearer "+readToken)
+	r.ServeHTTP(w, req)
+	if w.Code != 200 {
+		t.Fatalf("Expected read token to pass without scope check, got %d", w.Code)
+	}
+	if scope != service.APITokenScopeRead {
+		t.Errorf("Expected scope %q in context, got %v", service.APITokenScopeRead, scope)
+	}
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("POST", "/mcp", nil)
+	r.ServeHTTP(w, req)
+	if w.Code != 401 {
+		t.Errorf("Expected 401 without token, got %d", w.Code)
+	}
+}
@@ -226,7 +226,14 @@ func passkeyLoginFinishHandler(c *gin.Context) {
 		return
 	}
 
-	if err := service.StoreSessionToken(token, int64(appConfig.Passkey.TokenTTL)); err != nil {
+	deviceName := strings.TrimSpace(request.DeviceName)
+	if deviceName == "" {
+		if record, err := service.LoadPasskeyCredentialByID(credential.ID); err == nil {
+			deviceName = record.DeviceName
+		}
+	}
+	meta := service.SessionMeta{DeviceLabel: deviceName, IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	if _, err := service.CreateSession(token, int64(appConfig.Passkey.TokenTTL), meta); err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse(http.StatusInternalServerError, err.Error()))
 		return
 	}
@@ -0,0 +1,87 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/service"
+	"github.com/gin-gonic/gin"
+)
+
+// 添加报告相关路由
+func setupReportRoutes(group *gin.RouterGroup) {
+	group.GET("/reports", listReportsHandler)
+	group.GET("/reports/:date", getReportHandler)
+}
+
+// ListReportsHandler godoc
+// @Summary 查询日报列表
+// @Description 按日期倒序返回已生成的日报
+// @Tags report
+// @Produce json
+// @Param from query string false "开始日期 (YYYY-MM-DD)"
+// @Param to query string false "结束日期 (YYYY-MM-DD)"
+// @Param limit query int false "返回条数 (默认 30)"
+// @Success 200 {array} service.ReportEntry
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/reports [get]
+func listReportsHandler(c *gin.Context) {
+	from, to := c.Query("from"), c.Query("to")
+	for _, d := range []string{from, to} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, "Invalid date format, expected YYYY-MM-DD"))
+			return
+		}
+	}
+
+	limit := 30
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	reports, err := service.ListDailyReports(from, to, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, SuccessResponse(reports, "Reports retrieved successfully"))
+}
+
+// GetReportHandler godoc
+// @Summary 获取指定日期的日报
+// @Description 返回已生成的日报
+// @Tags report
+// @Produce json
+// @Param date path string true "日期 (YYYY-MM-DD格式)"
+// @Success 200 {object} service.ReportEntry
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/reports/{date} [get]
+func getReportHandler(c *gin.Context) {
+	dateStr := c.Param("date")
+	if _, err := time.Parse("2006-01-02", dateStr); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, "Invalid date format, expected YYYY-MM-DD"))
+		return
+	}
+
+	report, err := service.GetDailyReport(dateStr)
+	if err != nil {
+		if errors.Is(err, model.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse(http.StatusNotFound, "Report not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, SuccessResponse(report, "Report retrieved successfully"))
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io/fs"
 	"net/http"
+	"regexp"
 	"sync"
 	"time"
 
@@ -20,12 +21,20 @@ var GinLogger = gin.LoggerWithFormatter(func(p gin.LogFormatterParams) string {
 		p.TimeStamp.Format(time.DateTime),
 		p.ClientIP,
 		p.Method,
-		p.Path,
+		redactQueryToken(p.Path),
 		p.Latency,
 		p.StatusCode,
 		p.ErrorMessage,
 	)
 })
+
+// tokenQueryPattern 日历订阅地址中的令牌，写日志前替换掉
+var tokenQueryPattern = regexp.MustCompile(`([?&]token=)[^&]*`)
+
+func redactQueryToken(path string) string {
+	return tokenQueryPattern.ReplaceAllString(path, "${1}REDACTED")
+}
+
 var log logger.Logger
 var appConfig *config.Config
 
@@ -43,14 +52,50 @@ func Register(r *gin.Engine, cfg *config.Config, l logger.Logger, staticFiles em
 	// 注册 TimeLog 路由
 	RegisterTimeLogRoutes(protected)
 
+	// 注册 Timer 路由
+	setupTimerRoutes(protected)
+
 	// 注册 Task 路由
 	setupTaskRoutes(protected)
 
+	// 注册 Stats 路由
+	setupStatsRoutes(protected)
+
+	// 注册 Report 路由
+	setupReportRoutes(protected)
+
 	// 注册 Constraint 路由
 	setupConstraintRoutes(protected)
 
+	// 注册 Export 路由
+	setupExportRoutes(protected)
+
+	// 注册 Import 路由
+	setupImportRoutes(protected)
+
+	// 注册 Admin 路由
+	setupAdminRoutes(protected)
+
+	// 会话、令牌与 passkey 凭据的管理只允许登录会话访问
+	account := protected.Group("")
+	account.Use(middleware.SessionOnly())
+
+	// 注册 Session 路由
+	setupSessionRoutes(account)
+
+	// 注册 API Token 路由
+	setupAPITokenRoutes(account)
+
 	// 注册 Passkey 路由
-	setupPasskeyRoutes(api, protected)
+	setupPasskeyRoutes(api, account)
+
+	// 注册 iCalendar 订阅路由，订阅地址由令牌认证
+	setupICalRoutes(api, account)
+
+	// 注册 MCP 路由（可选）
+	if cfg.MCP.Mount {
+		setupMCPRoutes(r)
+	}
 
 	// 注册 Swagger 文档路由（仅非 prod 构建）
 	setupSwagger(r)
@@ -0,0 +1,92 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/router/middleware"
+	"github.com/blacksheepaul/timelog/service"
+	"github.com/gin-gonic/gin"
+)
+
+// sessionDTO 登录会话，Current 表示发起请求的会话
+type sessionDTO struct {
+	model.Session
+	Current bool `json:"current"`
+}
+
+// 添加会话相关路由
+func setupSessionRoutes(group *gin.RouterGroup) {
+	group.GET("/sessions", listSessionsHandler)
+	group.DELETE("/sessions/:id", revokeSessionHandler)
+	group.POST("/logout", logoutHandler)
+}
+
+// ListSessionsHandler godoc
+// @Summary 查询登录会话
+// @Description 按最近活动时间倒序返回未过期的登录会话
+// @Tags session
+// @Produce json
+// @Success 200 {array} sessionDTO
+// @Failure 500 {object} map[string]string
+// @Router /api/sessions [get]
+func listSessionsHandler(c *gin.Context) {
+	sessions, err := service.ListSessions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return
+	}
+
+	current := c.GetUint(middleware.SessionIDKey)
+	dtos := make([]sessionDTO, 0, len(sessions))
+	for _, s := range sessions {
+		dtos = append(dtos, sessionDTO{Session: s, Current: s.ID == current})
+	}
+	c.JSON(http.StatusOK, SuccessResponse(dtos, "Sessions retrieved successfully"))
+}
+
+// RevokeSessionHandler godoc
+// @Summary 撤销登录会话
+// @Description 撤销指定会话，之后使用该会话令牌的请求会返回 401
+// @Tags session
+// @Produce json
+// @Param id path int true "会话ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/sessions/{id} [delete]
+func revokeSessionHandler(c *gin.Context) {
+	var id uint
+	if err := parseUintParam(c, "id", &id); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	if err := service.RevokeSession(id); err != nil {
+		if errors.Is(err, model.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse(http.StatusNotFound, "Session not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, SuccessResponse(nil, "Session revoked successfully"))
+}
+
+// LogoutHandler godoc
+// @Summary 退出登录
+// @Description 撤销当前请求使用的会话
+// @Tags session
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/logout [post]
+func logoutHandler(c *gin.Context) {
+	if err := service.RevokeSession(c.GetUint(middleware.SessionIDKey)); err != nil && !errors.Is(err, model.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, SuccessResponse(nil, "Logged out successfully"))
+}
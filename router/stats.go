@@ -0,0 +1,54 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/service"
+	"github.com/gin-gonic/gin"
+)
+
+// 添加统计相关路由
+func setupStatsRoutes(group *gin.RouterGroup) {
+	group.GET("/stats", getStatsHandler)
+}
+
+// GetStatsHandler godoc
+// @Summary 获取分类时长统计
+// @Description 按天/周/月/年统计日期范围内各分类的时长，父分类汇总所有子分类，并给出占比
+// @Tags stats
+// @Produce json
+// @Param from query string false "开始日期 (YYYY-MM-DD，默认 to 往前 6 天)"
+// @Param to query string false "结束日期 (YYYY-MM-DD，默认今天)"
+// @Param period query string false "统计粒度 day|week|month|year (默认 day)"
+// @Success 200 {object} service.StatsReport
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/stats [get]
+func getStatsHandler(c *gin.Context) {
+	to := c.Query("to")
+	if to == "" {
+		to = model.LocalToday()
+	}
+	from := c.Query("from")
+	if from == "" {
+		toDate, err := model.ParseLocalDate(to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, "Invalid date format, expected YYYY-MM-DD"))
+			return
+		}
+		from = toDate.AddDate(0, 0, -6).Format("2006-01-02")
+	}
+
+	report, err := service.GetStats(from, to, c.Query("period"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidStatsQuery) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, ErrorResponse(status, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, SuccessResponse(report, "Stats retrieved successfully"))
+}
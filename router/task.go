@@ -1,10 +1,12 @@
 package router
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/blacksheepaul/timelog/model"
 	"github.com/blacksheepaul/timelog/model/gen"
 	"github.com/blacksheepaul/timelog/service"
 	"github.com/gin-gonic/gin"
@@ -22,6 +24,7 @@ func setupTaskRoutes(group *gin.RouterGroup) {
 	group.POST("/tasks/:id/suspend", suspendTaskHandler)
 	group.POST("/tasks/:id/unsuspend", unsuspendTaskHandler)
 	group.GET("/tasks/stats/:date", getTaskStatsHandler)
+	group.GET("/tasks/estimate-accuracy", getEstimateAccuracyHandler)
 }
 
 // CreateTaskHandler godoc
@@ -33,6 +36,7 @@ func setupTaskRoutes(group *gin.RouterGroup) {
 // @Param data body gen.Task true "任务数据"
 // @Success 200 {object} gen.Task
 // @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/tasks [post]
 func createTaskHandler(c *gin.Context) {
@@ -43,7 +47,11 @@ func createTaskHandler(c *gin.Context) {
 	}
 
 	if err := service.CreateTask(&task); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse(http.StatusInternalServerError, err.Error()))
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrCategoryArchived) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, ErrorResponse(status, err.Error()))
 		return
 	}
 
@@ -57,13 +65,13 @@ func createTaskHandler(c *gin.Context) {
 
 // ListTasksHandler godoc
 // @Summary 获取任务列表
-// @Description 获取所有任务，支持按日期过滤、是否包含暂停的任务和是否包含已完成的任务
+// @Description 获取所有任务，支持按日期过滤、是否包含暂停的任务和是否包含已完成的任务，每个任务附带实际用时与预估偏差
 // @Tags task
 // @Produce json
 // @Param date query string false "日期过滤 (YYYY-MM-DD格式)"
 // @Param include_suspended query boolean false "是否包含暂停的任务 (默认false)"
 // @Param include_completed query boolean false "是否包含已完成的任务 (默认false)"
-// @Success 200 {array} gen.Task
+// @Success 200 {array} service.TaskWithActual
 // @Failure 500 {object} map[string]string
 // @Router /api/tasks [get]
 func listTasksHandler(c *gin.Context) {
@@ -91,16 +99,22 @@ func listTasksHandler(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, SuccessResponse(tasks, "Tasks retrieved successfully"))
+	withActual, err := service.AttachTaskActuals(tasks)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse(withActual, "Tasks retrieved successfully"))
 }
 
 // GetTaskHandler godoc
 // @Summary 获取单个任务
-// @Description 根据ID获取任务详情
+// @Description 根据ID获取任务详情，附带实际用时与预估偏差
 // @Tags task
 // @Produce json
 // @Param id path int true "任务ID"
-// @Success 200 {object} gen.Task
+// @Success 200 {object} service.TaskWithActual
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
@@ -114,7 +128,7 @@ func getTaskHandler(c *gin.Context) {
 	}
 	id := int32(id64)
 
-	task, err := service.GetTaskByID(id)
+	task, err := service.GetTaskWithActualByID(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, ErrorResponse(http.StatusNotFound, "Task not found"))
 		return
@@ -131,9 +145,10 @@ func getTaskHandler(c *gin.Context) {
 // @Produce json
 // @Param id path int true "任务ID"
 // @Param data body gen.Task true "任务数据"
-// @Success 200 {object} gen.Task
+// @Success 200 {object} service.TaskWithActual
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/tasks/{id} [put]
 func updateTaskHandler(c *gin.Context) {
@@ -163,12 +178,16 @@ func updateTaskHandler(c *gin.Context) {
 	updateData.CreatedAt = existingTask.CreatedAt
 
 	if err := service.UpdateTask(&updateData); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse(http.StatusInternalServerError, err.Error()))
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrCategoryArchived) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, ErrorResponse(status, err.Error()))
 		return
 	}
 
 	// 重新查询以获取完整信息
-	if updatedTask, err := service.GetTaskByID(id); err == nil {
+	if updatedTask, err := service.GetTaskWithActualByID(id); err == nil {
 		c.JSON(http.StatusOK, SuccessResponse(updatedTask, "Task updated successfully"))
 	} else {
 		c.JSON(http.StatusOK, SuccessResponse(updateData, "Task updated successfully"))
@@ -335,7 +354,7 @@ func unsuspendTaskHandler(c *gin.Context) {
 
 // GetTaskStatsHandler godoc
 // @Summary 获取任务统计
-// @Description 获取指定日期的任务完成统计
+// @Description 获取指定日期的任务完成统计，包含预估时长、实际用时与偏差
 // @Tags task
 // @Produce json
 // @Param date path string true "日期 (YYYY-MM-DD格式)"
@@ -359,3 +378,42 @@ func getTaskStatsHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, SuccessResponse(stats, "Task stats retrieved successfully"))
 }
+
+// GetEstimateAccuracyHandler godoc
+// @Summary 获取任务预估准确度
+// @Description 统计日期范围内完成的、有预估时长的任务的实际用时与预估偏差，按分类和周期汇总
+// @Tags task
+// @Produce json
+// @Param from query string false "开始日期 (YYYY-MM-DD，默认 to 往前 29 天)"
+// @Param to query string false "结束日期 (YYYY-MM-DD，默认今天)"
+// @Param period query string false "统计粒度 day|week|month|year (默认 week)"
+// @Success 200 {object} service.EstimateAccuracyReport
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/tasks/estimate-accuracy [get]
+func getEstimateAccuracyHandler(c *gin.Context) {
+	to := c.Query("to")
+	if to == "" {
+		to = model.LocalToday()
+	}
+	from := c.Query("from")
+	if from == "" {
+		toDate, err := model.ParseLocalDate(to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, "Invalid date format, expected YYYY-MM-DD"))
+			return
+		}
+		from = toDate.AddDate(0, 0, -29).Format("2006-01-02")
+	}
+
+	report, err := service.GetEstimateAccuracy(from, to, c.Query("period"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidStatsQuery) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, ErrorResponse(status, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, SuccessResponse(report, "Estimate accuracy retrieved successfully"))
+}
@@ -1,9 +1,12 @@
 package router
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/blacksheepaul/timelog/model"
 	"github.com/blacksheepaul/timelog/model/gen"
 	"github.com/blacksheepaul/timelog/service"
 	"github.com/gin-gonic/gin"
@@ -42,6 +45,7 @@ func ErrorResponse(status int, message string) ApiResponse {
 func RegisterTimeLogRoutes(group *gin.RouterGroup) {
 	group.POST("/timelogs", createTimeLogHandler)
 	group.GET("/timelogs", listTimeLogsHandler)
+	group.GET("/timelogs/gaps", getTimeLogGapsHandler)
 	group.GET("/timelogs/:id", getTimeLogHandler)
 	group.PUT("/timelogs/:id", updateTimeLogHandler)
 	group.DELETE("/timelogs/:id", deleteTimeLogHandler)
@@ -50,10 +54,44 @@ func RegisterTimeLogRoutes(group *gin.RouterGroup) {
 	group.GET("/categories", listCategoriesHandler)
 	group.GET("/categories/tree", getCategoryTreeHandler)
 	group.POST("/categories", createCategoryHandler)
+	group.POST("/categories/reorder", reorderCategoriesHandler)
 	group.GET("/categories/:id", getCategoryHandler)
 	group.PUT("/categories/:id", updateCategoryHandler)
-
+	group.DELETE("/categories/:id", deleteCategoryHandler)
 	group.POST("/categories/:id/move", moveCategoryHandler)
+	group.POST("/categories/:id/merge", mergeCategoryHandler)
+	group.POST("/categories/:id/archive", archiveCategoryHandler)
+	group.POST("/categories/:id/unarchive", unarchiveCategoryHandler)
+}
+
+// timeLogErrorStatus 将时间日志/计时器相关错误映射为 HTTP 状态码
+func timeLogErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidTimeRange):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrTimeLogOverlap), errors.Is(err, service.ErrTimerAlreadyRunning), errors.Is(err, service.ErrCategoryArchived):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrNoRunningTimer):
+		return http.StatusNotFound
+	case errors.Is(err, model.ErrRecordNotFound):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// categoryErrorStatus 将分类合并/删除/排序/归档相关错误映射为 HTTP 状态码
+func categoryErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, model.ErrInvalidCategoryTarget), errors.Is(err, model.ErrInvalidCategoryOrder):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrCategoryArchived):
+		return http.StatusConflict
+	case errors.Is(err, model.ErrRecordNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
 }
 
 // CreateTimeLogHandler godoc
@@ -65,6 +103,7 @@ func RegisterTimeLogRoutes(group *gin.RouterGroup) {
 // @Param data body gen.Timelog true "时间日志数据"
 // @Success 200 {object} gen.Timelog
 // @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/timelogs [post]
 func createTimeLogHandler(c *gin.Context) {
@@ -74,7 +113,8 @@ func createTimeLogHandler(c *gin.Context) {
 		return
 	}
 	if err := service.CreateTimeLog(&tl); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse(http.StatusInternalServerError, err.Error()))
+		status := timeLogErrorStatus(err)
+		c.JSON(status, ErrorResponse(status, err.Error()))
 		return
 	}
 
@@ -90,43 +130,78 @@ func createTimeLogHandler(c *gin.Context) {
 
 // ListTimeLogsHandler godoc
 // @Summary 查询时间日志列表
-// @Description 获取所有时间日志
+// @Description 按日期范围、分类子树、任务、备注文本和状态过滤时间日志
+// @Description 提供 page/size/cursor 任一参数时返回分页结构（含总数和 next_cursor），否则返回数组
 // @Tags timelog
 // @Produce json
-// @Param limit query int false "Limit number of results"
-// @Param order query string false "Order by field (default: created_at DESC)"
+// @Param from query string false "开始日期 (YYYY-MM-DD，本地日期，含)"
+// @Param to query string false "结束日期 (YYYY-MM-DD，本地日期，含)"
+// @Param category_id query int false "分类ID，包含所有子分类"
+// @Param task_id query int false "任务ID"
+// @Param q query string false "备注包含的文本"
+// @Param state query string false "open|closed|all (默认 all)"
+// @Param sort query string false "排序字段: id|start_time|end_time|created_at|updated_at，前缀 - 表示倒序 (默认 -start_time)"
+// @Param order query string false "兼容旧参数，如 created_at DESC"
+// @Param limit query int false "不分页时限制返回条数"
+// @Param page query int false "页码，从 1 开始"
+// @Param size query int false "每页条数 (默认 20，最大 200)"
+// @Param cursor query string false "上一页返回的 next_cursor"
 // @Success 200 {array} gen.Timelog
+// @Success 200 {object} service.Response
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/timelogs [get]
 func listTimeLogsHandler(c *gin.Context) {
-	limitStr := c.Query("limit")
-	orderBy := c.Query("order")
+	var filter service.TimeLogFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, err.Error()))
+		return
+	}
 
-	var tls []gen.Timelog
+	var data interface{}
 	var err error
-
-	if limitStr != "" || orderBy != "" {
-		limit := 0
-		if limitStr != "" {
-			if l, parseErr := strconv.Atoi(limitStr); parseErr == nil && l > 0 {
-				limit = l
-			}
-		}
-
-		if orderBy == "" {
-			orderBy = "created_at DESC"
+	if filter.Paginated() {
+		data, err = service.SearchTimeLogsPaged(&filter)
+	} else {
+		data, err = service.SearchTimeLogs(&filter)
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidTimeLogQuery) || errors.Is(err, service.ErrInvalidCursor) {
+			status = http.StatusBadRequest
 		}
+		c.JSON(status, ErrorResponse(status, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, SuccessResponse(data, "Time logs retrieved successfully"))
+}
 
-		tls, err = service.ListTimeLogsWithOptions(limit, orderBy)
-	} else {
-		tls, err = service.ListTimeLogs()
+// GetTimeLogGapsHandler godoc
+// @Summary 查询未记录的时间区间
+// @Description 返回指定日期内没有被任何时间日志覆盖的区间，当天未到来的部分不计入
+// @Tags timelog
+// @Produce json
+// @Param date query string false "日期 (YYYY-MM-DD格式，默认今天)"
+// @Success 200 {object} service.DayGapReport
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/timelogs/gaps [get]
+func getTimeLogGapsHandler(c *gin.Context) {
+	dateStr := c.Query("date")
+	if dateStr == "" {
+		dateStr = model.LocalToday()
+	}
+	if _, err := time.Parse("2006-01-02", dateStr); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, "Invalid date format, expected YYYY-MM-DD"))
+		return
 	}
 
+	report, err := service.FindTimeLogGaps(dateStr)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse(http.StatusInternalServerError, err.Error()))
 		return
 	}
-	c.JSON(http.StatusOK, SuccessResponse(tls, "Time logs retrieved successfully"))
+	c.JSON(http.StatusOK, SuccessResponse(report, "Time log gaps retrieved successfully"))
 }
 
 // GetTimeLogHandler godoc
@@ -163,6 +238,7 @@ func getTimeLogHandler(c *gin.Context) {
 // @Param data body gen.Timelog true "时间日志数据"
 // @Success 200 {object} gen.Timelog
 // @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/timelogs/{id} [put]
 func updateTimeLogHandler(c *gin.Context) {
@@ -178,7 +254,8 @@ func updateTimeLogHandler(c *gin.Context) {
 	}
 	tl.ID = &id
 	if err := service.UpdateTimeLog(&tl); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse(http.StatusInternalServerError, err.Error()))
+		status := timeLogErrorStatus(err)
+		c.JSON(status, ErrorResponse(status, err.Error()))
 		return
 	}
 
@@ -231,6 +308,19 @@ func parseUint(s string) (uint64, error) {
 	return strconv.ParseUint(s, 10, 64)
 }
 
+// parseIncludeArchived 解析 include_archived 查询参数，默认 false
+func parseIncludeArchived(c *gin.Context) (bool, error) {
+	value := c.Query("include_archived")
+	if value == "" {
+		return false, nil
+	}
+	includeArchived, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.New("invalid include_archived parameter")
+	}
+	return includeArchived, nil
+}
+
 // parseInt32Param 辅助函数
 func parseInt32Param(c *gin.Context, key string, out *int32) error {
 	idStr := c.Param(key)
@@ -246,20 +336,26 @@ func parseInt32Param(c *gin.Context, key string, out *int32) error {
 
 // listCategoriesHandler godoc
 // @Summary 查询分类列表
-// @Description 获取所有分类（扁平列表）
+// @Description 获取所有分类（扁平列表），默认不含已归档的分类及其子树
 // @Tags category
 // @Produce json
-// @Param level query int false "Filter by level (0, 1, 2)"
+// @Param level query int false "Filter by level (0 为根分类)"
 // @Param parent_id query int false "Filter by parent_id"
+// @Param include_archived query bool false "是否包含已归档的分类"
 // @Success 200 {array} gen.Category
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/categories [get]
 func listCategoriesHandler(c *gin.Context) {
 	levelStr := c.Query("level")
 	parentIDStr := c.Query("parent_id")
+	includeArchived, err := parseIncludeArchived(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, err.Error()))
+		return
+	}
 
 	var categories []gen.Category
-	var err error
 
 	if levelStr != "" {
 		level, parseErr := strconv.ParseInt(levelStr, 10, 32)
@@ -267,7 +363,7 @@ func listCategoriesHandler(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, "invalid level parameter"))
 			return
 		}
-		categories, err = service.ListCategoriesByLevel(int32(level))
+		categories, err = service.ListCategoriesByLevel(int32(level), includeArchived)
 	} else if parentIDStr != "" {
 		parentID, parseErr := strconv.ParseInt(parentIDStr, 10, 32)
 		if parseErr != nil {
@@ -275,9 +371,9 @@ func listCategoriesHandler(c *gin.Context) {
 			return
 		}
 		pid := int32(parentID)
-		categories, err = service.GetCategoriesByParentID(&pid)
+		categories, err = service.GetCategoriesByParentID(&pid, includeArchived)
 	} else {
-		categories, err = service.ListCategories()
+		categories, err = service.ListCategories(includeArchived)
 	}
 
 	if err != nil {
@@ -289,14 +385,21 @@ func listCategoriesHandler(c *gin.Context) {
 
 // getCategoryTreeHandler godoc
 // @Summary 获取分类树
-// @Description 获取树形结构的分类列表
+// @Description 获取树形结构的分类列表，默认不含已归档的分类及其子树
 // @Tags category
 // @Produce json
+// @Param include_archived query bool false "是否包含已归档的分类"
 // @Success 200 {array} model.CategoryNode
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/categories/tree [get]
 func getCategoryTreeHandler(c *gin.Context) {
-	tree, err := service.GetCategoryTree()
+	includeArchived, err := parseIncludeArchived(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, err.Error()))
+		return
+	}
+	tree, err := service.GetCategoryTree(includeArchived)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse(http.StatusInternalServerError, err.Error()))
 		return
@@ -306,13 +409,14 @@ func getCategoryTreeHandler(c *gin.Context) {
 
 // createCategoryHandler godoc
 // @Summary 创建分类
-// @Description 新增一个分类（支持层级，最大深度3层）
+// @Description 新增一个分类（支持层级，最大层数由 category.max_depth 配置，默认3层）
 // @Tags category
 // @Accept json
 // @Produce json
 // @Param data body gen.Category true "分类数据"
 // @Success 200 {object} gen.Category
 // @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/categories [post]
 func createCategoryHandler(c *gin.Context) {
@@ -322,7 +426,11 @@ func createCategoryHandler(c *gin.Context) {
 		return
 	}
 	if err := service.CreateCategory(&category); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse(http.StatusInternalServerError, err.Error()))
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrCategoryArchived) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, ErrorResponse(status, err.Error()))
 		return
 	}
 	c.JSON(http.StatusOK, SuccessResponse(category, "Category created successfully"))
@@ -416,3 +524,159 @@ func moveCategoryHandler(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, SuccessResponse(nil, "Category moved successfully"))
 }
+
+// deleteCategoryHandler godoc
+// @Summary 删除分类
+// @Description 删除分类前必须指定目标分类，其时间日志、任务与子分类转移到目标分类（同名子分类合并）
+// @Tags category
+// @Produce json
+// @Param id path int true "分类ID"
+// @Param target_id query int true "接收时间日志、任务与子分类的目标分类ID"
+// @Success 200 {object} model.CategoryMergeResult
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/categories/{id} [delete]
+func deleteCategoryHandler(c *gin.Context) {
+	var id int32
+	if err := parseInt32Param(c, "id", &id); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, err.Error()))
+		return
+	}
+	targetStr := c.Query("target_id")
+	if targetStr == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, "target_id is required to reassign timelogs, tasks and children"))
+		return
+	}
+	targetID, err := strconv.ParseInt(targetStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, "invalid target_id parameter"))
+		return
+	}
+
+	result, err := service.DeleteCategory(id, int32(targetID))
+	if err != nil {
+		status := categoryErrorStatus(err)
+		c.JSON(status, ErrorResponse(status, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, SuccessResponse(result, "Category deleted successfully"))
+}
+
+// mergeCategoryHandler godoc
+// @Summary 合并分类
+// @Description 在同一事务中将分类合并到目标分类：时间日志、任务与子分类转移到目标分类，同名子分类递归合并，最后删除源分类
+// @Tags category
+// @Accept json
+// @Produce json
+// @Param id path int true "源分类ID"
+// @Param data body object true "合并参数" {"target_id": 0}
+// @Success 200 {object} model.CategoryMergeResult
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/categories/{id}/merge [post]
+func mergeCategoryHandler(c *gin.Context) {
+	var id int32
+	if err := parseInt32Param(c, "id", &id); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	var req struct {
+		TargetID int32 `json:"target_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	result, err := service.MergeCategory(id, req.TargetID)
+	if err != nil {
+		status := categoryErrorStatus(err)
+		c.JSON(status, ErrorResponse(status, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, SuccessResponse(result, "Category merged successfully"))
+}
+
+// reorderCategoriesHandler godoc
+// @Summary 调整分类排序
+// @Description 按给定顺序重写同一父分类下兄弟分类的 sort_order，列表必须包含该父分类下的全部子分类
+// @Tags category
+// @Accept json
+// @Produce json
+// @Param data body object true "排序参数" {"ids": [3, 1, 2]}
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/categories/reorder [post]
+func reorderCategoriesHandler(c *gin.Context) {
+	var req struct {
+		IDs []int32 `json:"ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	if err := service.ReorderCategories(req.IDs); err != nil {
+		status := categoryErrorStatus(err)
+		c.JSON(status, ErrorResponse(status, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, SuccessResponse(nil, "Categories reordered successfully"))
+}
+
+// archiveCategoryHandler godoc
+// @Summary 归档分类
+// @Description 归档后分类及其子树默认不出现在分类列表与分类树中，也不能再新建时间日志、任务或子分类；统计与历史日志不受影响
+// @Tags category
+// @Produce json
+// @Param id path int true "分类ID"
+// @Success 200 {object} gen.Category
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/categories/{id}/archive [post]
+func archiveCategoryHandler(c *gin.Context) {
+	var id int32
+	if err := parseInt32Param(c, "id", &id); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, err.Error()))
+		return
+	}
+	category, err := service.ArchiveCategory(id)
+	if err != nil {
+		status := categoryErrorStatus(err)
+		c.JSON(status, ErrorResponse(status, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, SuccessResponse(category, "Category archived successfully"))
+}
+
+// unarchiveCategoryHandler godoc
+// @Summary 取消归档分类
+// @Description 恢复已归档的分类；祖先分类仍处于归档状态时该分类依然隐藏
+// @Tags category
+// @Produce json
+// @Param id path int true "分类ID"
+// @Success 200 {object} gen.Category
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/categories/{id}/unarchive [post]
+func unarchiveCategoryHandler(c *gin.Context) {
+	var id int32
+	if err := parseInt32Param(c, "id", &id); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, err.Error()))
+		return
+	}
+	category, err := service.UnarchiveCategory(id)
+	if err != nil {
+		status := categoryErrorStatus(err)
+		c.JSON(status, ErrorResponse(status, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, SuccessResponse(category, "Category unarchived successfully"))
+}
@@ -0,0 +1,107 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/blacksheepaul/timelog/service"
+	"github.com/gin-gonic/gin"
+)
+
+// 添加计时器相关路由
+func setupTimerRoutes(group *gin.RouterGroup) {
+	group.GET("/timer", getTimerHandler)
+	group.POST("/timer/start", startTimerHandler)
+	group.POST("/timer/stop", stopTimerHandler)
+	group.POST("/timer/switch", switchTimerHandler)
+}
+
+// GetTimerHandler godoc
+// @Summary 获取当前计时器
+// @Description 返回正在计时的时间日志及已用时长，没有计时器时 data 为 null
+// @Tags timer
+// @Produce json
+// @Success 200 {object} service.RunningTimer
+// @Failure 500 {object} map[string]string
+// @Router /api/timer [get]
+func getTimerHandler(c *gin.Context) {
+	running, err := service.GetRunningTimer()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, SuccessResponse(running, "Timer retrieved successfully"))
+}
+
+// StartTimerHandler godoc
+// @Summary 启动计时器
+// @Description 以指定分类/任务开始计时，已有计时器运行时返回 409
+// @Tags timer
+// @Accept json
+// @Produce json
+// @Param data body service.TimerRequest true "计时参数"
+// @Success 200 {object} service.RunningTimer
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/timer/start [post]
+func startTimerHandler(c *gin.Context) {
+	var req service.TimerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	running, err := service.StartTimer(req)
+	if err != nil {
+		status := timeLogErrorStatus(err)
+		c.JSON(status, ErrorResponse(status, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, SuccessResponse(running, "Timer started successfully"))
+}
+
+// StopTimerHandler godoc
+// @Summary 停止计时器
+// @Description 结束当前正在计时的时间日志，没有计时器时返回 404
+// @Tags timer
+// @Produce json
+// @Success 200 {array} gen.Timelog
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/timer/stop [post]
+func stopTimerHandler(c *gin.Context) {
+	stopped, err := service.StopTimer()
+	if err != nil {
+		status := timeLogErrorStatus(err)
+		c.JSON(status, ErrorResponse(status, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, SuccessResponse(stopped, "Timer stopped successfully"))
+}
+
+// SwitchTimerHandler godoc
+// @Summary 切换计时器
+// @Description 原子地结束当前计时器并以新的分类/任务开始计时
+// @Tags timer
+// @Accept json
+// @Produce json
+// @Param data body service.TimerRequest true "计时参数"
+// @Success 200 {object} service.TimerSwitchResult
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/timer/switch [post]
+func switchTimerHandler(c *gin.Context) {
+	var req service.TimerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	result, err := service.SwitchTimer(req)
+	if err != nil {
+		status := timeLogErrorStatus(err)
+		c.JSON(status, ErrorResponse(status, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, SuccessResponse(result, "Timer switched successfully"))
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blacksheepaul/timelog/core/config"
+	log "github.com/blacksheepaul/timelog/core/logger"
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/service"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	cfg := config.GetConfig("config.yml")
+	logger := log.SetZapLogger(*cfg)
+	service.InitService(logger, cfg)
+	model.InitDao(cfg, logger)
+
+	command := strings.ToLower(os.Args[1])
+	switch command {
+	case "create":
+		if len(os.Args) < 3 {
+			fmt.Println("create requires a name")
+			os.Exit(1)
+		}
+		scope := service.APITokenScopeRead
+		if len(os.Args) >= 4 {
+			scope = os.Args[3]
+		}
+		var expiresAt *time.Time
+		if len(os.Args) >= 5 {
+			days, err := strconv.Atoi(os.Args[4])
+			if err != nil || days <= 0 {
+				fmt.Printf("invalid days: %s\n", os.Args[4])
+				os.Exit(1)
+			}
+			t := time.Now().AddDate(0, 0, days)
+			expiresAt = &t
+		}
+		record, token, err := service.CreateAPIToken(os.Args[2], scope, expiresAt)
+		if err != nil {
+			fmt.Printf("failed to create api token: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("token: %s\n", token)
+		fmt.Printf("id: %d\t scope: %s\t expires_at: %s\n", record.ID, record.Scope, formatTime(record.ExpiresAt))
+	case "list":
+		tokens, err := service.ListAPITokens()
+		if err != nil {
+			fmt.Printf("failed to list api tokens: %v\n", err)
+			os.Exit(1)
+		}
+		if len(tokens) == 0 {
+			fmt.Println("no api tokens found")
+			return
+		}
+		for _, token := range tokens {
+			fmt.Printf("id: %d\t name: %s\t prefix: %s\t scope: %s\t expires_at: %s\t last_used_at: %s\n",
+				token.ID, token.Name, token.TokenPrefix, token.Scope, formatTime(token.ExpiresAt), formatTime(token.LastUsedAt))
+		}
+	case "revoke":
+		if len(os.Args) < 3 {
+			fmt.Println("revoke requires an id")
+			os.Exit(1)
+		}
+		id, err := strconv.Atoi(os.Args[2])
+		if err != nil {
+			fmt.Printf("invalid id: %v\n", err)
+			os.Exit(1)
+		}
+		if err := service.RevokeAPIToken(uint(id)); err != nil {
+			fmt.Printf("failed to revoke api token: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("revoked")
+	case "prune":
+		days := 90
+		if len(os.Args) >= 3 {
+			value, err := strconv.Atoi(os.Args[2])
+			if err != nil || value <= 0 {
+				fmt.Printf("invalid days: %s\n", os.Args[2])
+				os.Exit(1)
+			}
+			days = value
+		}
+		count, err := service.PruneAPITokens(time.Duration(days) * 24 * time.Hour)
+		if err != nil {
+			fmt.Printf("failed to prune api tokens: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("pruned %d expired or unused api tokens\n", count)
+	default:
+		printUsage()
+		os.Exit(1)
+	}
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+func printUsage() {
+	fmt.Println("Usage: go run ./scripts/api_token <create|list|revoke|prune> [args]")
+	fmt.Println("  create <name> [read|read-write] [days]  create a token, optionally expiring after days")
+	fmt.Println("  list                                    list tokens with last used time")
+	fmt.Println("  revoke <id>                             revoke a token")
+	fmt.Println("  prune [days]                            remove expired tokens and tokens unused for days (default 90)")
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/blacksheepaul/timelog/core/config"
+	log "github.com/blacksheepaul/timelog/core/logger"
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/service"
+)
+
+func main() {
+	if len(os.Args) < 3 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	command := strings.ToLower(os.Args[1])
+	file := os.Args[2]
+	mode := model.ArchiveImportMerge
+	dryRun := false
+	for _, arg := range os.Args[3:] {
+		switch arg {
+		case "--restore":
+			mode = model.ArchiveImportRestore
+		case "--merge":
+			mode = model.ArchiveImportMerge
+		case "--dry-run":
+			dryRun = true
+		default:
+			printUsage()
+			os.Exit(1)
+		}
+	}
+	if command != "export" && command != "import" {
+		printUsage()
+		os.Exit(1)
+	}
+
+	cfg := config.GetConfig("config.yml")
+	logger := log.SetZapLogger(*cfg)
+	service.InitService(logger, cfg)
+	model.InitDao(cfg, logger)
+
+	if command == "export" {
+		if err := exportArchive(file); err != nil {
+			fmt.Printf("failed to export archive: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("archive written to %s\n", file)
+		return
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		fmt.Printf("failed to open archive: %v\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	report, err := service.ImportArchive(f, mode, dryRun)
+	if report != nil {
+		printArchiveConflicts(report.TimeLogConflicts)
+	}
+	if err != nil {
+		fmt.Printf("failed to import archive: %v\n", err)
+		os.Exit(1)
+	}
+	for _, row := range []struct {
+		name   string
+		counts model.ArchiveImportCounts
+	}{
+		{"categories", report.Categories},
+		{"constraints", report.Constraints},
+		{"tasks", report.Tasks},
+		{"timelogs", report.TimeLogs},
+	} {
+		fmt.Printf("%-12s total: %d\t created: %d\t existing: %d\t remapped: %d\n",
+			row.name, row.counts.Total, row.counts.Created, row.counts.Existing, row.counts.Remapped)
+	}
+	if dryRun {
+		fmt.Printf("dry run (%s): nothing was written\n", report.Mode)
+		return
+	}
+	fmt.Printf("archive imported (%s)\n", report.Mode)
+}
+
+// printArchiveConflicts 列出与现有日志冲突的时间日志，rejected 的不会被导入
+func printArchiveConflicts(conflicts []model.ArchiveTimeLogConflict) {
+	for _, conflict := range conflicts {
+		status := "warning"
+		if conflict.Rejected {
+			status = "rejected"
+		}
+		fmt.Printf("timelog %d (%s): %s: %s\n", conflict.TimeLogID, conflict.StartTime.Format(time.RFC3339), status, conflict.Message)
+	}
+}
+
+func exportArchive(file string) error {
+	if file == "-" {
+		return service.ExportArchive(os.Stdout)
+	}
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := service.ExportArchive(f); err != nil {
+		f.Close()
+		os.Remove(file)
+		return err
+	}
+	return f.Close()
+}
+
+func printUsage() {
+	fmt.Println("Usage: go run ./scripts/archive <export|import> <file> [--restore|--merge] [--dry-run]")
+	fmt.Println("  export <file>   write every category, constraint, task and time log to a JSON archive (- for stdout)")
+	fmt.Println("  import <file>   import an archive; --merge (default) remaps ids and skips records that already exist,")
+	fmt.Println("                  --restore keeps the original ids and requires a database without time logs, tasks or constraints")
+	fmt.Println("  --dry-run       report what would be imported without writing")
+}
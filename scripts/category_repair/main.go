@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/blacksheepaul/timelog/core/config"
+	log "github.com/blacksheepaul/timelog/core/logger"
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/service"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	var dryRun bool
+	switch strings.ToLower(os.Args[1]) {
+	case "check":
+		dryRun = true
+	case "repair":
+		dryRun = false
+	default:
+		printUsage()
+		os.Exit(1)
+	}
+
+	cfg := config.GetConfig("config.yml")
+	logger := log.SetZapLogger(*cfg)
+	service.InitService(logger, cfg)
+	model.InitDao(cfg, logger)
+
+	report, err := service.RepairCategoryHierarchy(dryRun)
+	if err != nil {
+		fmt.Printf("failed to repair categories: %v\n", err)
+		os.Exit(1)
+	}
+	for _, r := range report.Repairs {
+		name := r.Name
+		if r.Deleted {
+			name += " (deleted)"
+		}
+		fmt.Printf("id: %d\t name: %s\t reason: %s\t parent: %s -> %s\t level: %d -> %d\t path: %s -> %s\n",
+			r.CategoryID, name, r.Reason, formatID(r.OldParentID), formatID(r.NewParentID), r.OldLevel, r.NewLevel, r.OldPath, r.NewPath)
+	}
+	if dryRun {
+		fmt.Printf("checked %d categories, %d need repair\n", report.Checked, len(report.Repairs))
+		return
+	}
+	fmt.Printf("checked %d categories, repaired %d\n", report.Checked, len(report.Repairs))
+}
+
+func formatID(id *int32) string {
+	if id == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d", *id)
+}
+
+func printUsage() {
+	fmt.Println("Usage: go run ./scripts/category_repair <check|repair>")
+	fmt.Println("  check   report categories whose level or path disagree with parent_id")
+	fmt.Println("  repair  recompute level and path from parent_id and report what was fixed")
+}
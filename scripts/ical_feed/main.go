@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blacksheepaul/timelog/core/config"
+	log "github.com/blacksheepaul/timelog/core/logger"
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/service"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	cfg := config.GetConfig("config.yml")
+	logger := log.SetZapLogger(*cfg)
+	service.InitService(logger, cfg)
+	model.InitDao(cfg, logger)
+
+	command := strings.ToLower(os.Args[1])
+	switch command {
+	case "create":
+		if len(os.Args) < 4 {
+			fmt.Println("create requires a kind and a name")
+			os.Exit(1)
+		}
+		feed, token, err := service.CreateCalendarFeed(os.Args[3], os.Args[2])
+		if err != nil {
+			fmt.Printf("failed to create calendar feed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("id: %d\t kind: %s\t name: %s\n", feed.ID, feed.Kind, feed.Name)
+		fmt.Printf("subscribe to: http://%s:%d/api/ical/%s.ics?token=%s\n", serverHost(cfg), cfg.Server.Port, feed.Kind, token)
+	case "list":
+		feeds, err := service.ListCalendarFeeds()
+		if err != nil {
+			fmt.Printf("failed to list calendar feeds: %v\n", err)
+			os.Exit(1)
+		}
+		if len(feeds) == 0 {
+			fmt.Println("no calendar feeds found")
+			return
+		}
+		for _, feed := range feeds {
+			fmt.Printf("id: %d\t kind: %s\t name: %s\t prefix: %s\t last_used_at: %s\n",
+				feed.ID, feed.Kind, feed.Name, feed.TokenPrefix, formatTime(feed.LastUsedAt))
+		}
+	case "revoke":
+		if len(os.Args) < 3 {
+			fmt.Println("revoke requires an id")
+			os.Exit(1)
+		}
+		id, err := strconv.Atoi(os.Args[2])
+		if err != nil {
+			fmt.Printf("invalid id: %v\n", err)
+			os.Exit(1)
+		}
+		if err := service.RevokeCalendarFeed(uint(id)); err != nil {
+			fmt.Printf("failed to revoke calendar feed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("revoked")
+	default:
+		printUsage()
+		os.Exit(1)
+	}
+}
+
+// serverHost 订阅地址中的主机名，监听所有地址时使用 localhost，部署在反向代理后面需要自行替换
+func serverHost(cfg *config.Config) string {
+	if cfg.Server.Addr == "" || cfg.Server.Addr == "0.0.0.0" {
+		return "localhost"
+	}
+	return cfg.Server.Addr
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+func printUsage() {
+	fmt.Println("Usage: go run ./scripts/ical_feed <create|list|revoke> [args]")
+	fmt.Println("  create <timelogs|tasks> <name>  create a feed and print its subscription URL")
+	fmt.Println("  list                            list feeds with last fetch time")
+	fmt.Println("  revoke <id>                     revoke a feed, its URL stops working")
+}
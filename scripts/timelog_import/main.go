@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/blacksheepaul/timelog/core/config"
+	log "github.com/blacksheepaul/timelog/core/logger"
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/service"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	opts := service.TimeLogImportOptions{DryRun: true}
+	for _, arg := range os.Args[2:] {
+		name, value, _ := strings.Cut(arg, "=")
+		switch name {
+		case "--commit":
+			opts.DryRun = false
+		case "--source":
+			opts.Source = value
+		case "--timezone":
+			opts.Timezone = value
+		case "--day-first":
+			opts.DayFirst = true
+		case "--default-category":
+			opts.DefaultCategory = value
+		default:
+			printUsage()
+			os.Exit(1)
+		}
+	}
+
+	f, err := os.Open(os.Args[1])
+	if err != nil {
+		fmt.Printf("failed to open file: %v\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	cfg := config.GetConfig("config.yml")
+	logger := log.SetZapLogger(*cfg)
+	service.InitService(logger, cfg)
+	model.InitDao(cfg, logger)
+
+	report, importErr := service.ImportTimeLogs(f, opts)
+	if report == nil {
+		fmt.Printf("failed to import: %v\n", importErr)
+		os.Exit(1)
+	}
+
+	for _, m := range report.Categories {
+		target := "new"
+		if !m.Created {
+			target = fmt.Sprintf("#%d", *m.CategoryID)
+		}
+		fmt.Printf("category %-40s -> %s\t entries: %d\n", m.Path, target, m.Entries)
+	}
+	for _, e := range report.Entries {
+		if e.Status == service.ImportStatusImported && e.Message == "" {
+			continue
+		}
+		fmt.Printf("line %d\t %s\t %s\n", e.Line, e.Status, e.Message)
+	}
+	fmt.Printf("source: %s\t total: %d\t imported: %d\t duplicates: %d\t invalid: %d\t overlapping: %d\n",
+		report.Source, report.Total, report.Imported, report.Duplicates, report.Invalid, report.Overlapping)
+
+	switch {
+	case importErr != nil:
+		fmt.Printf("nothing was imported: %v\n", importErr)
+		os.Exit(1)
+	case opts.DryRun:
+		fmt.Println("preview only, run again with --commit to import")
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage: go run ./scripts/timelog_import <file> [--commit] [--source=toggl|clockify|timewarrior|org] [--timezone=Area/City] [--day-first] [--default-category=/Imported]")
+	fmt.Println("  previews how a Toggl Track / Clockify CSV, timew export JSON or org-mode file maps onto categories and tasks;")
+	fmt.Println("  --commit imports it in a single transaction")
+}
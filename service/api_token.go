@@ -0,0 +1,125 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+)
+
+// APITokenPrefix 个人访问令牌的前缀，用于和会话令牌区分
+const APITokenPrefix = "tl_pat_"
+
+// 令牌权限范围
+const (
+	APITokenScopeRead      = "read"
+	APITokenScopeReadWrite = "read-write"
+)
+
+// apiTokenTouchInterval 两次更新令牌最近使用时间的最小间隔
+const apiTokenTouchInterval = time.Minute
+
+var (
+	ErrInvalidAPIToken        = errors.New("invalid or expired api token")
+	ErrInvalidAPITokenRequest = errors.New("invalid api token request")
+)
+
+// IsAPIToken 判断令牌是否为个人访问令牌
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
+
+// CreateAPIToken 创建个人访问令牌，返回的明文令牌只在创建时可见
+func CreateAPIToken(name, scope string, expiresAt *time.Time) (*model.APIToken, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return nil, "", fmt.Errorf("%w: name is required and must be at most 100 characters", ErrInvalidAPITokenRequest)
+	}
+	if scope == "" {
+		scope = APITokenScopeRead
+	}
+	if scope != APITokenScopeRead && scope != APITokenScopeReadWrite {
+		return nil, "", fmt.Errorf("%w: scope must be read or read-write", ErrInvalidAPITokenRequest)
+	}
+	now := nowUTC()
+	if expiresAt != nil {
+		if !expiresAt.After(now) {
+			return nil, "", fmt.Errorf("%w: expires_at must be in the future", ErrInvalidAPITokenRequest)
+		}
+		utc := expiresAt.UTC()
+		expiresAt = &utc
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", err
+	}
+	token := APITokenPrefix + hex.EncodeToString(raw)
+
+	record := &model.APIToken{
+		Name:        name,
+		TokenHash:   hashToken(token),
+		TokenPrefix: token[:len(APITokenPrefix)+6],
+		Scope:       scope,
+		CreatedAt:   now,
+		ExpiresAt:   expiresAt,
+	}
+	if err := model.CreateAPIToken(model.GetDao().Db(), record); err != nil {
+		return nil, "", err
+	}
+	return record, token, nil
+}
+
+func ListAPITokens() ([]model.APIToken, error) {
+	return model.ListAPITokens(model.GetDao().Db())
+}
+
+func RevokeAPIToken(id uint) error {
+	return model.DeleteAPIToken(model.GetDao().Db(), id)
+}
+
+// PruneAPITokens 删除已过期的令牌，以及 unusedFor 时长内没有使用过的令牌
+func PruneAPITokens(unusedFor time.Duration) (int64, error) {
+	now := nowUTC()
+	return model.DeleteStaleAPITokens(model.GetDao().Db(), now, now.Add(-unusedFor))
+}
+
+// ValidateAPIToken 校验个人访问令牌并记录最近使用时间
+func ValidateAPIToken(token string) (*model.APIToken, error) {
+	if !IsAPIToken(token) {
+		return nil, ErrInvalidAPIToken
+	}
+	db := model.GetDao().Db()
+	now := nowUTC()
+	record, err := model.GetAPITokenByHash(db, hashToken(token), now)
+	if errors.Is(err, model.ErrRecordNotFound) {
+		return nil, ErrInvalidAPIToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if record.LastUsedAt == nil || now.Sub(*record.LastUsedAt) >= apiTokenTouchInterval {
+		if err := model.TouchAPIToken(db, record.ID, now); err != nil {
+			return nil, err
+		}
+		record.LastUsedAt = &now
+	}
+	return record, nil
+}
+
+// APITokenAllows 判断令牌的权限范围是否允许该 HTTP 方法，只读令牌只能发起 GET/HEAD/OPTIONS 请求
+func APITokenAllows(token *model.APIToken, method string) bool {
+	if token.Scope == APITokenScopeReadWrite {
+		return true
+	}
+	switch method {
+	case "GET", "HEAD", "OPTIONS":
+		return true
+	}
+	return false
+}
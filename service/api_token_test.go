@@ -0,0 +1,102 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+)
+
+func TestAPITokenLifecycle(t *testing.T) {
+	dao := setupTestModel()
+
+	record, token, err := CreateAPIToken("script", APITokenScopeReadWrite, nil)
+	if err != nil {
+		t.Fatalf("Failed to create api token: %v", err)
+	}
+	if !strings.HasPrefix(token, APITokenPrefix) || !strings.HasPrefix(token, record.TokenPrefix) {
+		t.Errorf("Expected token %q to start with %q", token, record.TokenPrefix)
+	}
+	if record.TokenHash != hashToken(token) {
+		t.Error("Expected only the token hash to be stored")
+	}
+
+	validated, err := ValidateAPIToken(token)
+	if err != nil || validated.ID != record.ID {
+		t.Fatalf("Expected token to be valid, got %v", err)
+	}
+	stored, err := model.GetAPITokenByHash(dao.Db(), record.TokenHash, nowUTC())
+	if err != nil || stored.LastUsedAt == nil {
+		t.Errorf("Expected last used time to be recorded, got %v (%v)", stored, err)
+	}
+
+	if err := RevokeAPIToken(record.ID); err != nil {
+		t.Fatalf("Failed to revoke api token: %v", err)
+	}
+	if _, err := ValidateAPIToken(token); !errors.Is(err, ErrInvalidAPIToken) {
+		t.Errorf("Expected revoked token to be rejected, got %v", err)
+	}
+}
+
+func TestCreateAPITokenValidation(t *testing.T) {
+	setupTestModel()
+
+	past := time.Now().Add(-time.Hour)
+	cases := []struct {
+		name, scope string
+		expiresAt   *time.Time
+	}{
+		{"", APITokenScopeRead, nil},
+		{"bad scope", "admin", nil},
+		{"expired", APITokenScopeRead, &past},
+	}
+	for _, c := range cases {
+		if _, _, err := CreateAPIToken(c.name, c.scope, c.expiresAt); !errors.Is(err, ErrInvalidAPITokenRequest) {
+			t.Errorf("Expected %q/%q to be rejected, got %v", c.name, c.scope, err)
+		}
+	}
+
+	record, _, err := CreateAPIToken("default scope", "", nil)
+	if err != nil || record.Scope != APITokenScopeRead {
+		t.Errorf("Expected default scope read, got %v (%v)", record, err)
+	}
+}
+
+func TestPruneAPITokens(t *testing.T) {
+	dao := setupTestModel()
+	db := dao.Db()
+
+	expiring, token, err := CreateAPIToken("expiring", APITokenScopeRead, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unused, _, err := CreateAPIToken("unused", APITokenScopeRead, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	used, usedToken, err := CreateAPIToken("used", APITokenScopeRead, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := nowUTC().AddDate(0, 0, -100)
+	db.Model(&model.APIToken{}).Where("id = ?", expiring.ID).Update("expires_at", nowUTC().Add(-time.Minute))
+	db.Model(&model.APIToken{}).Where("id IN ?", []uint{unused.ID, used.ID}).Update("created_at", old)
+	if _, err := ValidateAPIToken(usedToken); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateAPIToken(token); !errors.Is(err, ErrInvalidAPIToken) {
+		t.Errorf("Expected expired token to be rejected, got %v", err)
+	}
+
+	if _, err := PruneAPITokens(90 * 24 * time.Hour); err != nil {
+		t.Fatalf("Failed to prune api tokens: %v", err)
+	}
+	var remaining []uint
+	db.Model(&model.APIToken{}).Where("id IN ?", []uint{expiring.ID, unused.ID, used.ID}).Pluck("id", &remaining)
+	if len(remaining) != 1 || remaining[0] != used.ID {
+		t.Errorf("Expected only recently used token to remain, got %v", remaining)
+	}
+}
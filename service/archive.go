@@ -0,0 +1,117 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/model/gen"
+	"gorm.io/gorm"
+)
+
+// 归档文件格式标识与版本，格式发生不兼容变化时递增 ArchiveVersion
+const (
+	ArchiveFormat  = "timelog-archive"
+	ArchiveVersion = 1
+)
+
+// Archive 完整归档：保留原始ID与引用关系，时间为 UTC
+// SchemaVersion 记录导出时的数据库迁移版本，仅供参考
+type Archive struct {
+	Format        string    `json:"format"`
+	Version       int       `json:"version"`
+	SchemaVersion uint      `json:"schema_version"`
+	ExportedAt    time.Time `json:"exported_at"`
+	model.ArchiveData
+}
+
+// ExportArchive 将所有分类、约束、任务与时间日志（包括已软删除的）写成归档 JSON
+func ExportArchive(w io.Writer) error {
+	db := model.GetDao().Db()
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	version, _, err := model.SchemaVersion(sqlDB)
+	if err != nil {
+		return err
+	}
+	data, err := model.LoadArchiveData(db)
+	if err != nil {
+		return err
+	}
+
+	archive := Archive{
+		Format:        ArchiveFormat,
+		Version:       ArchiveVersion,
+		SchemaVersion: version,
+		ExportedAt:    time.Now().UTC(),
+		ArchiveData:   *data,
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(&archive)
+}
+
+// ImportArchive 读取归档并以 restore 或 merge 模式导入（mode 为空时为 merge），dryRun 为 true 时只返回导入结果不写入
+// merge 模式下与现有日志冲突的时间日志写入报告，有被拒绝的日志时返回 model.ErrArchiveConflicts 与报告
+func ImportArchive(r io.Reader, mode string, dryRun bool) (*model.ArchiveImportReport, error) {
+	if mode == "" {
+		mode = model.ArchiveImportMerge
+	}
+	if mode != model.ArchiveImportRestore && mode != model.ArchiveImportMerge {
+		return nil, fmt.Errorf("%w: mode must be restore or merge", model.ErrInvalidArchive)
+	}
+
+	var archive Archive
+	if err := json.NewDecoder(r).Decode(&archive); err != nil {
+		return nil, fmt.Errorf("%w: %v", model.ErrInvalidArchive, err)
+	}
+	if archive.Format != ArchiveFormat {
+		return nil, fmt.Errorf("%w: not a %s file", model.ErrInvalidArchive, ArchiveFormat)
+	}
+	if archive.Version < 1 || archive.Version > ArchiveVersion {
+		return nil, fmt.Errorf("%w: unsupported archive version %d (supported up to %d)", model.ErrInvalidArchive, archive.Version, ArchiveVersion)
+	}
+
+	db := model.GetDao().Db()
+	timerMu.Lock()
+	defer timerMu.Unlock()
+	return model.ImportArchiveData(db, &archive.ArchiveData, mode, dryRun, checkArchiveTimeLog)
+}
+
+// checkArchiveTimeLog 合并归档时对新插入的时间日志执行与新建日志相同的检查：
+// 时间范围必须有效、最多只有一条未结束的日志，重叠按 timelog.overlap_policy 处理
+func checkArchiveTimeLog(tx *gorm.DB, tl *gen.Timelog) (*model.ArchiveTimeLogConflict, error) {
+	if err := validateTimeRange(tl); err != nil {
+		return &model.ArchiveTimeLogConflict{Message: err.Error(), Rejected: true}, nil
+	}
+	if tl.EndTime == nil {
+		if err := ensureNoOtherOpenTimeLog(tx, nil); err != nil {
+			if !errors.Is(err, ErrTimerAlreadyRunning) {
+				return nil, err
+			}
+			return &model.ArchiveTimeLogConflict{Message: err.Error(), Rejected: true}, nil
+		}
+	}
+
+	policy := overlapPolicy()
+	if policy == OverlapPolicyOff {
+		return nil, nil
+	}
+	conflicts, err := model.ListTimeLogsOverlapping(tx, tl.StartTime, tl.EndTime, nil)
+	if err != nil || len(conflicts) == 0 {
+		return nil, err
+	}
+	conflict := &model.ArchiveTimeLogConflict{
+		Message:  (&OverlapError{Conflicts: conflicts}).Error(),
+		Rejected: policy == OverlapPolicyReject,
+	}
+	for _, existing := range conflicts {
+		conflict.Overlaps = append(conflict.Overlaps, *existing.ID)
+	}
+	return conflict, nil
+}
@@ -0,0 +1,322 @@
+package service
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+)
+
+// 快照文件名形如 timelog-20261018T033000.000Z.db，同目录下的 .sha256 文件保存校验和
+const (
+	backupPrefix     = "timelog-"
+	backupSuffix     = ".db"
+	checksumSuffix   = ".sha256"
+	backupTimeLayout = "20060102T150405.000Z"
+)
+
+var (
+	ErrBackupNotFound         = errors.New("backup not found")
+	ErrInvalidBackup          = errors.New("invalid backup")
+	ErrBackupChecksumMismatch = errors.New("backup checksum mismatch")
+)
+
+// backupMu 保证同一时间只有一个备份在写入
+var backupMu sync.Mutex
+
+// BackupInfo 一个数据库快照
+type BackupInfo struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	SHA256    string    `json:"sha256"` // 校验和文件缺失时为空
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func backupDir() string {
+	if cfg == nil || cfg.Backup.Dir == "" {
+		return "backups"
+	}
+	return cfg.Backup.Dir
+}
+
+// parseBackupName 从快照文件名解析创建时间
+func parseBackupName(name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, backupPrefix) || !strings.HasSuffix(name, backupSuffix) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(backupTimeLayout, strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), backupSuffix))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// fileSHA256 计算文件的 SHA-256 与大小
+func fileSHA256(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// readChecksumFile 读取 sha256sum 格式的校验和文件
+func readChecksumFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	line, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("%w: empty checksum file %s", ErrInvalidBackup, filepath.Base(path))
+	}
+	return strings.ToLower(fields[0]), nil
+}
+
+// CreateBackup 使用 VACUUM INTO 在线写出数据库快照，同时写入校验和文件并按保留数量清理旧快照
+func CreateBackup() (*BackupInfo, error) {
+	backupMu.Lock()
+	defer backupMu.Unlock()
+
+	dir := backupDir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	createdAt := time.Now().UTC().Truncate(time.Millisecond)
+	name := backupPrefix + createdAt.Format(backupTimeLayout) + backupSuffix
+	for {
+		if _, err := os.Stat(filepath.Join(dir, name)); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		createdAt = createdAt.Add(time.Millisecond)
+		name = backupPrefix + createdAt.Format(backupTimeLayout) + backupSuffix
+	}
+	path := filepath.Join(dir, name)
+
+	// 先写到临时文件，完成后再改名，避免留下不完整的快照
+	tmp := path + ".tmp"
+	_ = os.Remove(tmp)
+	if err := model.VacuumInto(model.GetDao().Db(), tmp); err != nil {
+		_ = os.Remove(tmp)
+		return nil, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	sum, size, err := fileSHA256(tmp)
+	if err != nil {
+		_ = os.Remove(tmp)
+		return nil, err
+	}
+	if err := os.WriteFile(path+checksumSuffix, []byte(fmt.Sprintf("%s  %s\n", sum, name)), 0o644); err != nil {
+		_ = os.Remove(tmp)
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		_ = os.Remove(path + checksumSuffix)
+		return nil, err
+	}
+
+	if cfg != nil && cfg.Backup.Retention > 0 {
+		if err := pruneBackups(cfg.Backup.Retention); err != nil && log != nil {
+			log.Errorw("Failed to prune old backups", "error", err)
+		}
+	}
+	return &BackupInfo{Name: name, Size: size, SHA256: sum, CreatedAt: createdAt}, nil
+}
+
+// ListBackups 按创建时间倒序列出备份目录中的快照
+func ListBackups() ([]BackupInfo, error) {
+	dir := backupDir()
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []BackupInfo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	backups := []BackupInfo{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		createdAt, ok := parseBackupName(entry.Name())
+		if !ok {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		sum, _ := readChecksumFile(filepath.Join(dir, entry.Name()+checksumSuffix))
+		backups = append(backups, BackupInfo{
+			Name:      entry.Name(),
+			Size:      info.Size(),
+			SHA256:    sum,
+			CreatedAt: createdAt,
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt.After(backups[j].CreatedAt) })
+	return backups, nil
+}
+
+// LatestBackup 返回最新的快照，没有快照时返回 nil
+func LatestBackup() (*BackupInfo, error) {
+	backups, err := ListBackups()
+	if err != nil || len(backups) == 0 {
+		return nil, err
+	}
+	return &backups[0], nil
+}
+
+// pruneBackups 只保留最新的 keep 个快照
+func pruneBackups(keep int) error {
+	backups, err := ListBackups()
+	if err != nil {
+		return err
+	}
+	dir := backupDir()
+	for i := keep; i < len(backups); i++ {
+		path := filepath.Join(dir, backups[i].Name)
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if err := os.Remove(path + checksumSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResolveBackupPath 将快照名解析为备份目录中的路径，传入的是已存在的文件路径时原样返回
+func ResolveBackupPath(nameOrPath string) (string, error) {
+	if _, err := os.Stat(nameOrPath); err == nil {
+		return nameOrPath, nil
+	}
+	if filepath.Base(nameOrPath) == nameOrPath {
+		path := filepath.Join(backupDir(), nameOrPath)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrBackupNotFound, nameOrPath)
+}
+
+// VerifyBackup 校验快照的 SHA-256，并检查其是否为完整且可被当前二进制使用的数据库
+func VerifyBackup(path string) error {
+	expected, err := readChecksumFile(path + checksumSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: checksum file %s is missing", ErrInvalidBackup, filepath.Base(path)+checksumSuffix)
+	}
+	if err != nil {
+		return err
+	}
+	actual, _, err := fileSHA256(path)
+	if err != nil {
+		return err
+	}
+	if actual != expected {
+		return fmt.Errorf("%w: expected %s, got %s", ErrBackupChecksumMismatch, expected, actual)
+	}
+	if err := model.VerifySQLiteFile(path); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	return nil
+}
+
+// RestoreBackup 校验快照后用它替换配置的数据库文件，原数据库连同 -wal/-shm 文件改名保留
+// 返回原数据库保留的路径（原数据库不存在时为空）
+// 必须在服务停止、数据库未被打开时调用
+func RestoreBackup(nameOrPath string) (string, error) {
+	dbPath := cfg.Database.Host
+	if dbPath == "" || dbPath == ":memory:" || strings.HasPrefix(dbPath, "file:") {
+		return "", fmt.Errorf("restore requires database.host to be a plain file path, got %q", dbPath)
+	}
+	path, err := ResolveBackupPath(nameOrPath)
+	if err != nil {
+		return "", err
+	}
+	if err := VerifyBackup(path); err != nil {
+		return "", err
+	}
+	expected, err := readChecksumFile(path + checksumSuffix)
+	if err != nil {
+		return "", err
+	}
+
+	// 先复制到数据库同目录下的临时文件，确保最后一步改名是原子的
+	tmp := dbPath + ".restore.tmp"
+	if err := copyFileChecked(path, tmp, expected); err != nil {
+		_ = os.Remove(tmp)
+		return "", err
+	}
+
+	var kept string
+	if _, err := os.Stat(dbPath); err == nil {
+		kept = dbPath + ".pre-restore-" + time.Now().UTC().Format("20060102T150405Z")
+		for _, suffix := range []string{"", "-wal", "-shm"} {
+			if err := os.Rename(dbPath+suffix, kept+suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+				_ = os.Remove(tmp)
+				return "", fmt.Errorf("failed to keep current database: %w", err)
+			}
+		}
+	}
+	if err := os.Rename(tmp, dbPath); err != nil {
+		_ = os.Remove(tmp)
+		return kept, err
+	}
+	return kept, nil
+}
+
+// copyFileChecked 复制文件并落盘，复制内容的 SHA-256 与 expected 不一致时返回错误
+func copyFileChecked(src, dst, expected string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(out, h), in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if actual := hex.EncodeToString(h.Sum(nil)); actual != expected {
+		return fmt.Errorf("%w: snapshot changed while copying", ErrBackupChecksumMismatch)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blacksheepaul/timelog/core/config"
+)
+
+func setupBackupConfig(t *testing.T, retention int) *config.Config {
+	setupTestModel()
+	old := cfg
+	t.Cleanup(func() { cfg = old })
+
+	cfg = &config.Config{}
+	cfg.Backup.Dir = filepath.Join(t.TempDir(), "backups")
+	cfg.Backup.Retention = retention
+	cfg.Database.Host = filepath.Join(t.TempDir(), "timelog.db")
+	return cfg
+}
+
+func TestCreateBackupRetention(t *testing.T) {
+	setupBackupConfig(t, 2)
+
+	var names []string
+	for i := 0; i < 3; i++ {
+		backup, err := CreateBackup()
+		if err != nil {
+			t.Fatalf("Failed to create backup: %v", err)
+		}
+		if len(backup.SHA256) != 64 || backup.Size == 0 {
+			t.Errorf("Expected checksum and size, got %+v", backup)
+		}
+		names = append(names, backup.Name)
+	}
+
+	backups, err := ListBackups()
+	if err != nil {
+		t.Fatalf("Failed to list backups: %v", err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("Expected 2 backups after pruning, got %d", len(backups))
+	}
+	if backups[0].Name != names[2] || backups[1].Name != names[1] {
+		t.Errorf("Expected newest backups first, got %s, %s", backups[0].Name, backups[1].Name)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.Backup.Dir, names[0]+checksumSuffix)); !errors.Is(err, os.ErrNotExist) {
+		t.Error("Expected checksum of pruned backup to be removed")
+	}
+}
+
+func TestRestoreBackup(t *testing.T) {
+	setupBackupConfig(t, 0)
+
+	backup, err := CreateBackup()
+	if err != nil {
+		t.Fatalf("Failed to create backup: %v", err)
+	}
+	if err := os.WriteFile(cfg.Database.Host, []byte("current"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	kept, err := RestoreBackup(backup.Name)
+	if err != nil {
+		t.Fatalf("Failed to restore backup: %v", err)
+	}
+	if data, err := os.ReadFile(kept); err != nil || string(data) != "current" {
+		t.Errorf("Expected previous database to be kept at %s", kept)
+	}
+	sum, _, err := fileSHA256(cfg.Database.Host)
+	if err != nil || sum != backup.SHA256 {
+		t.Errorf("Expected restored database to match snapshot checksum")
+	}
+}
+
+func TestRestoreBackupRejectsCorruptSnapshot(t *testing.T) {
+	setupBackupConfig(t, 0)
+
+	backup, err := CreateBackup()
+	if err != nil {
+		t.Fatalf("Failed to create backup: %v", err)
+	}
+	path := filepath.Join(cfg.Backup.Dir, backup.Name)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("garbage")
+	f.Close()
+
+	if _, err := RestoreBackup(backup.Name); !errors.Is(err, ErrBackupChecksumMismatch) {
+		t.Errorf("Expected checksum mismatch, got %v", err)
+	}
+	if _, err := os.Stat(cfg.Database.Host); !errors.Is(err, os.ErrNotExist) {
+		t.Error("Expected database to be left untouched")
+	}
+
+	// 校验和匹配但内容不是合法的数据库
+	if err := os.WriteFile(path, []byte("not a database"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sum, _, _ := fileSHA256(path)
+	if err := os.WriteFile(path+checksumSuffix, []byte(sum+"  "+backup.Name+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RestoreBackup(backup.Name); !errors.Is(err, ErrInvalidBackup) {
+		t.Errorf("Expected invalid backup, got %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+)
+
+// CalendarFeedPrefix 日历订阅令牌的前缀，与会话令牌、个人访问令牌区分
+const CalendarFeedPrefix = "tl_cal_"
+
+// 订阅源类型
+const (
+	CalendarFeedTimeLogs = "timelogs"
+	CalendarFeedTasks    = "tasks"
+)
+
+// calendarFeedTouchInterval 两次更新订阅源最近使用时间的最小间隔
+const calendarFeedTouchInterval = time.Minute
+
+var (
+	ErrInvalidCalendarFeed        = errors.New("invalid calendar feed token")
+	ErrInvalidCalendarFeedRequest = errors.New("invalid calendar feed request")
+)
+
+// CreateCalendarFeed 创建日历订阅源，返回的明文令牌只在创建时可见
+// 日历客户端无法使用 passkey 或请求头认证，令牌直接放在订阅地址中，且只能读取对应类型的订阅源
+func CreateCalendarFeed(name, kind string) (*model.CalendarFeed, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return nil, "", fmt.Errorf("%w: name is required and must be at most 100 characters", ErrInvalidCalendarFeedRequest)
+	}
+	if kind != CalendarFeedTimeLogs && kind != CalendarFeedTasks {
+		return nil, "", fmt.Errorf("%w: kind must be timelogs or tasks", ErrInvalidCalendarFeedRequest)
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", err
+	}
+	token := CalendarFeedPrefix + hex.EncodeToString(raw)
+
+	feed := &model.CalendarFeed{
+		Name:        name,
+		Kind:        kind,
+		TokenHash:   hashToken(token),
+		TokenPrefix: token[:len(CalendarFeedPrefix)+6],
+		CreatedAt:   nowUTC(),
+	}
+	if err := model.CreateCalendarFeed(model.GetDao().Db(), feed); err != nil {
+		return nil, "", err
+	}
+	return feed, token, nil
+}
+
+func ListCalendarFeeds() ([]model.CalendarFeed, error) {
+	return model.ListCalendarFeeds(model.GetDao().Db())
+}
+
+func RevokeCalendarFeed(id uint) error {
+	return model.DeleteCalendarFeed(model.GetDao().Db(), id)
+}
+
+// ValidateCalendarFeed 校验订阅地址中的令牌是否属于 kind 类型的订阅源，并记录最近使用时间
+func ValidateCalendarFeed(token, kind string) (*model.CalendarFeed, error) {
+	if !strings.HasPrefix(token, CalendarFeedPrefix) {
+		return nil, ErrInvalidCalendarFeed
+	}
+	db := model.GetDao().Db()
+	feed, err := model.GetCalendarFeedByHash(db, hashToken(token), kind)
+	if errors.Is(err, model.ErrRecordNotFound) {
+		return nil, ErrInvalidCalendarFeed
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := nowUTC()
+	if feed.LastUsedAt == nil || now.Sub(*feed.LastUsedAt) >= calendarFeedTouchInterval {
+		if err := model.TouchCalendarFeed(db, feed.ID, now); err != nil {
+			return nil, err
+		}
+		feed.LastUsedAt = &now
+	}
+	return feed, nil
+}
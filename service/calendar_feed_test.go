@@ -0,0 +1,43 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCalendarFeedLifecycle(t *testing.T) {
+	setupTestModel()
+
+	feed, token, err := CreateCalendarFeed("phone", CalendarFeedTasks)
+	if err != nil {
+		t.Fatalf("Failed to create calendar feed: %v", err)
+	}
+	if !strings.HasPrefix(token, CalendarFeedPrefix) || feed.TokenHash != hashToken(token) {
+		t.Errorf("Expected a %s token stored as its hash, got %q", CalendarFeedPrefix, token)
+	}
+
+	validated, err := ValidateCalendarFeed(token, CalendarFeedTasks)
+	if err != nil || validated.ID != feed.ID || validated.LastUsedAt == nil {
+		t.Fatalf("Expected the token to open the tasks feed, got %v (%v)", validated, err)
+	}
+	if _, err := ValidateCalendarFeed(token, CalendarFeedTimeLogs); !errors.Is(err, ErrInvalidCalendarFeed) {
+		t.Errorf("A tasks token must not open the timelogs feed, got %v", err)
+	}
+	if _, err := ValidateCalendarFeed(strings.Replace(token, CalendarFeedPrefix, APITokenPrefix, 1), CalendarFeedTasks); !errors.Is(err, ErrInvalidCalendarFeed) {
+		t.Errorf("Expected tokens without the feed prefix to be rejected, got %v", err)
+	}
+
+	if err := RevokeCalendarFeed(feed.ID); err != nil {
+		t.Fatalf("Failed to revoke calendar feed: %v", err)
+	}
+	if _, err := ValidateCalendarFeed(token, CalendarFeedTasks); !errors.Is(err, ErrInvalidCalendarFeed) {
+		t.Errorf("Expected the revoked feed to be rejected, got %v", err)
+	}
+
+	for _, kind := range []string{"", "events"} {
+		if _, _, err := CreateCalendarFeed("bad", kind); !errors.Is(err, ErrInvalidCalendarFeedRequest) {
+			t.Errorf("Expected kind %q to be rejected, got %v", kind, err)
+		}
+	}
+}
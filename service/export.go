@@ -0,0 +1,588 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/model/gen"
+)
+
+// 导出格式
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatJSON   = "json"
+	ExportFormatNDJSON = "ndjson"
+)
+
+// 导出的数据类型，按引用关系排序：分类、约束、任务、时间日志
+const (
+	ExportTypeCategories  = "categories"
+	ExportTypeConstraints = "constraints"
+	ExportTypeTasks       = "tasks"
+	ExportTypeTimeLogs    = "timelogs"
+)
+
+var exportTypes = []string{ExportTypeCategories, ExportTypeConstraints, ExportTypeTasks, ExportTypeTimeLogs}
+
+// exportFlushEvery 每写出多少条记录刷新一次缓冲
+const exportFlushEvery = 200
+
+var ErrInvalidExportQuery = errors.New("invalid export query")
+
+// ExportOptions 导出参数
+// From/To 为本地日期（两端均包含），限定时间日志的开始时间与任务的截止时间；分类与约束总是全量导出
+// Types 为空时导出全部类型，csv 格式一次只能导出一种类型，默认为 timelogs
+type ExportOptions struct {
+	Format string
+	Types  []string
+	From   string
+	To     string
+
+	start, end *time.Time
+}
+
+// Normalize 校验并补全导出参数
+func (o *ExportOptions) Normalize() error {
+	if o.Format == "" {
+		o.Format = ExportFormatJSON
+	}
+	switch o.Format {
+	case ExportFormatCSV, ExportFormatJSON, ExportFormatNDJSON:
+	default:
+		return fmt.Errorf("%w: format must be csv, json or ndjson", ErrInvalidExportQuery)
+	}
+
+	if len(o.Types) == 0 {
+		if o.Format == ExportFormatCSV {
+			o.Types = []string{ExportTypeTimeLogs}
+		} else {
+			o.Types = exportTypes
+		}
+	}
+	selected := map[string]bool{}
+	for _, t := range o.Types {
+		if !isExportType(t) {
+			return fmt.Errorf("%w: type must be one of %s", ErrInvalidExportQuery, strings.Join(exportTypes, ", "))
+		}
+		selected[t] = true
+	}
+	if o.Format == ExportFormatCSV && len(selected) != 1 {
+		return fmt.Errorf("%w: csv export supports exactly one type", ErrInvalidExportQuery)
+	}
+	// 按引用关系重新排序并去重
+	ordered := make([]string, 0, len(selected))
+	for _, t := range exportTypes {
+		if selected[t] {
+			ordered = append(ordered, t)
+		}
+	}
+	o.Types = ordered
+
+	if o.From != "" {
+		from, err := model.ParseLocalDate(o.From)
+		if err != nil {
+			return fmt.Errorf("%w: from must be YYYY-MM-DD", ErrInvalidExportQuery)
+		}
+		start, _ := model.LocalDayBounds(from)
+		o.start = &start
+	}
+	if o.To != "" {
+		to, err := model.ParseLocalDate(o.To)
+		if err != nil {
+			return fmt.Errorf("%w: to must be YYYY-MM-DD", ErrInvalidExportQuery)
+		}
+		_, end := model.LocalDayBounds(to)
+		o.end = &end
+	}
+	if o.start != nil && o.end != nil && !o.start.Before(*o.end) {
+		return fmt.Errorf("%w: from must not be after to", ErrInvalidExportQuery)
+	}
+	return nil
+}
+
+func isExportType(t string) bool {
+	for _, known := range exportTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// ContentType 导出格式对应的 MIME 类型
+func (o *ExportOptions) ContentType() string {
+	switch o.Format {
+	case ExportFormatCSV:
+		return "text/csv; charset=utf-8"
+	case ExportFormatNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/json; charset=utf-8"
+	}
+}
+
+// FileName 下载时建议的文件名
+func (o *ExportOptions) FileName() string {
+	name := "timelog-export"
+	if len(o.Types) == 1 {
+		name = "timelog-" + o.Types[0]
+	}
+	if o.From != "" {
+		name += "-" + o.From
+	}
+	if o.To != "" {
+		name += "-" + o.To
+	}
+	return name + "." + o.Format
+}
+
+// ExportCategory 导出的分类，按分类树的深度优先顺序输出
+type ExportCategory struct {
+	ID          int32      `json:"id"`
+	Name        string     `json:"name"`
+	ParentID    *int32     `json:"parent_id"`
+	Level       int32      `json:"level"`
+	Path        string     `json:"path"`
+	Color       *string    `json:"color"`
+	Description *string    `json:"description"`
+	SortOrder   int32      `json:"sort_order"`
+	ArchivedAt  *time.Time `json:"archived_at"`
+}
+
+// ExportConstraint 导出的约束，日期为本地日期
+type ExportConstraint struct {
+	ID              int32   `json:"id"`
+	Description     string  `json:"description"`
+	PunishmentQuote string  `json:"punishment_quote"`
+	StartDate       string  `json:"start_date"`
+	EndDate         *string `json:"end_date"`
+	IsActive        bool    `json:"is_active"`
+	EndReason       *string `json:"end_reason"`
+}
+
+// ExportTask 导出的任务，时间为本地时区
+type ExportTask struct {
+	ID               int32      `json:"id"`
+	Title            string     `json:"title"`
+	Description      *string    `json:"description"`
+	CategoryID       int32      `json:"category_id"`
+	CategoryPath     string     `json:"category_path"`
+	DueDate          time.Time  `json:"due_date"`
+	EstimatedMinutes int32      `json:"estimated_minutes"`
+	IsCompleted      bool       `json:"is_completed"`
+	CompletedAt      *time.Time `json:"completed_at"`
+	IsSuspended      bool       `json:"is_suspended"`
+}
+
+// ExportTimeLog 导出的时间日志，时间为本地时区，附带分类路径与任务标题
+type ExportTimeLog struct {
+	ID              int32      `json:"id"`
+	StartTime       time.Time  `json:"start_time"`
+	EndTime         *time.Time `json:"end_time"`
+	DurationMinutes *float64   `json:"duration_minutes"`
+	CategoryID      int32      `json:"category_id"`
+	CategoryPath    string     `json:"category_path"`
+	TaskID          *int32     `json:"task_id"`
+	TaskTitle       *string    `json:"task_title"`
+	Remark          *string    `json:"remark"`
+}
+
+// exportRecordWriter 一种导出格式的写入方式
+type exportRecordWriter interface {
+	meta(meta map[string]interface{}) error
+	begin(dataType string) error
+	record(dataType string, r exportRecord) error
+	end(dataType string) error
+	close() error
+}
+
+// exportRecord 可以写成 CSV 行的导出记录
+type exportRecord interface {
+	csvRow() []string
+}
+
+// Export 将数据按 opts 流式写入 w，opts 需先经过 Normalize
+// 时间日志与任务逐条从数据库读取，写出的数据会定期刷新，不会整体载入内存
+func Export(w io.Writer, opts ExportOptions) error {
+	db := model.GetDao().Db()
+	buf := bufio.NewWriter(w)
+
+	var out exportRecordWriter
+	switch opts.Format {
+	case ExportFormatCSV:
+		out = &csvExportWriter{w: csv.NewWriter(buf)}
+	case ExportFormatNDJSON:
+		out = &ndjsonExportWriter{w: buf}
+	default:
+		out = &jsonExportWriter{w: buf}
+	}
+
+	meta := map[string]interface{}{
+		"exported_at": time.Now().In(model.GetLocation()),
+		"timezone":    model.GetLocation().String(),
+		"from":        opts.From,
+		"to":          opts.To,
+	}
+	if err := out.meta(meta); err != nil {
+		return err
+	}
+
+	paths, err := categoryPaths()
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	emit := func(dataType string, r exportRecord) error {
+		if err := out.record(dataType, r); err != nil {
+			return err
+		}
+		count++
+		if count%exportFlushEvery == 0 {
+			return flushExport(out, buf)
+		}
+		return nil
+	}
+
+	for _, dataType := range opts.Types {
+		if err := out.begin(dataType); err != nil {
+			return err
+		}
+		switch dataType {
+		case ExportTypeCategories:
+			err = exportCategories(emit)
+		case ExportTypeConstraints:
+			err = exportConstraints(emit)
+		case ExportTypeTasks:
+			err = model.EachTask(db, opts.start, opts.end, func(task *gen.Task) error {
+				return emit(dataType, newExportTask(task, paths))
+			})
+		case ExportTypeTimeLogs:
+			var titles map[int32]string
+			if titles, err = model.ListTaskTitles(db); err == nil {
+				err = model.EachTimeLog(db, opts.start, opts.end, func(tl *gen.Timelog) error {
+					return emit(dataType, newExportTimeLog(tl, paths, titles))
+				})
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if err := out.end(dataType); err != nil {
+			return err
+		}
+	}
+	if err := out.close(); err != nil {
+		return err
+	}
+	return flushExport(out, buf)
+}
+
+func flushExport(out exportRecordWriter, buf *bufio.Writer) error {
+	if c, ok := out.(*csvExportWriter); ok {
+		c.w.Flush()
+		if err := c.w.Error(); err != nil {
+			return err
+		}
+	}
+	return buf.Flush()
+}
+
+// categoryPaths 返回所有分类（包括已归档的）ID 到完整路径的映射
+func categoryPaths() (map[int32]string, error) {
+	categories, err := model.ListCategories(model.GetDao().Db())
+	if err != nil {
+		return nil, err
+	}
+	paths := make(map[int32]string, len(categories))
+	for i := range categories {
+		paths[*categories[i].ID] = model.GetFullPath(&categories[i])
+	}
+	return paths, nil
+}
+
+func exportCategories(emit func(string, exportRecord) error) error {
+	tree, err := model.GetCategoryTree(model.GetDao().Db())
+	if err != nil {
+		return err
+	}
+	var walk func(nodes []*model.CategoryNode) error
+	walk = func(nodes []*model.CategoryNode) error {
+		for _, node := range nodes {
+			c := node.Category
+			r := &ExportCategory{
+				ID:          *c.ID,
+				Name:        c.Name,
+				ParentID:    c.ParentID,
+				Path:        model.GetFullPath(&c),
+				Color:       c.Color,
+				Description: c.Description,
+				ArchivedAt:  localTimePtr(c.ArchivedAt),
+			}
+			if c.Level != nil {
+				r.Level = *c.Level
+			}
+			if c.SortOrder != nil {
+				r.SortOrder = *c.SortOrder
+			}
+			if err := emit(ExportTypeCategories, r); err != nil {
+				return err
+			}
+			if err := walk(node.Children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(tree)
+}
+
+func exportConstraints(emit func(string, exportRecord) error) error {
+	constraints, err := model.GetAllConstraints(model.GetDao().Db())
+	if err != nil {
+		return err
+	}
+	for _, c := range constraints {
+		r := &ExportConstraint{
+			ID:              *c.ID,
+			Description:     c.Description,
+			PunishmentQuote: c.PunishmentQuote,
+			StartDate:       c.StartDate.In(model.GetLocation()).Format("2006-01-02"),
+			IsActive:        c.IsActive != nil && *c.IsActive,
+			EndReason:       c.EndReason,
+		}
+		if c.EndDate != nil {
+			end := c.EndDate.In(model.GetLocation()).Format("2006-01-02")
+			r.EndDate = &end
+		}
+		if err := emit(ExportTypeConstraints, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newExportTask(task *gen.Task, paths map[int32]string) *ExportTask {
+	return &ExportTask{
+		ID:               *task.ID,
+		Title:            task.Title,
+		Description:      task.Description,
+		CategoryID:       task.CategoryID,
+		CategoryPath:     paths[task.CategoryID],
+		DueDate:          task.DueDate.In(model.GetLocation()),
+		EstimatedMinutes: task.EstimatedMinutes,
+		IsCompleted:      task.IsCompleted != nil && *task.IsCompleted,
+		CompletedAt:      localTimePtr(task.CompletedAt),
+		IsSuspended:      task.IsSuspended != nil && *task.IsSuspended,
+	}
+}
+
+func newExportTimeLog(tl *gen.Timelog, paths map[int32]string, titles map[int32]string) *ExportTimeLog {
+	r := &ExportTimeLog{
+		ID:           *tl.ID,
+		StartTime:    tl.StartTime.In(model.GetLocation()),
+		EndTime:      localTimePtr(tl.EndTime),
+		CategoryID:   tl.CategoryID,
+		CategoryPath: paths[tl.CategoryID],
+		TaskID:       tl.TaskID,
+		Remark:       tl.Remark,
+	}
+	if tl.EndTime != nil {
+		minutes := tl.EndTime.Sub(tl.StartTime).Minutes()
+		r.DurationMinutes = &minutes
+	}
+	if tl.TaskID != nil {
+		if title, ok := titles[*tl.TaskID]; ok {
+			r.TaskTitle = &title
+		}
+	}
+	return r
+}
+
+func localTimePtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	local := t.In(model.GetLocation())
+	return &local
+}
+
+// --- CSV ---
+
+var exportCSVHeaders = map[string][]string{
+	ExportTypeCategories:  {"id", "name", "parent_id", "level", "path", "color", "description", "sort_order", "archived_at"},
+	ExportTypeConstraints: {"id", "description", "punishment_quote", "start_date", "end_date", "is_active", "end_reason"},
+	ExportTypeTasks:       {"id", "title", "description", "category_id", "category_path", "due_date", "estimated_minutes", "is_completed", "completed_at", "is_suspended"},
+	ExportTypeTimeLogs:    {"id", "start_time", "end_time", "duration_minutes", "category_id", "category_path", "task_id", "task_title", "remark"},
+}
+
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+// meta CSV 只包含单一类型的记录，不输出导出信息
+func (c *csvExportWriter) meta(map[string]interface{}) error { return nil }
+
+func (c *csvExportWriter) begin(dataType string) error {
+	return c.w.Write(exportCSVHeaders[dataType])
+}
+
+func (c *csvExportWriter) record(_ string, r exportRecord) error {
+	return c.w.Write(r.csvRow())
+}
+
+func (c *csvExportWriter) end(string) error { return nil }
+
+func (c *csvExportWriter) close() error { return nil }
+
+func csvString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func csvInt(v *int32) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(int(*v))
+}
+
+func csvTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func (r *ExportCategory) csvRow() []string {
+	return []string{
+		strconv.Itoa(int(r.ID)), r.Name, csvInt(r.ParentID), strconv.Itoa(int(r.Level)), r.Path,
+		csvString(r.Color), csvString(r.Description), strconv.Itoa(int(r.SortOrder)), csvTime(r.ArchivedAt),
+	}
+}
+
+func (r *ExportConstraint) csvRow() []string {
+	return []string{
+		strconv.Itoa(int(r.ID)), r.Description, r.PunishmentQuote, r.StartDate, csvString(r.EndDate),
+		strconv.FormatBool(r.IsActive), csvString(r.EndReason),
+	}
+}
+
+func (r *ExportTask) csvRow() []string {
+	return []string{
+		strconv.Itoa(int(r.ID)), r.Title, csvString(r.Description), strconv.Itoa(int(r.CategoryID)), r.CategoryPath,
+		r.DueDate.Format(time.RFC3339), strconv.Itoa(int(r.EstimatedMinutes)), strconv.FormatBool(r.IsCompleted),
+		csvTime(r.CompletedAt), strconv.FormatBool(r.IsSuspended),
+	}
+}
+
+func (r *ExportTimeLog) csvRow() []string {
+	duration := ""
+	if r.DurationMinutes != nil {
+		duration = strconv.FormatFloat(*r.DurationMinutes, 'f', -1, 64)
+	}
+	return []string{
+		strconv.Itoa(int(r.ID)), r.StartTime.Format(time.RFC3339), csvTime(r.EndTime), duration,
+		strconv.Itoa(int(r.CategoryID)), r.CategoryPath, csvInt(r.TaskID), csvString(r.TaskTitle), csvString(r.Remark),
+	}
+}
+
+// --- JSON ---
+
+// jsonExportWriter 输出一个 JSON 对象，每种数据类型为一个数组字段
+type jsonExportWriter struct {
+	w     io.Writer
+	first bool
+}
+
+func (j *jsonExportWriter) meta(meta map[string]interface{}) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	// 去掉结尾的 }，后续字段接在 meta 之后
+	_, err = j.w.Write(data[:len(data)-1])
+	return err
+}
+
+func (j *jsonExportWriter) begin(dataType string) error {
+	j.first = true
+	_, err := fmt.Fprintf(j.w, ",%q:[", dataType)
+	return err
+}
+
+func (j *jsonExportWriter) record(_ string, r exportRecord) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if !j.first {
+		if _, err := io.WriteString(j.w, ","); err != nil {
+			return err
+		}
+	}
+	j.first = false
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonExportWriter) end(string) error {
+	_, err := io.WriteString(j.w, "]")
+	return err
+}
+
+func (j *jsonExportWriter) close() error {
+	_, err := io.WriteString(j.w, "}\n")
+	return err
+}
+
+// --- NDJSON ---
+
+// ndjsonRecordTypes 每行记录的 type 字段
+var ndjsonRecordTypes = map[string]string{
+	ExportTypeCategories:  "category",
+	ExportTypeConstraints: "constraint",
+	ExportTypeTasks:       "task",
+	ExportTypeTimeLogs:    "timelog",
+}
+
+// ndjsonExportWriter 每行一条记录，第一行为 type 为 meta 的导出信息
+type ndjsonExportWriter struct {
+	w io.Writer
+}
+
+func (n *ndjsonExportWriter) meta(meta map[string]interface{}) error {
+	meta["type"] = "meta"
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(n.w, "%s\n", data)
+	return err
+}
+
+func (n *ndjsonExportWriter) begin(string) error { return nil }
+
+func (n *ndjsonExportWriter) record(dataType string, r exportRecord) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	// 在记录对象的开头插入 type 字段
+	_, err = fmt.Fprintf(n.w, "{\"type\":%q,%s\n", ndjsonRecordTypes[dataType], data[1:])
+	return err
+}
+
+func (n *ndjsonExportWriter) end(string) error { return nil }
+
+func (n *ndjsonExportWriter) close() error { return nil }
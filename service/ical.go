@@ -0,0 +1,212 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/model/gen"
+)
+
+// icalTimeLayout RFC 5545 的 UTC 日期时间格式
+const icalTimeLayout = "20060102T150405Z"
+
+// icalLineLimit RFC 5545 内容行的最大字节数，超过时折行
+const icalLineLimit = 75
+
+// DefaultICalTimeLogDays 时间日志订阅源默认包含最近多少天的记录
+const DefaultICalTimeLogDays = 90
+
+// icalWriter 按 RFC 5545 写出内容行：CRLF 换行、超长折行、文本值转义
+// 第一次写入失败后忽略后续写入，由 close 返回错误
+type icalWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func newICalWriter(w io.Writer) *icalWriter {
+	return &icalWriter{w: bufio.NewWriter(w)}
+}
+
+// line 写出一行，value 原样输出，只用于日期、枚举等不需要转义的值
+func (iw *icalWriter) line(name, value string) {
+	if iw.err != nil {
+		return
+	}
+	_, iw.err = iw.w.WriteString(foldICalLine(name + ":" + value))
+}
+
+// text 写出文本类型的属性
+func (iw *icalWriter) text(name, value string) {
+	iw.line(name, escapeICalText(value))
+}
+
+func (iw *icalWriter) time(name string, t time.Time) {
+	iw.line(name, t.UTC().Format(icalTimeLayout))
+}
+
+// begin 写出日历头，时间统一使用 UTC，X-WR-TIMEZONE 只供客户端显示参考
+func (iw *icalWriter) begin(name string) {
+	iw.line("BEGIN", "VCALENDAR")
+	iw.line("VERSION", "2.0")
+	iw.line("PRODID", "-//blacksheepaul//timelog//EN")
+	iw.line("CALSCALE", "GREGORIAN")
+	iw.line("METHOD", "PUBLISH")
+	iw.text("X-WR-CALNAME", name)
+	iw.line("X-WR-TIMEZONE", model.GetLocation().String())
+	iw.line("REFRESH-INTERVAL;VALUE=DURATION", "PT1H")
+	iw.line("X-PUBLISHED-TTL", "PT1H")
+}
+
+func (iw *icalWriter) close() error {
+	iw.line("END", "VCALENDAR")
+	if iw.err != nil {
+		return iw.err
+	}
+	return iw.w.Flush()
+}
+
+// foldICalLine 把超过 75 字节的内容行折成多行，续行以空格开头，不拆开多字节字符
+func foldICalLine(line string) string {
+	var b strings.Builder
+	limit := icalLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// 续行开头的空格也计入长度
+		limit = icalLineLimit - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+	return b.String()
+}
+
+var icalTextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// escapeICalText 转义 TEXT 类型值中的反斜杠、分号、逗号与换行
+func escapeICalText(s string) string {
+	return icalTextEscaper.Replace(s)
+}
+
+// WriteTimeLogsICal 把最近 days 天内开始的时间日志写成 VEVENT，days 为 0 时包含全部历史
+// 分类路径作为 CATEGORIES，备注作为 DESCRIPTION；标题依次取任务标题、备注首行、分类路径。
+// 正在计时的日志没有结束时间，不写入订阅源
+func WriteTimeLogsICal(w io.Writer, feed *model.CalendarFeed, days int) error {
+	db := model.GetDao().Db()
+	paths, err := categoryPaths()
+	if err != nil {
+		return err
+	}
+	titles, err := model.ListTaskTitles(db)
+	if err != nil {
+		return err
+	}
+	var start *time.Time
+	if days > 0 {
+		from := time.Now().AddDate(0, 0, -days)
+		start = &from
+	}
+
+	iw := newICalWriter(w)
+	iw.begin(feed.Name)
+	err = model.EachTimeLog(db, start, nil, func(tl *gen.Timelog) error {
+		if tl.EndTime == nil {
+			return nil
+		}
+		writeTimeLogEvent(iw, tl, paths, titles)
+		return iw.err
+	})
+	if err != nil {
+		return err
+	}
+	return iw.close()
+}
+
+func writeTimeLogEvent(iw *icalWriter, tl *gen.Timelog, paths, titles map[int32]string) {
+	path := paths[tl.CategoryID]
+	remark := ""
+	if tl.Remark != nil {
+		remark = strings.TrimSpace(*tl.Remark)
+	}
+	summary := path
+	if tl.TaskID != nil && titles[*tl.TaskID] != "" {
+		summary = titles[*tl.TaskID]
+	} else if remark != "" {
+		summary, _, _ = strings.Cut(remark, "\n")
+	}
+
+	iw.line("BEGIN", "VEVENT")
+	iw.line("UID", fmt.Sprintf("timelog-%d@timelog", *tl.ID))
+	iw.time("DTSTAMP", tl.UpdatedAt)
+	iw.time("LAST-MODIFIED", tl.UpdatedAt)
+	iw.time("DTSTART", tl.StartTime)
+	iw.time("DTEND", *tl.EndTime)
+	iw.text("SUMMARY", summary)
+	if path != "" {
+		iw.text("CATEGORIES", path)
+	}
+	if remark != "" {
+		iw.text("DESCRIPTION", remark)
+	}
+	iw.line("END", "VEVENT")
+}
+
+// WriteTasksICal 把未删除的任务写成 VTODO，截止时间作为 DUE，分类路径作为 CATEGORIES，任务描述作为 DESCRIPTION
+func WriteTasksICal(w io.Writer, feed *model.CalendarFeed) error {
+	db := model.GetDao().Db()
+	paths, err := categoryPaths()
+	if err != nil {
+		return err
+	}
+
+	iw := newICalWriter(w)
+	iw.begin(feed.Name)
+	err = model.EachTask(db, nil, nil, func(task *gen.Task) error {
+		writeTaskTodo(iw, task, paths)
+		return iw.err
+	})
+	if err != nil {
+		return err
+	}
+	return iw.close()
+}
+
+func writeTaskTodo(iw *icalWriter, task *gen.Task, paths map[int32]string) {
+	stamp := time.Now()
+	if task.UpdatedAt != nil {
+		stamp = *task.UpdatedAt
+	} else if task.CreatedAt != nil {
+		stamp = *task.CreatedAt
+	}
+
+	iw.line("BEGIN", "VTODO")
+	iw.line("UID", fmt.Sprintf("task-%d@timelog", *task.ID))
+	iw.time("DTSTAMP", stamp)
+	iw.time("LAST-MODIFIED", stamp)
+	iw.time("DUE", task.DueDate)
+	iw.text("SUMMARY", task.Title)
+	if path := paths[task.CategoryID]; path != "" {
+		iw.text("CATEGORIES", path)
+	}
+	if task.Description != nil && strings.TrimSpace(*task.Description) != "" {
+		iw.text("DESCRIPTION", *task.Description)
+	}
+	if task.IsCompleted != nil && *task.IsCompleted {
+		iw.line("STATUS", "COMPLETED")
+		if task.CompletedAt != nil {
+			iw.time("COMPLETED", *task.CompletedAt)
+		}
+	} else {
+		iw.line("STATUS", "NEEDS-ACTION")
+	}
+	iw.line("END", "VTODO")
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFoldICalLine(t *testing.T) {
+	line := "DESCRIPTION:" + strings.Repeat("时间记录 timelog ", 20)
+	folded := foldICalLine(line)
+	if !strings.HasSuffix(folded, "\r\n") {
+		t.Fatalf("Expected a CRLF terminated line: %q", folded)
+	}
+
+	physical := strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n")
+	if len(physical) < 2 {
+		t.Fatalf("Expected the long line to be folded: %q", folded)
+	}
+	var unfolded strings.Builder
+	for i, part := range physical {
+		if len(part) > icalLineLimit {
+			t.Errorf("Line %d is %d bytes long", i, len(part))
+		}
+		if i > 0 {
+			if !strings.HasPrefix(part, " ") {
+				t.Errorf("Continuation line %d must start with a space: %q", i, part)
+			}
+			part = part[1:]
+		}
+		if !utf8.ValidString(part) {
+			t.Errorf("Line %d splits a multi-byte character: %q", i, part)
+		}
+		unfolded.WriteString(part)
+	}
+	if unfolded.String() != line {
+		t.Errorf("Unfolding should restore the original line")
+	}
+
+	if got := foldICalLine("SUMMARY:short"); got != "SUMMARY:short\r\n" {
+		t.Errorf("Short lines should not be folded: %q", got)
+	}
+}
+
+func TestEscapeICalText(t *testing.T) {
+	got := escapeICalText("a,b;c\\d\r\ne\nf")
+	if want := `a\,b\;c\\d\ne\nf`; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
@@ -106,13 +106,6 @@ func GenerateSessionToken() (string, error) {
 	return hex.EncodeToString(tokenBytes), nil
 }
 
-func StoreSessionToken(token string, ttlSeconds int64) error {
-	dao := model.GetDao()
-	// Namespace the key to distinguish from passkey sessions
-	dao.WriteCache("auth_token:"+token, true, ttlSeconds)
-	return nil
-}
-
 func GenerateTempPassword() (string, string, error) {
 	raw := make([]byte, 16)
 	if _, err := rand.Read(raw); err != nil {
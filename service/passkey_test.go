@@ -26,6 +26,7 @@ func (l FakeLogger) Fatalw(msg string, keysAndValues ...interface{}) {}
 func setupTestModel() *model.Dao {
 	cfg := &config.Config{}
 	cfg.Database.Host = ":memory:"
+	cfg.Database.AutoMigrate = true
 	cfg.Log.ORMLogLevel = 1
 	model.InitDao(cfg, FakeLogger{})
 	return model.GetDao()
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/model/gen"
+)
+
+// ReportTasks 日报中的任务完成情况
+type ReportTasks struct {
+	Planned        int     `json:"planned"`
+	Completed      int     `json:"completed"`
+	CompletionRate float64 `json:"completion_rate"`
+	// Items 当天计划（截止日期为当天）的任务
+	Items []TaskWithActual `json:"items"`
+	// CompletedUnplanned 当天完成但截止日期不在当天的任务
+	CompletedUnplanned []TaskWithActual `json:"completed_unplanned"`
+}
+
+// ReportConstraint 日报中的生效约束
+type ReportConstraint struct {
+	ID              int32  `json:"id"`
+	Description     string `json:"description"`
+	PunishmentQuote string `json:"punishment_quote"`
+	StartDate       string `json:"start_date"`
+}
+
+// DailyReport 某一天的日报
+type DailyReport struct {
+	Date             string             `json:"date"`
+	Timezone         string             `json:"timezone"`
+	GeneratedAt      time.Time          `json:"generated_at"`
+	TrackedMinutes   float64            `json:"tracked_minutes"`
+	Categories       []*CategoryStat    `json:"categories"`
+	Tasks            ReportTasks        `json:"tasks"`
+	EstimateAccuracy EstimateAccuracy   `json:"estimate_accuracy"`
+	Constraints      []ReportConstraint `json:"constraints"`
+}
+
+// ReportEntry 已保存的报告，Content 为报告内容
+type ReportEntry struct {
+	model.Report
+	Content json.RawMessage `json:"content"`
+}
+
+func newReportEntry(r model.Report) ReportEntry {
+	return ReportEntry{Report: r, Content: json.RawMessage(r.Content)}
+}
+
+// BuildDailyReport 生成指定本地日期（YYYY-MM-DD）的日报，不保存
+func BuildDailyReport(dateStr string) (*DailyReport, error) {
+	date, err := model.ParseLocalDate(dateStr)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := GetStats(dateStr, dateStr, StatsPeriodDay)
+	if err != nil {
+		return nil, err
+	}
+	accuracy, err := GetEstimateAccuracy(dateStr, dateStr, StatsPeriodDay)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DailyReport{
+		Date:             dateStr,
+		Timezone:         model.GetLocation().String(),
+		GeneratedAt:      time.Now(),
+		TrackedMinutes:   stats.TotalMinutes,
+		Categories:       stats.Categories,
+		EstimateAccuracy: accuracy.Overall,
+		Constraints:      []ReportConstraint{},
+	}
+
+	db := model.GetDao().Db()
+	planned, err := model.GetTasksByDate(db, date, false, true)
+	if err != nil {
+		return nil, err
+	}
+	report.Tasks.Items, err = AttachTaskActuals(planned)
+	if err != nil {
+		return nil, err
+	}
+	report.Tasks.Planned = len(planned)
+	for _, task := range planned {
+		if task.IsCompleted != nil && *task.IsCompleted {
+			report.Tasks.Completed++
+		}
+	}
+	if report.Tasks.Planned > 0 {
+		report.Tasks.CompletionRate = float64(report.Tasks.Completed) / float64(report.Tasks.Planned) * 100
+	}
+
+	dayStart, dayEnd := model.LocalDayBounds(date)
+	completed, err := model.GetCompletedTasksInDateRange(db, dayStart.UTC(), dayEnd.UTC())
+	if err != nil {
+		return nil, err
+	}
+	plannedIDs := make(map[int32]bool, len(planned))
+	for _, task := range planned {
+		plannedIDs[*task.ID] = true
+	}
+	var unplanned []gen.Task
+	for _, task := range completed {
+		if !plannedIDs[*task.ID] && task.CompletedAt.Before(dayEnd) {
+			unplanned = append(unplanned, task)
+		}
+	}
+	report.Tasks.CompletedUnplanned, err = AttachTaskActuals(unplanned)
+	if err != nil {
+		return nil, err
+	}
+
+	constraints, err := model.GetActiveConstraints(db)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range constraints {
+		report.Constraints = append(report.Constraints, ReportConstraint{
+			ID:              *c.ID,
+			Description:     c.Description,
+			PunishmentQuote: c.PunishmentQuote,
+			StartDate:       c.StartDate.In(model.GetLocation()).Format("2006-01-02"),
+		})
+	}
+	return report, nil
+}
+
+// GenerateDailyReport 生成并保存指定日期的日报，已存在时覆盖
+func GenerateDailyReport(dateStr string) (*ReportEntry, error) {
+	report, err := BuildDailyReport(dateStr)
+	if err != nil {
+		return nil, err
+	}
+	content, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+
+	db := model.GetDao().Db()
+	if err := model.SaveReport(db, &model.Report{
+		Kind:       model.ReportKindDaily,
+		ReportDate: dateStr,
+		Content:    string(content),
+	}); err != nil {
+		return nil, err
+	}
+	return GetDailyReport(dateStr)
+}
+
+// GetDailyReport 获取已保存的日报
+func GetDailyReport(dateStr string) (*ReportEntry, error) {
+	r, err := model.GetReport(model.GetDao().Db(), model.ReportKindDaily, dateStr)
+	if err != nil {
+		return nil, err
+	}
+	entry := newReportEntry(*r)
+	return &entry, nil
+}
+
+// ListDailyReports 按日期倒序查询已保存的日报
+func ListDailyReports(from, to string, limit int) ([]ReportEntry, error) {
+	reports, err := model.ListReports(model.GetDao().Db(), model.ReportKindDaily, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]ReportEntry, 0, len(reports))
+	for _, r := range reports {
+		entries = append(entries, newReportEntry(r))
+	}
+	return entries, nil
+}
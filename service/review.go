@@ -0,0 +1,227 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/model/gen"
+)
+
+// DateRange 本地日期范围，两端均包含
+type DateRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// CategoryComparison 某个分类在两个日期范围内的时长，分钟数包含所有子分类
+type CategoryComparison struct {
+	CategoryID      int32    `json:"category_id"`
+	Path            string   `json:"path"`
+	Level           int      `json:"level"`
+	CurrentMinutes  float64  `json:"current_minutes"`
+	PreviousMinutes float64  `json:"previous_minutes"`
+	DeltaMinutes    float64  `json:"delta_minutes"`
+	DeltaPercent    *float64 `json:"delta_percent"` // 上一周期为 0 时为空
+}
+
+// PeriodComparison 两个日期范围的分类时长对比
+type PeriodComparison struct {
+	Current              DateRange            `json:"current"`
+	Previous             DateRange            `json:"previous"`
+	CurrentTotalMinutes  float64              `json:"current_total_minutes"`
+	PreviousTotalMinutes float64              `json:"previous_total_minutes"`
+	DeltaMinutes         float64              `json:"delta_minutes"`
+	DeltaPercent         *float64             `json:"delta_percent"`
+	Categories           []CategoryComparison `json:"categories"`
+}
+
+func deltaPercent(current, previous float64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	p := (current - previous) / previous * 100
+	return &p
+}
+
+// flattenCategoryStats 将分类统计树按分类ID展开，level 从 1 开始
+func flattenCategoryStats(stats []*CategoryStat, level int, out map[int32]CategoryComparison, current bool) {
+	for _, stat := range stats {
+		entry, ok := out[stat.CategoryID]
+		if !ok {
+			entry = CategoryComparison{CategoryID: stat.CategoryID, Path: stat.Path, Level: level}
+		}
+		if current {
+			entry.CurrentMinutes = stat.TotalMinutes
+		} else {
+			entry.PreviousMinutes = stat.TotalMinutes
+		}
+		out[stat.CategoryID] = entry
+		flattenCategoryStats(stat.Children, level+1, out, current)
+	}
+}
+
+// ComparePeriods 对比 [fromStr, toStr] 与上一周期各分类的时长
+// prevFromStr/prevToStr 为空时，上一周期为紧邻当前周期之前、天数相同的日期范围
+func ComparePeriods(fromStr, toStr, prevFromStr, prevToStr string) (*PeriodComparison, error) {
+	from, err := model.ParseLocalDate(fromStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: from must be YYYY-MM-DD", ErrInvalidStatsQuery)
+	}
+	to, err := model.ParseLocalDate(toStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: to must be YYYY-MM-DD", ErrInvalidStatsQuery)
+	}
+	if prevFromStr == "" && prevToStr == "" {
+		days := int(math.Round(to.Sub(from).Hours()/24)) + 1
+		prevToStr = from.AddDate(0, 0, -1).Format("2006-01-02")
+		prevFromStr = from.AddDate(0, 0, -days).Format("2006-01-02")
+	} else if prevFromStr == "" || prevToStr == "" {
+		return nil, fmt.Errorf("%w: previous range needs both from and to", ErrInvalidStatsQuery)
+	}
+
+	// 只需要整个范围的汇总，按年切分窗口最少
+	current, err := GetStats(fromStr, toStr, StatsPeriodYear)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := GetStats(prevFromStr, prevToStr, StatsPeriodYear)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := map[int32]CategoryComparison{}
+	flattenCategoryStats(current.Categories, 1, byID, true)
+	flattenCategoryStats(previous.Categories, 1, byID, false)
+
+	result := &PeriodComparison{
+		Current:              DateRange{From: fromStr, To: toStr},
+		Previous:             DateRange{From: prevFromStr, To: prevToStr},
+		CurrentTotalMinutes:  current.TotalMinutes,
+		PreviousTotalMinutes: previous.TotalMinutes,
+		DeltaMinutes:         current.TotalMinutes - previous.TotalMinutes,
+		DeltaPercent:         deltaPercent(current.TotalMinutes, previous.TotalMinutes),
+		Categories:           make([]CategoryComparison, 0, len(byID)),
+	}
+	for _, entry := range byID {
+		entry.DeltaMinutes = entry.CurrentMinutes - entry.PreviousMinutes
+		entry.DeltaPercent = deltaPercent(entry.CurrentMinutes, entry.PreviousMinutes)
+		result.Categories = append(result.Categories, entry)
+	}
+	sort.Slice(result.Categories, func(i, j int) bool { return result.Categories[i].Path < result.Categories[j].Path })
+	return result, nil
+}
+
+// TrackedSession 一段已结束的时间日志
+type TrackedSession struct {
+	TimeLogID       int32     `json:"timelog_id"`
+	CategoryID      int32     `json:"category_id"`
+	CategoryPath    string    `json:"category_path"`
+	TaskID          *int32    `json:"task_id"`
+	TaskTitle       string    `json:"task_title,omitempty"`
+	Remark          *string   `json:"remark"`
+	StartTime       time.Time `json:"start_time"`
+	EndTime         time.Time `json:"end_time"`
+	DurationMinutes float64   `json:"duration_minutes"`
+}
+
+// SessionExtremes 日期范围内最长与最短的时间日志
+type SessionExtremes struct {
+	From           string           `json:"from"`
+	To             string           `json:"to"`
+	Count          int              `json:"count"`
+	TotalMinutes   float64          `json:"total_minutes"`
+	AverageMinutes float64          `json:"average_minutes"`
+	MedianMinutes  float64          `json:"median_minutes"`
+	Longest        []TrackedSession `json:"longest"`
+	Shortest       []TrackedSession `json:"shortest"`
+}
+
+// GetSessionExtremes 统计 [fromStr, toStr] 内开始且已结束的时间日志，返回最长与最短的 limit 条
+func GetSessionExtremes(fromStr, toStr string, limit int) (*SessionExtremes, error) {
+	if limit <= 0 {
+		limit = 5
+	}
+	from, err := model.ParseLocalDate(fromStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: from must be YYYY-MM-DD", ErrInvalidStatsQuery)
+	}
+	to, err := model.ParseLocalDate(toStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: to must be YYYY-MM-DD", ErrInvalidStatsQuery)
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: from must not be after to", ErrInvalidStatsQuery)
+	}
+
+	db := model.GetDao().Db()
+	tls, err := model.ListTimeLogsByLocalDateRange(db, fromStr, toStr)
+	if err != nil {
+		return nil, err
+	}
+	categoryList, err := model.ListCategories(db)
+	if err != nil {
+		return nil, err
+	}
+	categories := make(map[int32]gen.Category, len(categoryList))
+	for _, cat := range categoryList {
+		categories[*cat.ID] = cat
+	}
+	tasks, err := model.GetAllTasks(db, true, true)
+	if err != nil {
+		return nil, err
+	}
+	taskTitles := make(map[int32]string, len(tasks))
+	for _, task := range tasks {
+		taskTitles[*task.ID] = task.Title
+	}
+
+	sessions := make([]TrackedSession, 0, len(tls))
+	result := &SessionExtremes{From: fromStr, To: toStr}
+	for _, tl := range tls {
+		if tl.EndTime == nil || !tl.EndTime.After(tl.StartTime) {
+			continue
+		}
+		s := TrackedSession{
+			TimeLogID:       *tl.ID,
+			CategoryID:      tl.CategoryID,
+			CategoryPath:    fmt.Sprintf("#%d", tl.CategoryID),
+			TaskID:          tl.TaskID,
+			Remark:          tl.Remark,
+			StartTime:       tl.StartTime,
+			EndTime:         *tl.EndTime,
+			DurationMinutes: tl.EndTime.Sub(tl.StartTime).Minutes(),
+		}
+		if cat, ok := categories[tl.CategoryID]; ok {
+			s.CategoryPath = model.GetFullPath(&cat)
+		}
+		if tl.TaskID != nil {
+			s.TaskTitle = taskTitles[*tl.TaskID]
+		}
+		sessions = append(sessions, s)
+		result.TotalMinutes += s.DurationMinutes
+	}
+
+	result.Count = len(sessions)
+	result.Longest, result.Shortest = []TrackedSession{}, []TrackedSession{}
+	if result.Count == 0 {
+		return result, nil
+	}
+	result.AverageMinutes = result.TotalMinutes / float64(result.Count)
+
+	sort.SliceStable(sessions, func(i, j int) bool { return sessions[i].DurationMinutes > sessions[j].DurationMinutes })
+	mid := result.Count / 2
+	if result.Count%2 == 1 {
+		result.MedianMinutes = sessions[mid].DurationMinutes
+	} else {
+		result.MedianMinutes = (sessions[mid-1].DurationMinutes + sessions[mid].DurationMinutes) / 2
+	}
+	n := min(limit, result.Count)
+	result.Longest = append(result.Longest, sessions[:n]...)
+	for i := result.Count - 1; i >= result.Count-n; i-- {
+		result.Shortest = append(result.Shortest, sessions[i])
+	}
+	return result, nil
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"testing"
+)
+
+func TestFlattenCategoryStatsMergesPeriods(t *testing.T) {
+	current := []*CategoryStat{{
+		CategoryID: 1, Path: "Work", TotalMinutes: 90,
+		Children: []*CategoryStat{{CategoryID: 2, Path: "Work/Coding", TotalMinutes: 60}},
+	}}
+	previous := []*CategoryStat{
+		{CategoryID: 1, Path: "Work", TotalMinutes: 60},
+		{CategoryID: 3, Path: "Rest", TotalMinutes: 30},
+	}
+
+	byID := map[int32]CategoryComparison{}
+	flattenCategoryStats(current, 1, byID, true)
+	flattenCategoryStats(previous, 1, byID, false)
+	if len(byID) != 3 {
+		t.Fatalf("Expected 3 categories, got %d", len(byID))
+	}
+
+	work := byID[1]
+	if work.CurrentMinutes != 90 || work.PreviousMinutes != 60 || work.Level != 1 {
+		t.Errorf("Unexpected Work entry: %+v", work)
+	}
+	if coding := byID[2]; coding.Level != 2 || coding.PreviousMinutes != 0 {
+		t.Errorf("Expected Coding at level 2 without previous minutes, got %+v", coding)
+	}
+	if rest := byID[3]; rest.CurrentMinutes != 0 || rest.PreviousMinutes != 30 {
+		t.Errorf("Expected Rest only in previous period, got %+v", rest)
+	}
+}
+
+func TestDeltaPercent(t *testing.T) {
+	if p := deltaPercent(90, 60); p == nil || *p != 50 {
+		t.Errorf("Expected +50%%, got %v", p)
+	}
+	if p := deltaPercent(30, 0); p != nil {
+		t.Errorf("Expected nil when previous is 0, got %v", *p)
+	}
+}
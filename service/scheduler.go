@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/blacksheepaul/timelog/core/config"
+	"github.com/blacksheepaul/timelog/model"
+)
+
+// parseClock 解析 "HH:MM" 格式的时间
+func parseClock(s string) (int, int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time %q, expected HH:MM: %w", s, err)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// ValidateSchedule 校验已启用定时任务的 report.daily_at 与 backup.daily_at，格式错误时拒绝启动，避免定时任务被静默跳过
+func ValidateSchedule(config *config.Config) error {
+	if config.Report.Enabled {
+		if _, _, err := parseClock(config.Report.DailyAt); err != nil {
+			return fmt.Errorf("invalid report.daily_at: %w", err)
+		}
+	}
+	if config.Backup.Enabled {
+		if _, _, err := parseClock(config.Backup.DailyAt); err != nil {
+			return fmt.Errorf("invalid backup.daily_at: %w", err)
+		}
+	}
+	return nil
+}
+
+// nextDailyRun 返回 now 之后下一次在本地时间 hour:minute 运行的时间点
+func nextDailyRun(now time.Time, hour, minute int) time.Time {
+	local := now.In(model.GetLocation())
+	next := time.Date(local.Year(), local.Month(), local.Day(), hour, minute, 0, 0, model.GetLocation())
+	if !next.After(now) {
+		next = time.Date(local.Year(), local.Month(), local.Day()+1, hour, minute, 0, 0, model.GetLocation())
+	}
+	return next
+}
+
+// previousReportDate 返回在 now 时应生成日报的日期，即当前逻辑日期的前一天
+func previousReportDate(now time.Time) string {
+	return model.LocalDateOf(now).AddDate(0, 0, -1).Format("2006-01-02")
+}
+
+// runDailyReport 生成前一天的日报，失败只记录日志
+func runDailyReport(now time.Time) {
+	date := previousReportDate(now)
+	if _, err := GenerateDailyReport(date); err != nil {
+		log.Errorw("Failed to generate daily report", "date", date, "error", err)
+		return
+	}
+	log.Infow("Daily report generated", "date", date)
+}
+
+// runBackup 创建数据库快照，失败只记录日志
+func runBackup(time.Time) {
+	backup, err := CreateBackup()
+	if err != nil {
+		log.Errorw("Failed to back up database", "error", err)
+		return
+	}
+	log.Infow("Database backup created", "name", backup.Name, "size", backup.Size)
+}
+
+// dailyJob 每天在本地时间 hour:minute 运行一次的定时任务
+type dailyJob struct {
+	name         string
+	at           string
+	hour, minute int
+	run          func(now time.Time)
+	// missed 启动时调用，返回 true 表示错过了上一次运行，需要立即补运行
+	missed func(now time.Time) bool
+}
+
+// scheduledJobs 按配置返回启用的定时任务
+func scheduledJobs() []*dailyJob {
+	var jobs []*dailyJob
+	if cfg.Report.Enabled {
+		jobs = append(jobs, &dailyJob{
+			name: "daily report",
+			at:   cfg.Report.DailyAt,
+			run:  runDailyReport,
+			missed: func(now time.Time) bool {
+				_, err := GetDailyReport(previousReportDate(now))
+				return errors.Is(err, model.ErrRecordNotFound)
+			},
+		})
+	} else {
+		log.Info("[Startup] Daily report is disabled")
+	}
+	if cfg.Backup.Enabled {
+		jobs = append(jobs, &dailyJob{
+			name: "database backup",
+			at:   cfg.Backup.DailyAt,
+			run:  runBackup,
+			missed: func(now time.Time) bool {
+				latest, err := LatestBackup()
+				return err == nil && (latest == nil || now.Sub(latest.CreatedAt) >= 24*time.Hour)
+			},
+		})
+	} else {
+		log.Info("[Startup] Scheduled backup is disabled")
+	}
+
+	// daily_at 已在 InitService 中校验
+	for _, job := range jobs {
+		job.hour, job.minute, _ = parseClock(job.at)
+	}
+	return jobs
+}
+
+// LaunchScheduler 启动定时任务：每天在配置的本地时间生成前一天的日报、备份数据库，ctx 取消后退出
+// 启动时如果今天的运行时间已过而上一次运行被错过（日报不存在、24 小时内没有快照），会立即补运行
+func LaunchScheduler(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	jobs := scheduledJobs()
+	if len(jobs) == 0 {
+		log.Info("[Startup] Scheduler has no jobs, exiting")
+		return
+	}
+
+	now := time.Now()
+	local := now.In(model.GetLocation())
+	for _, job := range jobs {
+		log.Info(fmt.Sprintf("[Startup] Scheduled %s at %s %s", job.name, job.at, model.GetLocation()))
+		todayRun := time.Date(local.Year(), local.Month(), local.Day(), job.hour, job.minute, 0, 0, model.GetLocation())
+		if !now.Before(todayRun) && job.missed(now) {
+			job.run(now)
+		}
+	}
+
+	for {
+		now := time.Now()
+		var next time.Time
+		for _, job := range jobs {
+			if t := nextDailyRun(now, job.hour, job.minute); next.IsZero() || t.Before(next) {
+				next = t
+			}
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			log.Info("Scheduler received stop signal, exiting.")
+			return
+		case t := <-timer.C:
+			for _, job := range jobs {
+				if nextDailyRun(now, job.hour, job.minute).Equal(next) {
+					job.run(t)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blacksheepaul/timelog/core/config"
+	"github.com/blacksheepaul/timelog/model"
+)
+
+func TestNextDailyRun(t *testing.T) {
+	loc := model.GetLocation()
+
+	before := time.Date(2026, 10, 18, 3, 0, 0, 0, loc)
+	if got := nextDailyRun(before, 4, 0); !got.Equal(time.Date(2026, 10, 18, 4, 0, 0, 0, loc)) {
+		t.Errorf("Expected run later today, got %s", got)
+	}
+
+	at := time.Date(2026, 10, 18, 4, 0, 0, 0, loc)
+	if got := nextDailyRun(at, 4, 0); !got.Equal(time.Date(2026, 10, 19, 4, 0, 0, 0, loc)) {
+		t.Errorf("Expected run tomorrow when exactly at run time, got %s", got)
+	}
+}
+
+func TestPreviousReportDate(t *testing.T) {
+	now := time.Date(2026, 10, 18, 4, 0, 0, 0, model.GetLocation())
+	if got := previousReportDate(now); got != "2026-10-17" {
+		t.Errorf("Expected 2026-10-17, got %s", got)
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	if h, m, err := parseClock("04:30"); err != nil || h != 4 || m != 30 {
+		t.Errorf("Expected 04:30, got %d:%d (%v)", h, m, err)
+	}
+	if _, _, err := parseClock("4am"); err == nil {
+		t.Error("Expected invalid clock to be rejected")
+	}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	var cfg config.Config
+	cfg.Backup.DailyAt = "3:30am"
+	if err := ValidateSchedule(&cfg); err != nil {
+		t.Errorf("A disabled job should not be validated, got %v", err)
+	}
+	cfg.Report.Enabled, cfg.Backup.Enabled = true, true
+	cfg.Report.DailyAt, cfg.Backup.DailyAt = "04:00", "03:30"
+	if err := ValidateSchedule(&cfg); err != nil {
+		t.Errorf("Expected valid schedule, got %v", err)
+	}
+	cfg.Backup.DailyAt = "3:30am"
+	if err := ValidateSchedule(&cfg); err == nil || !strings.Contains(err.Error(), "backup.daily_at") {
+		t.Errorf("Expected invalid backup.daily_at to be rejected, got %v", err)
+	}
+}
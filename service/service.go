@@ -8,13 +8,23 @@ import (
 var log logger.Logger
 var cfg *config.Config
 
+// InitService 初始化服务层，配置中的重叠检测策略无效时拒绝启动
 func InitService(loggerInstance logger.Logger, config *config.Config) {
+	if config != nil {
+		if err := ValidateOverlapPolicy(config.Timelog.OverlapPolicy); err != nil {
+			panic(err)
+		}
+		if err := ValidateSchedule(config); err != nil {
+			panic(err)
+		}
+	}
 	log = loggerInstance
 	cfg = config
 }
 
 type Response struct {
-	Items []any `json:"items"`
+	Items      []any  `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
 	Pages
 }
 
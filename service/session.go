@@ -0,0 +1,163 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+)
+
+const (
+	// sessionTouchInterval 两次更新会话最近活动时间的最小间隔，避免每个请求都写数据库
+	sessionTouchInterval = time.Minute
+	// sessionCacheTTL 会话在内存缓存中的有效期，过期后重新从数据库加载
+	sessionCacheTTL = 10 * time.Minute
+	// defaultSessionTTL 未配置 passkey.token_ttl 时的会话有效期
+	defaultSessionTTL = 24 * time.Hour
+)
+
+var ErrInvalidSessionToken = errors.New("invalid or expired token")
+
+// SessionMeta 创建或使用会话时的客户端信息
+type SessionMeta struct {
+	DeviceLabel string
+	IP          string
+	UserAgent   string
+}
+
+// cachedSession 缓存中的会话状态，键为 "auth_token:"+token
+type cachedSession struct {
+	id         uint
+	lastSeenAt time.Time
+	expiresAt  time.Time
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func authTokenCacheKey(token string) string {
+	return "auth_token:" + token
+}
+
+// authSessionCacheKey 会话ID到令牌的反向索引，用于撤销其他设备的会话时清除缓存
+func authSessionCacheKey(id uint) string {
+	return fmt.Sprintf("auth_session:%d", id)
+}
+
+// sessionTTL 会话的滑动有效期，每次使用后从当前时间重新计算
+func sessionTTL() time.Duration {
+	if cfg == nil || cfg.Passkey.TokenTTL <= 0 {
+		return defaultSessionTTL
+	}
+	return time.Duration(cfg.Passkey.TokenTTL) * time.Second
+}
+
+func cacheSession(token string, s cachedSession) {
+	dao := model.GetDao()
+	dao.WriteCache(authTokenCacheKey(token), s, int64(sessionCacheTTL/time.Second))
+	dao.WriteCache(authSessionCacheKey(s.id), token, int64(sessionCacheTTL/time.Second))
+}
+
+func uncacheSession(token string, id uint) {
+	dao := model.GetDao()
+	dao.DeleteCache(authTokenCacheKey(token))
+	dao.DeleteCache(authSessionCacheKey(id))
+}
+
+// CreateSession 保存登录会话，数据库只保存令牌的哈希，同时清理已过期的会话
+func CreateSession(token string, ttlSeconds int64, meta SessionMeta) (*model.Session, error) {
+	now := nowUTC()
+	session := &model.Session{
+		TokenHash:   hashToken(token),
+		DeviceLabel: meta.DeviceLabel,
+		IP:          meta.IP,
+		UserAgent:   meta.UserAgent,
+		CreatedAt:   now,
+		LastSeenAt:  now,
+		ExpiresAt:   now.Add(time.Duration(ttlSeconds) * time.Second),
+	}
+	db := model.GetDao().Db()
+	if err := model.CreateSession(db, session); err != nil {
+		return nil, err
+	}
+	if err := model.DeleteExpiredSessions(db, now); err != nil && log != nil {
+		log.Errorw("Failed to clean up expired sessions", "error", err)
+	}
+
+	cacheSession(token, cachedSession{id: session.ID, lastSeenAt: now, expiresAt: session.ExpiresAt})
+	return session, nil
+}
+
+// StoreSessionToken 保存没有客户端信息的登录会话
+func StoreSessionToken(token string, ttlSeconds int64) error {
+	_, err := CreateSession(token, ttlSeconds, SessionMeta{})
+	return err
+}
+
+// ValidateSessionToken 校验令牌并返回会话ID，会话有效期随使用滑动延长
+// 会话状态缓存在内存中，数据库中的最近活动时间每分钟最多更新一次
+func ValidateSessionToken(token string, meta SessionMeta) (uint, error) {
+	dao := model.GetDao()
+	now := nowUTC()
+
+	var s cachedSession
+	if raw, ok := dao.GetCache(authTokenCacheKey(token)); ok {
+		cached, ok := raw.(cachedSession)
+		if !ok {
+			return 0, ErrInvalidSessionToken
+		}
+		s = cached
+	} else {
+		session, err := model.GetSessionByTokenHash(dao.Db(), hashToken(token), now)
+		if errors.Is(err, model.ErrRecordNotFound) {
+			return 0, ErrInvalidSessionToken
+		}
+		if err != nil {
+			return 0, err
+		}
+		s = cachedSession{id: session.ID, lastSeenAt: session.LastSeenAt, expiresAt: session.ExpiresAt}
+	}
+
+	if !now.Before(s.expiresAt) {
+		uncacheSession(token, s.id)
+		return 0, ErrInvalidSessionToken
+	}
+	if now.Sub(s.lastSeenAt) >= sessionTouchInterval {
+		expiresAt := now.Add(sessionTTL())
+		err := model.TouchSession(dao.Db(), s.id, now, expiresAt, meta.IP, meta.UserAgent)
+		if errors.Is(err, model.ErrRecordNotFound) {
+			uncacheSession(token, s.id)
+			return 0, ErrInvalidSessionToken
+		}
+		if err != nil {
+			return 0, err
+		}
+		s.lastSeenAt, s.expiresAt = now, expiresAt
+	}
+	cacheSession(token, s)
+	return s.id, nil
+}
+
+// ListSessions 列出未过期的登录会话
+func ListSessions() ([]model.Session, error) {
+	return model.ListSessions(model.GetDao().Db(), nowUTC())
+}
+
+// RevokeSession 撤销会话，之后使用该会话令牌的请求会被拒绝
+func RevokeSession(id uint) error {
+	dao := model.GetDao()
+	if err := model.DeleteSession(dao.Db(), id); err != nil {
+		return err
+	}
+	if raw, ok := dao.GetCache(authSessionCacheKey(id)); ok {
+		if token, ok := raw.(string); ok {
+			uncacheSession(token, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+)
+
+func TestSessionSurvivesCacheLoss(t *testing.T) {
+	dao := setupTestModel()
+
+	token := "session-survives-restart"
+	session, err := CreateSession(token, 300, SessionMeta{DeviceLabel: "laptop", IP: "10.0.0.1"})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if session.TokenHash == token || session.TokenHash != hashToken(token) {
+		t.Error("Expected only the token hash to be stored")
+	}
+
+	// 模拟重启：内存缓存丢失后仍能通过数据库校验
+	dao.DeleteCache(authTokenCacheKey(token))
+	id, err := ValidateSessionToken(token, SessionMeta{})
+	if err != nil || id != session.ID {
+		t.Fatalf("Expected session %d to be valid after cache loss, got %d (%v)", session.ID, id, err)
+	}
+
+	if _, err := ValidateSessionToken("unknown-token", SessionMeta{}); !errors.Is(err, ErrInvalidSessionToken) {
+		t.Errorf("Expected unknown token to be rejected, got %v", err)
+	}
+}
+
+func TestSessionSlidingExpiry(t *testing.T) {
+	dao := setupTestModel()
+
+	token := "session-sliding-expiry"
+	session, err := CreateSession(token, 300, SessionMeta{})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	// 上次活动在两分钟前，本次使用应延长有效期并记录客户端信息
+	past := nowUTC().Add(-2 * time.Minute)
+	dao.WriteCache(authTokenCacheKey(token), cachedSession{id: session.ID, lastSeenAt: past, expiresAt: session.ExpiresAt}, 60)
+	if _, err := ValidateSessionToken(token, SessionMeta{IP: "10.0.0.2", UserAgent: "test-agent"}); err != nil {
+		t.Fatalf("Expected session to be valid: %v", err)
+	}
+
+	stored, err := model.GetSessionByTokenHash(dao.Db(), hashToken(token), nowUTC())
+	if err != nil {
+		t.Fatalf("Failed to load session: %v", err)
+	}
+	if !stored.ExpiresAt.After(session.ExpiresAt) {
+		t.Errorf("Expected expiry to slide past %s, got %s", session.ExpiresAt, stored.ExpiresAt)
+	}
+	if stored.IP != "10.0.0.2" || stored.UserAgent != "test-agent" {
+		t.Errorf("Expected client info to be updated, got %q %q", stored.IP, stored.UserAgent)
+	}
+
+	// 过期的会话被拒绝
+	dao.WriteCache(authTokenCacheKey(token), cachedSession{id: session.ID, lastSeenAt: past, expiresAt: past}, 60)
+	if _, err := ValidateSessionToken(token, SessionMeta{}); !errors.Is(err, ErrInvalidSessionToken) {
+		t.Errorf("Expected expired session to be rejected, got %v", err)
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	setupTestModel()
+
+	token := "session-to-revoke"
+	session, err := CreateSession(token, 300, SessionMeta{})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if _, err := ValidateSessionToken(token, SessionMeta{}); err != nil {
+		t.Fatalf("Expected session to be valid: %v", err)
+	}
+
+	if err := RevokeSession(session.ID); err != nil {
+		t.Fatalf("Failed to revoke session: %v", err)
+	}
+	if _, err := ValidateSessionToken(token, SessionMeta{}); !errors.Is(err, ErrInvalidSessionToken) {
+		t.Errorf("Expected revoked session to be rejected, got %v", err)
+	}
+	if err := RevokeSession(session.ID); !errors.Is(err, model.ErrRecordNotFound) {
+		t.Errorf("Expected revoking twice to return not found, got %v", err)
+	}
+
+	sessions, err := ListSessions()
+	if err != nil {
+		t.Fatalf("Failed to list sessions: %v", err)
+	}
+	for _, s := range sessions {
+		if s.ID == session.ID {
+			t.Error("Expected revoked session to be removed from list")
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/model/gen"
+)
+
+// 统计的时间粒度
+const (
+	StatsPeriodDay   = "day"
+	StatsPeriodWeek  = "week"
+	StatsPeriodMonth = "month"
+	StatsPeriodYear  = "year"
+)
+
+// maxStatsPeriods 单次统计最多返回的周期数，避免按天统计过长的日期范围
+const maxStatsPeriods = 1000
+
+var ErrInvalidStatsQuery = errors.New("invalid stats query")
+
+// CategoryStat 某个分类在一个时间段内的时长，TotalMinutes 包含所有子分类
+type CategoryStat struct {
+	CategoryID   int32           `json:"category_id"`
+	Name         string          `json:"name"`
+	Path         string          `json:"path"`
+	Color        *string         `json:"color"`
+	OwnMinutes   float64         `json:"own_minutes"`
+	TotalMinutes float64         `json:"total_minutes"`
+	Percentage   float64         `json:"percentage"`
+	Children     []*CategoryStat `json:"children,omitempty"`
+}
+
+// PeriodStats 一个统计周期（天/周/月/年）内的汇总
+type PeriodStats struct {
+	Period       string          `json:"period"`
+	Start        time.Time       `json:"start"`
+	End          time.Time       `json:"end"`
+	TotalMinutes float64         `json:"total_minutes"`
+	Categories   []*CategoryStat `json:"categories"`
+}
+
+// StatsReport 统计结果，Categories 为整个日期范围的汇总，Periods 按粒度拆分
+type StatsReport struct {
+	From         string          `json:"from"`
+	To           string          `json:"to"`
+	Period       string          `json:"period"`
+	Timezone     string          `json:"timezone"`
+	TotalMinutes float64         `json:"total_minutes"`
+	Categories   []*CategoryStat `json:"categories"`
+	Periods      []*PeriodStats  `json:"periods"`
+}
+
+// statsWindow 一个统计周期的时间窗口及其中每个分类自身的时长
+type statsWindow struct {
+	key     string
+	start   time.Time
+	end     time.Time
+	minutes map[int32]float64
+}
+
+// periodStartDate 返回 date 所在统计周期的第一天，周以周一开始
+func periodStartDate(date time.Time, period string) time.Time {
+	switch period {
+	case StatsPeriodWeek:
+		return date.AddDate(0, 0, -((int(date.Weekday()) + 6) % 7))
+	case StatsPeriodMonth:
+		return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+	case StatsPeriodYear:
+		return time.Date(date.Year(), 1, 1, 0, 0, 0, 0, date.Location())
+	default:
+		return date
+	}
+}
+
+// nextPeriodDate 返回下一个统计周期的第一天
+func nextPeriodDate(date time.Time, period string) time.Time {
+	switch period {
+	case StatsPeriodWeek:
+		return date.AddDate(0, 0, 7)
+	case StatsPeriodMonth:
+		return date.AddDate(0, 1, 0)
+	case StatsPeriodYear:
+		return date.AddDate(1, 0, 0)
+	default:
+		return date.AddDate(0, 0, 1)
+	}
+}
+
+// periodKey 返回统计周期的标识，如 2026-10-17、2026-W42、2026-10、2026
+func periodKey(date time.Time, period string) string {
+	switch period {
+	case StatsPeriodWeek:
+		year, week := date.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case StatsPeriodMonth:
+		return date.Format("2006-01")
+	case StatsPeriodYear:
+		return date.Format("2006")
+	default:
+		return date.Format("2006-01-02")
+	}
+}
+
+// buildStatsWindows 将 [from, to] 两个本地日期之间按粒度切分为时间窗口，首尾窗口截断到日期范围内
+// 周期数超过 maxStatsPeriods 时返回 nil
+func buildStatsWindows(from, to time.Time, period string) []*statsWindow {
+	rangeStart, _ := model.LocalDayBounds(from)
+	_, rangeEnd := model.LocalDayBounds(to)
+
+	var windows []*statsWindow
+	for date := periodStartDate(from, period); !date.After(to); date = nextPeriodDate(date, period) {
+		if len(windows) == maxStatsPeriods {
+			return nil
+		}
+		start, _ := model.LocalDayBounds(date)
+		end, _ := model.LocalDayBounds(nextPeriodDate(date, period))
+		if start.Before(rangeStart) {
+			start = rangeStart
+		}
+		if end.After(rangeEnd) {
+			end = rangeEnd
+		}
+		windows = append(windows, &statsWindow{
+			key:     periodKey(date, period),
+			start:   start,
+			end:     end,
+			minutes: map[int32]float64{},
+		})
+	}
+	return windows
+}
+
+// accumulateTimeLogs 将时间日志按窗口切分累加，跨越窗口边界的日志按时长拆分，未结束的日志计算到 now
+func accumulateTimeLogs(windows []*statsWindow, tls []gen.Timelog, now time.Time) {
+	for _, tl := range tls {
+		start := tl.StartTime
+		end := now
+		if tl.EndTime != nil {
+			end = *tl.EndTime
+		}
+		if !end.After(start) {
+			continue
+		}
+
+		// 第一个结束时间晚于日志开始时间的窗口
+		i := sort.Search(len(windows), func(i int) bool {
+			return windows[i].end.After(start)
+		})
+		for ; i < len(windows) && windows[i].start.Before(end); i++ {
+			s, e := start, end
+			if s.Before(windows[i].start) {
+				s = windows[i].start
+			}
+			if e.After(windows[i].end) {
+				e = windows[i].end
+			}
+			if e.After(s) {
+				windows[i].minutes[tl.CategoryID] += e.Sub(s).Minutes()
+			}
+		}
+	}
+}
+
+// rollupCategoryStats 按分类树汇总时长，父分类的 TotalMinutes 包含所有后代，时长为 0 的分支不返回
+// 不在分类树中的分类（例如已删除）作为根节点单独列出
+func rollupCategoryStats(roots []*model.CategoryNode, minutes map[int32]float64) ([]*CategoryStat, float64) {
+	seen := map[int32]bool{}
+
+	var build func(node *model.CategoryNode) *CategoryStat
+	build = func(node *model.CategoryNode) *CategoryStat {
+		id := *node.Category.ID
+		seen[id] = true
+		stat := &CategoryStat{
+			CategoryID: id,
+			Name:       node.Category.Name,
+			Path:       model.GetFullPath(&node.Category),
+			Color:      node.Category.Color,
+			OwnMinutes: minutes[id],
+		}
+		stat.TotalMinutes = stat.OwnMinutes
+		for _, child := range node.Children {
+			if childStat := build(child); childStat != nil {
+				stat.Children = append(stat.Children, childStat)
+				stat.TotalMinutes += childStat.TotalMinutes
+			}
+		}
+		if stat.TotalMinutes == 0 {
+			return nil
+		}
+		return stat
+	}
+
+	result := []*CategoryStat{}
+	total := float64(0)
+	for _, root := range roots {
+		if stat := build(root); stat != nil {
+			result = append(result, stat)
+			total += stat.TotalMinutes
+		}
+	}
+
+	var orphanIDs []int32
+	for id, m := range minutes {
+		if !seen[id] && m > 0 {
+			orphanIDs = append(orphanIDs, id)
+		}
+	}
+	sort.Slice(orphanIDs, func(i, j int) bool { return orphanIDs[i] < orphanIDs[j] })
+	for _, id := range orphanIDs {
+		result = append(result, &CategoryStat{
+			CategoryID:   id,
+			Name:         fmt.Sprintf("#%d", id),
+			Path:         fmt.Sprintf("#%d", id),
+			OwnMinutes:   minutes[id],
+			TotalMinutes: minutes[id],
+		})
+		total += minutes[id]
+	}
+
+	setPercentages(result, total)
+	return result, total
+}
+
+// setPercentages 设置每个分类占 total 的百分比
+func setPercentages(stats []*CategoryStat, total float64) {
+	for _, stat := range stats {
+		if total > 0 {
+			stat.Percentage = stat.TotalMinutes / total * 100
+		}
+		setPercentages(stat.Children, total)
+	}
+}
+
+// GetStats 统计 [fromStr, toStr] 本地日期范围内各分类的时长，按 period 拆分为天/周/月/年
+// 一天从配置的 day_starts_at 开始，跨越边界的日志按时长拆分到各个周期
+func GetStats(fromStr, toStr, period string) (*StatsReport, error) {
+	switch period {
+	case "":
+		period = StatsPeriodDay
+	case StatsPeriodDay, StatsPeriodWeek, StatsPeriodMonth, StatsPeriodYear:
+	default:
+		return nil, fmt.Errorf("%w: period must be day, week, month or year", ErrInvalidStatsQuery)
+	}
+
+	from, err := model.ParseLocalDate(fromStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: from must be YYYY-MM-DD", ErrInvalidStatsQuery)
+	}
+	to, err := model.ParseLocalDate(toStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: to must be YYYY-MM-DD", ErrInvalidStatsQuery)
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: from must not be after to", ErrInvalidStatsQuery)
+	}
+
+	windows := buildStatsWindows(from, to, period)
+	if windows == nil {
+		return nil, fmt.Errorf("%w: date range spans more than %d periods, use a coarser period", ErrInvalidStatsQuery, maxStatsPeriods)
+	}
+	rangeStart := windows[0].start
+	rangeEnd := windows[len(windows)-1].end
+
+	db := model.GetDao().Db()
+	tls, err := model.ListTimeLogsOverlapping(db, rangeStart, &rangeEnd, nil)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := model.GetCategoryTree(db)
+	if err != nil {
+		return nil, err
+	}
+
+	accumulateTimeLogs(windows, tls, time.Now())
+
+	report := &StatsReport{
+		From:     fromStr,
+		To:       toStr,
+		Period:   period,
+		Timezone: model.GetLocation().String(),
+		Periods:  make([]*PeriodStats, 0, len(windows)),
+	}
+	overall := map[int32]float64{}
+	for _, w := range windows {
+		categories, total := rollupCategoryStats(tree, w.minutes)
+		report.Periods = append(report.Periods, &PeriodStats{
+			Period:       w.key,
+			Start:        w.start,
+			End:          w.end,
+			TotalMinutes: total,
+			Categories:   categories,
+		})
+		for id, m := range w.minutes {
+			overall[id] += m
+		}
+	}
+	report.Categories, report.TotalMinutes = rollupCategoryStats(tree, overall)
+	return report, nil
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/model/gen"
+)
+
+func int32Ptr(v int32) *int32 { return &v }
+
+func TestBuildStatsWindowsClipsToRange(t *testing.T) {
+	from, _ := model.ParseLocalDate("2026-10-14") // 周三
+	to, _ := model.ParseLocalDate("2026-10-20")   // 下周二
+
+	windows := buildStatsWindows(from, to, StatsPeriodWeek)
+	if len(windows) != 2 {
+		t.Fatalf("Expected 2 weekly windows, got %d", len(windows))
+	}
+	if windows[0].key != "2026-W42" || windows[1].key != "2026-W43" {
+		t.Errorf("Unexpected window keys: %s, %s", windows[0].key, windows[1].key)
+	}
+	rangeStart, _ := model.LocalDayBounds(from)
+	_, rangeEnd := model.LocalDayBounds(to)
+	if !windows[0].start.Equal(rangeStart) || !windows[1].end.Equal(rangeEnd) {
+		t.Errorf("Expected windows clipped to %s - %s, got %s - %s", rangeStart, rangeEnd, windows[0].start, windows[1].end)
+	}
+
+	if days := buildStatsWindows(from, to, StatsPeriodDay); len(days) != 7 {
+		t.Errorf("Expected 7 daily windows, got %d", len(days))
+	}
+}
+
+func TestAccumulateTimeLogsSplitsAcrossWindows(t *testing.T) {
+	from, _ := model.ParseLocalDate("2026-10-17")
+	to, _ := model.ParseLocalDate("2026-10-18")
+	windows := buildStatsWindows(from, to, StatsPeriodDay)
+	_, firstEnd := model.LocalDayBounds(from)
+
+	// 跨越午夜的日志：前一天 30 分钟，后一天 60 分钟
+	start := firstEnd.Add(-30 * time.Minute)
+	end := firstEnd.Add(60 * time.Minute)
+	tls := []gen.Timelog{{StartTime: start, EndTime: &end, CategoryID: 1}}
+
+	accumulateTimeLogs(windows, tls, end)
+	if windows[0].minutes[1] != 30 || windows[1].minutes[1] != 60 {
+		t.Errorf("Expected 30/60 minutes split, got %v/%v", windows[0].minutes[1], windows[1].minutes[1])
+	}
+}
+
+func TestRollupCategoryStats(t *testing.T) {
+	root := &model.CategoryNode{Category: gen.Category{ID: int32Ptr(1), Name: "Work"}}
+	child := &model.CategoryNode{Category: gen.Category{ID: int32Ptr(2), Name: "Coding", ParentID: int32Ptr(1)}}
+	idle := &model.CategoryNode{Category: gen.Category{ID: int32Ptr(3), Name: "Idle", ParentID: int32Ptr(1)}}
+	root.Children = []*model.CategoryNode{child, idle}
+	other := &model.CategoryNode{Category: gen.Category{ID: int32Ptr(4), Name: "Rest"}}
+
+	stats, total := rollupCategoryStats([]*model.CategoryNode{root, other}, map[int32]float64{1: 10, 2: 50, 4: 20, 99: 20})
+	if total != 100 {
+		t.Fatalf("Expected total 100, got %v", total)
+	}
+	if len(stats) != 3 {
+		t.Fatalf("Expected Work, Rest and the unknown category, got %d entries", len(stats))
+	}
+
+	work := stats[0]
+	if work.TotalMinutes != 60 || work.OwnMinutes != 10 || math.Abs(work.Percentage-60) > 1e-9 {
+		t.Errorf("Unexpected Work rollup: %+v", work)
+	}
+	if len(work.Children) != 1 || work.Children[0].CategoryID != 2 || work.Children[0].Percentage != 50 {
+		t.Errorf("Expected only the non-empty child to be reported, got %+v", work.Children)
+	}
+	if stats[2].CategoryID != 99 || stats[2].Percentage != 20 {
+		t.Errorf("Expected unknown category to be reported as a root, got %+v", stats[2])
+	}
+}
@@ -7,9 +7,12 @@ import (
 	"github.com/blacksheepaul/timelog/model/gen"
 )
 
-// CreateTask 创建任务
+// CreateTask 创建任务，不能创建在已归档的分类下
 func CreateTask(task *gen.Task) error {
 	dao := model.GetDao()
+	if err := ensureCategoryActive(dao.Db(), task.CategoryID); err != nil {
+		return err
+	}
 	return model.CreateTask(dao.Db(), task)
 }
 
@@ -41,9 +44,20 @@ func GetTasksByDateRange(startDate, endDate time.Time) ([]gen.Task, error) {
 	return model.GetTasksByDateRange(dao.Db(), startDate, endDate)
 }
 
-// UpdateTask 更新任务
+// UpdateTask 更新任务，不能把任务改到已归档的分类下
 func UpdateTask(task *gen.Task) error {
 	dao := model.GetDao()
+	if task.ID != nil {
+		existing, err := model.GetTaskByID(dao.Db(), *task.ID)
+		if err != nil {
+			return err
+		}
+		if existing.CategoryID != task.CategoryID {
+			if err := ensureCategoryActive(dao.Db(), task.CategoryID); err != nil {
+				return err
+			}
+		}
+	}
 	return model.UpdateTask(dao.Db(), task)
 }
 
@@ -83,10 +97,33 @@ func GetCompletedTasksInDateRange(startDate, endDate time.Time) ([]gen.Task, err
 	return model.GetCompletedTasksInDateRange(dao.Db(), startDate, endDate)
 }
 
-// GetTaskStats 获取任务统计信息
+// GetTaskStats 获取任务统计信息，包含当天任务的预估时长、实际用时和偏差
 func GetTaskStats(date time.Time) (map[string]interface{}, error) {
 	dao := model.GetDao()
-	return model.GetTaskStats(dao.Db(), date)
+	stats, err := model.GetTaskStats(dao.Db(), date)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := model.GetTasksByDate(dao.Db(), date, true, true)
+	if err != nil {
+		return nil, err
+	}
+	withActual, err := AttachTaskActuals(tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	estimated, actual := float64(0), float64(0)
+	for _, t := range withActual {
+		estimated += float64(t.EstimatedMinutes)
+		actual += t.ActualMinutes
+	}
+	stats["estimated_minutes"] = estimated
+	stats["actual_minutes"] = actual
+	stats["variance_minutes"] = actual - estimated
+	stats["tasks"] = withActual
+	return stats, nil
 }
 
 // CompleteTaskWithTimelog 完成任务并创建时间记录
@@ -112,6 +149,15 @@ func CompleteTaskWithTimelog(taskID int32, createTimelog bool, timelogData *gen.
 	// 如果需要创建时间记录
 	if createTimelog && timelogData != nil {
 		timelogData.TaskID = &taskID
+		normalizeTimeLog(timelogData)
+		if err := ensureCategoryActive(tx.Db(), timelogData.CategoryID); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := ValidateTimeLog(tx.Db(), timelogData); err != nil {
+			tx.Rollback()
+			return err
+		}
 		if err := model.CreateTimeLog(tx.Db(), timelogData); err != nil {
 			tx.Rollback()
 			return err
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/model/gen"
+)
+
+// estimateOnTargetPercent 实际用时与预估相差在该百分比以内视为预估准确
+const estimateOnTargetPercent = 20
+
+// TaskWithActual 任务及其关联时间日志的实际用时
+type TaskWithActual struct {
+	gen.Task
+	ActualMinutes   float64  `json:"actual_minutes"`
+	VarianceMinutes *float64 `json:"variance_minutes"` // 实际 - 预估，未预估时为空
+	VariancePercent *float64 `json:"variance_percent"` // 偏差占预估的百分比，未预估时为空
+}
+
+func newTaskWithActual(task gen.Task, actual float64) TaskWithActual {
+	t := TaskWithActual{Task: task, ActualMinutes: actual}
+	if task.EstimatedMinutes > 0 {
+		variance := actual - float64(task.EstimatedMinutes)
+		percent := variance / float64(task.EstimatedMinutes) * 100
+		t.VarianceMinutes = &variance
+		t.VariancePercent = &percent
+	}
+	return t
+}
+
+// AttachTaskActuals 为任务列表附加实际用时与偏差
+func AttachTaskActuals(tasks []gen.Task) ([]TaskWithActual, error) {
+	ids := make([]int32, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, *task.ID)
+	}
+	actuals, err := model.SumTrackedMinutesByTask(model.GetDao().Db(), ids, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]TaskWithActual, 0, len(tasks))
+	for _, task := range tasks {
+		result = append(result, newTaskWithActual(task, actuals[*task.ID]))
+	}
+	return result, nil
+}
+
+// GetTaskWithActualByID 获取任务及其实际用时
+func GetTaskWithActualByID(id int32) (*TaskWithActual, error) {
+	task, err := GetTaskByID(id)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := AttachTaskActuals([]gen.Task{*task})
+	if err != nil {
+		return nil, err
+	}
+	return &tasks[0], nil
+}
+
+// EstimateAccuracy 一组已完成任务的预估准确度
+type EstimateAccuracy struct {
+	TaskCount        int     `json:"task_count"`
+	OnTargetCount    int     `json:"on_target_count"` // 偏差在 ±20% 以内的任务数
+	EstimatedMinutes float64 `json:"estimated_minutes"`
+	ActualMinutes    float64 `json:"actual_minutes"`
+	VarianceMinutes  float64 `json:"variance_minutes"`
+	// Ratio 实际/预估，大于 1 表示普遍低估
+	Ratio float64 `json:"ratio"`
+	// MeanAbsPercentError 每个任务偏差百分比绝对值的平均值
+	MeanAbsPercentError float64 `json:"mean_abs_percent_error"`
+
+	absPercentSum float64
+}
+
+func (a *EstimateAccuracy) add(t TaskWithActual) {
+	a.TaskCount++
+	a.EstimatedMinutes += float64(t.EstimatedMinutes)
+	a.ActualMinutes += t.ActualMinutes
+	abs := math.Abs(*t.VariancePercent)
+	a.absPercentSum += abs
+	if abs <= estimateOnTargetPercent {
+		a.OnTargetCount++
+	}
+}
+
+func (a *EstimateAccuracy) finish() {
+	a.VarianceMinutes = a.ActualMinutes - a.EstimatedMinutes
+	if a.EstimatedMinutes > 0 {
+		a.Ratio = a.ActualMinutes / a.EstimatedMinutes
+	}
+	if a.TaskCount > 0 {
+		a.MeanAbsPercentError = a.absPercentSum / float64(a.TaskCount)
+	}
+}
+
+// CategoryEstimateAccuracy 某个分类的预估准确度
+type CategoryEstimateAccuracy struct {
+	CategoryID int32  `json:"category_id"`
+	Name       string `json:"name"`
+	Path       string `json:"path"`
+	EstimateAccuracy
+}
+
+// PeriodEstimateAccuracy 某个统计周期的预估准确度
+type PeriodEstimateAccuracy struct {
+	Period string `json:"period"`
+	EstimateAccuracy
+	Categories []*CategoryEstimateAccuracy `json:"categories"`
+}
+
+// EstimateAccuracyReport 预估准确度报告，只统计有预估时长且在日期范围内完成的任务
+type EstimateAccuracyReport struct {
+	From       string                      `json:"from"`
+	To         string                      `json:"to"`
+	Period     string                      `json:"period"`
+	Overall    EstimateAccuracy            `json:"overall"`
+	Categories []*CategoryEstimateAccuracy `json:"categories"`
+	Periods    []*PeriodEstimateAccuracy   `json:"periods"`
+}
+
+// categoryAccuracyGroup 按分类累加预估准确度，保持分类ID顺序稳定
+type categoryAccuracyGroup map[int32]*CategoryEstimateAccuracy
+
+func (g categoryAccuracyGroup) add(t TaskWithActual, categories map[int32]gen.Category) {
+	entry, ok := g[t.CategoryID]
+	if !ok {
+		entry = &CategoryEstimateAccuracy{CategoryID: t.CategoryID, Name: fmt.Sprintf("#%d", t.CategoryID), Path: fmt.Sprintf("#%d", t.CategoryID)}
+		if cat, found := categories[t.CategoryID]; found {
+			entry.Name = cat.Name
+			entry.Path = model.GetFullPath(&cat)
+		}
+		g[t.CategoryID] = entry
+	}
+	entry.add(t)
+}
+
+func (g categoryAccuracyGroup) list() []*CategoryEstimateAccuracy {
+	result := make([]*CategoryEstimateAccuracy, 0, len(g))
+	for _, entry := range g {
+		entry.finish()
+		result = append(result, entry)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CategoryID < result[j].CategoryID })
+	return result
+}
+
+// GetEstimateAccuracy 统计 [fromStr, toStr] 本地日期范围内完成的任务的预估准确度，按分类和周期拆分
+// 任务按完成时间所在的逻辑日期归入周期
+func GetEstimateAccuracy(fromStr, toStr, period string) (*EstimateAccuracyReport, error) {
+	switch period {
+	case "":
+		period = StatsPeriodWeek
+	case StatsPeriodDay, StatsPeriodWeek, StatsPeriodMonth, StatsPeriodYear:
+	default:
+		return nil, fmt.Errorf("%w: period must be day, week, month or year", ErrInvalidStatsQuery)
+	}
+
+	from, err := model.ParseLocalDate(fromStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: from must be YYYY-MM-DD", ErrInvalidStatsQuery)
+	}
+	to, err := model.ParseLocalDate(toStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: to must be YYYY-MM-DD", ErrInvalidStatsQuery)
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: from must not be after to", ErrInvalidStatsQuery)
+	}
+	rangeStart, _ := model.LocalDayBounds(from)
+	_, rangeEnd := model.LocalDayBounds(to)
+
+	db := model.GetDao().Db()
+	completed, err := model.GetCompletedTasksInDateRange(db, rangeStart.UTC(), rangeEnd.UTC())
+	if err != nil {
+		return nil, err
+	}
+	var estimated []gen.Task
+	for _, task := range completed {
+		if task.EstimatedMinutes > 0 && task.CompletedAt.Before(rangeEnd) {
+			estimated = append(estimated, task)
+		}
+	}
+	tasks, err := AttachTaskActuals(estimated)
+	if err != nil {
+		return nil, err
+	}
+
+	categoryList, err := model.ListCategories(db)
+	if err != nil {
+		return nil, err
+	}
+	categories := make(map[int32]gen.Category, len(categoryList))
+	for _, cat := range categoryList {
+		categories[*cat.ID] = cat
+	}
+
+	report := &EstimateAccuracyReport{From: fromStr, To: toStr, Period: period}
+	overallCategories := categoryAccuracyGroup{}
+	periods := map[string]*PeriodEstimateAccuracy{}
+	periodCategories := map[string]categoryAccuracyGroup{}
+	var periodKeys []string
+
+	for _, t := range tasks {
+		report.Overall.add(t)
+		overallCategories.add(t, categories)
+
+		key := periodKey(periodStartDate(model.LocalDateOf(*t.CompletedAt), period), period)
+		if _, ok := periods[key]; !ok {
+			periods[key] = &PeriodEstimateAccuracy{Period: key}
+			periodCategories[key] = categoryAccuracyGroup{}
+			periodKeys = append(periodKeys, key)
+		}
+		periods[key].add(t)
+		periodCategories[key].add(t, categories)
+	}
+
+	report.Overall.finish()
+	report.Categories = overallCategories.list()
+	sort.Strings(periodKeys)
+	report.Periods = make([]*PeriodEstimateAccuracy, 0, len(periodKeys))
+	for _, key := range periodKeys {
+		p := periods[key]
+		p.finish()
+		p.Categories = periodCategories[key].list()
+		report.Periods = append(report.Periods, p)
+	}
+	return report, nil
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/blacksheepaul/timelog/model/gen"
+)
+
+func TestNewTaskWithActualVariance(t *testing.T) {
+	withEstimate := newTaskWithActual(gen.Task{EstimatedMinutes: 60}, 90)
+	if withEstimate.VarianceMinutes == nil || *withEstimate.VarianceMinutes != 30 {
+		t.Errorf("Expected variance of 30 minutes, got %v", withEstimate.VarianceMinutes)
+	}
+	if withEstimate.VariancePercent == nil || *withEstimate.VariancePercent != 50 {
+		t.Errorf("Expected variance of 50%%, got %v", withEstimate.VariancePercent)
+	}
+
+	noEstimate := newTaskWithActual(gen.Task{}, 15)
+	if noEstimate.VarianceMinutes != nil || noEstimate.VariancePercent != nil {
+		t.Error("Expected no variance for a task without an estimate")
+	}
+	if noEstimate.ActualMinutes != 15 {
+		t.Errorf("Expected actual minutes to be kept, got %v", noEstimate.ActualMinutes)
+	}
+}
+
+func TestEstimateAccuracyAggregation(t *testing.T) {
+	var acc EstimateAccuracy
+	acc.add(newTaskWithActual(gen.Task{EstimatedMinutes: 100}, 110)) // +10%，预估准确
+	acc.add(newTaskWithActual(gen.Task{EstimatedMinutes: 100}, 50))  // -50%
+	acc.finish()
+
+	if acc.TaskCount != 2 || acc.OnTargetCount != 1 {
+		t.Errorf("Expected 2 tasks with 1 on target, got %d/%d", acc.TaskCount, acc.OnTargetCount)
+	}
+	if acc.EstimatedMinutes != 200 || acc.ActualMinutes != 160 || acc.VarianceMinutes != -40 {
+		t.Errorf("Unexpected totals: %+v", acc)
+	}
+	if acc.Ratio != 0.8 {
+		t.Errorf("Expected ratio 0.8, got %v", acc.Ratio)
+	}
+	if acc.MeanAbsPercentError != 30 {
+		t.Errorf("Expected mean absolute percent error 30, got %v", acc.MeanAbsPercentError)
+	}
+}
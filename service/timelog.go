@@ -1,16 +1,41 @@
 package service
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/blacksheepaul/timelog/model"
 	"github.com/blacksheepaul/timelog/model/gen"
+	"gorm.io/gorm"
 )
 
+// ErrCategoryArchived 不能在已归档的分类（或其子树）下新建时间日志、任务或子分类
+var ErrCategoryArchived = errors.New("category is archived")
+
 // --- TimeLog Service ---
 
 // CreateTimeLog 新增一条时间日志
+// 写入前校验时间范围与重叠；未结束的日志（end_time 为空）与计时器共用同一约束：同一时间最多只有一条。
+// 检查与写入在同一事务中，多个进程写同一数据库时由 SQLite 的写锁串行化
 func CreateTimeLog(tl *gen.Timelog) error {
-	db := model.GetDao().Db()
-	return model.CreateTimeLog(db, tl)
+	normalizeTimeLog(tl)
+
+	timerMu.Lock()
+	defer timerMu.Unlock()
+	return model.GetDao().Db().Transaction(func(tx *gorm.DB) error {
+		if err := ensureCategoryActive(tx, tl.CategoryID); err != nil {
+			return err
+		}
+		if tl.EndTime == nil {
+			if err := ensureNoOtherOpenTimeLog(tx, nil); err != nil {
+				return err
+			}
+		}
+		if err := ValidateTimeLog(tx, tl); err != nil {
+			return err
+		}
+		return model.CreateTimeLog(tx, tl)
+	})
 }
 
 // GetTimeLogByID 根据ID获取时间日志
@@ -32,9 +57,34 @@ func ListTimeLogsWithOptions(limit int, orderBy string, conds ...interface{}) ([
 }
 
 // UpdateTimeLog 更新一条时间日志
+// 已归档分类下的历史日志仍可编辑，但不能把日志改到已归档的分类下；检查与写入在同一事务中
 func UpdateTimeLog(tl *gen.Timelog) error {
-	db := model.GetDao().Db()
-	return model.UpdateTimeLog(db, tl)
+	normalizeTimeLog(tl)
+
+	timerMu.Lock()
+	defer timerMu.Unlock()
+	return model.GetDao().Db().Transaction(func(tx *gorm.DB) error {
+		if tl.ID != nil {
+			existing, err := model.GetTimeLogByID(tx, *tl.ID)
+			if err != nil {
+				return err
+			}
+			if existing.CategoryID != tl.CategoryID {
+				if err := ensureCategoryActive(tx, tl.CategoryID); err != nil {
+					return err
+				}
+			}
+		}
+		if tl.EndTime == nil {
+			if err := ensureNoOtherOpenTimeLog(tx, tl.ID); err != nil {
+				return err
+			}
+		}
+		if err := ValidateTimeLog(tx, tl); err != nil {
+			return err
+		}
+		return model.UpdateTimeLog(tx, tl)
+	})
 }
 
 // DeleteTimeLog 删除一条时间日志
@@ -48,6 +98,11 @@ func DeleteTimeLog(id int32) error {
 // CreateCategory 创建分类
 func CreateCategory(category *gen.Category) error {
 	db := model.GetDao().Db()
+	if category.ParentID != nil && *category.ParentID > 0 {
+		if err := ensureCategoryActive(db, *category.ParentID); err != nil {
+			return err
+		}
+	}
 	return model.CreateCategory(db, category)
 }
 
@@ -63,28 +118,67 @@ func GetCategoryByName(name string, parentID *int32) (*gen.Category, error) {
 	return model.GetCategoryByName(db, name, parentID)
 }
 
-// ListCategories 查询所有分类
-func ListCategories(conds ...interface{}) ([]gen.Category, error) {
+// ListCategories 查询所有分类，includeArchived 为 false 时不含已归档的分类及其子树
+func ListCategories(includeArchived bool, conds ...interface{}) ([]gen.Category, error) {
 	db := model.GetDao().Db()
-	return model.ListCategories(db, conds...)
+	categories, err := model.ListCategories(db, conds...)
+	if err != nil || includeArchived {
+		return categories, err
+	}
+	return model.ExcludeArchivedCategories(db, categories)
 }
 
 // ListCategoriesByLevel 按层级查询分类
-func ListCategoriesByLevel(level int32) ([]gen.Category, error) {
+func ListCategoriesByLevel(level int32, includeArchived bool) ([]gen.Category, error) {
 	db := model.GetDao().Db()
-	return model.ListCategoriesByLevel(db, level)
+	categories, err := model.ListCategoriesByLevel(db, level)
+	if err != nil || includeArchived {
+		return categories, err
+	}
+	return model.ExcludeArchivedCategories(db, categories)
 }
 
 // GetCategoriesByParentID 获取指定父分类下的子分类
-func GetCategoriesByParentID(parentID *int32) ([]gen.Category, error) {
+func GetCategoriesByParentID(parentID *int32, includeArchived bool) ([]gen.Category, error) {
+	db := model.GetDao().Db()
+	categories, err := model.GetCategoriesByParentID(db, parentID)
+	if err != nil || includeArchived {
+		return categories, err
+	}
+	return model.ExcludeArchivedCategories(db, categories)
+}
+
+// GetCategoryTree 获取分类树，includeArchived 为 false 时不含已归档的分类及其子树
+func GetCategoryTree(includeArchived bool) ([]*model.CategoryNode, error) {
+	db := model.GetDao().Db()
+	if includeArchived {
+		return model.GetCategoryTree(db)
+	}
+	return model.GetActiveCategoryTree(db)
+}
+
+// ArchiveCategory 归档分类，分类及其子树从列表与分类树中隐藏，统计与历史日志不受影响
+func ArchiveCategory(id int32) (*gen.Category, error) {
 	db := model.GetDao().Db()
-	return model.GetCategoriesByParentID(db, parentID)
+	return model.ArchiveCategory(db, id, nowUTC())
 }
 
-// GetCategoryTree 获取分类树
-func GetCategoryTree() ([]*model.CategoryNode, error) {
+// UnarchiveCategory 取消归档分类
+func UnarchiveCategory(id int32) (*gen.Category, error) {
 	db := model.GetDao().Db()
-	return model.GetCategoryTree(db)
+	return model.UnarchiveCategory(db, id)
+}
+
+// ensureCategoryActive 分类本身或祖先已归档时返回 ErrCategoryArchived
+func ensureCategoryActive(db *gorm.DB, categoryID int32) error {
+	archived, err := model.IsCategoryArchived(db, categoryID)
+	if err != nil {
+		return err
+	}
+	if archived {
+		return fmt.Errorf("%w: category %d", ErrCategoryArchived, categoryID)
+	}
+	return nil
 }
 
 // UpdateCategory 更新分类
@@ -98,3 +192,26 @@ func MoveCategory(categoryID int32, newParentID *int32) error {
 	db := model.GetDao().Db()
 	return model.MoveCategory(db, categoryID, newParentID)
 }
+
+// ReorderCategories 按给定顺序重写兄弟分类的排序
+func ReorderCategories(ids []int32) error {
+	db := model.GetDao().Db()
+	return model.ReorderCategories(db, ids)
+}
+
+// MergeCategory 将源分类合并到目标分类，时间日志、任务与子分类转移到目标分类后删除源分类
+func MergeCategory(sourceID, targetID int32) (*model.CategoryMergeResult, error) {
+	db := model.GetDao().Db()
+	return model.MergeCategory(db, sourceID, targetID)
+}
+
+// DeleteCategory 删除分类，其时间日志、任务与子分类必须转移到 targetID 指定的分类
+func DeleteCategory(id, targetID int32) (*model.CategoryMergeResult, error) {
+	return MergeCategory(id, targetID)
+}
+
+// RepairCategoryHierarchy 以 parent_id 为准重新计算分类的 level 与 path，返回修复的差异
+func RepairCategoryHierarchy(dryRun bool) (*model.CategoryRepairReport, error) {
+	db := model.GetDao().Db()
+	return model.RepairCategoryHierarchy(db, dryRun)
+}
@@ -0,0 +1,450 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/model/gen"
+	"gorm.io/gorm"
+)
+
+// 支持导入的外部时间记录格式
+const (
+	ImportSourceToggl       = "toggl"
+	ImportSourceClockify    = "clockify"
+	ImportSourceTimewarrior = "timewarrior"
+	ImportSourceOrg         = "org"
+)
+
+var importSources = []string{ImportSourceToggl, ImportSourceClockify, ImportSourceTimewarrior, ImportSourceOrg}
+
+// 导入条目的处理结果
+const (
+	ImportStatusImported  = "imported"  // 已导入（试运行时表示将被导入）
+	ImportStatusDuplicate = "duplicate" // 已存在开始与结束时间相同的日志，跳过
+	ImportStatusInvalid   = "invalid"   // 无法解析或校验失败
+)
+
+// DefaultImportCategory 没有分类信息的记录放入的分类
+const DefaultImportCategory = "Imported"
+
+var (
+	ErrInvalidImport     = errors.New("invalid import")
+	ErrImportRowsInvalid = errors.New("import contains invalid rows")
+)
+
+// TimeLogImportOptions 导入参数
+type TimeLogImportOptions struct {
+	Source          string // 为空时根据文件内容识别
+	DryRun          bool   // 只预览映射结果，不写入
+	Timezone        string // 文件中本地时间所在的时区（IANA 名称），默认为配置的时区
+	DayFirst        bool   // 按 日/月/年 解析 01/02/2006 形式的日期，默认为 月/日/年
+	DefaultCategory string // 没有分类信息（客户、项目、标签或标题）的记录放入的分类路径（以 / 分隔），默认为 Imported
+
+	loc *time.Location
+}
+
+// importRecord 从外部文件解析出的一条时间记录
+type importRecord struct {
+	line       int
+	start      time.Time
+	end        *time.Time
+	path       []string // 分类路径，从根分类开始
+	taskTitles []string // 依次尝试匹配的任务标题
+	remark     string
+	err        error // 解析失败的原因
+}
+
+// ImportCategoryMapping 外部分类路径与本地分类的对应关系
+type ImportCategoryMapping struct {
+	Path       string `json:"path"`
+	CategoryID *int32 `json:"category_id"` // 试运行时新建的分类没有ID
+	Created    bool   `json:"created"`
+	Restored   bool   `json:"restored,omitempty"` // 匹配到已软删除的同名分类并将其恢复
+	Entries    int    `json:"entries"` // 直接归入该分类的条目数，中间层级为 0
+}
+
+// ImportOverlap 与导入条目重叠的时间日志：已有日志给出 TimeLogID，同一文件中的条目给出 Line
+type ImportOverlap struct {
+	TimeLogID *int32 `json:"timelog_id,omitempty"`
+	Line      int    `json:"line,omitempty"`
+}
+
+// TimeLogImportEntry 一条导入记录的映射结果
+type TimeLogImportEntry struct {
+	Line         int             `json:"line"`
+	Status       string          `json:"status"`
+	Message      string          `json:"message,omitempty"`
+	StartTime    *time.Time      `json:"start_time"`
+	EndTime      *time.Time      `json:"end_time"`
+	CategoryPath string          `json:"category_path"`
+	TaskID       *int32          `json:"task_id"`
+	TaskTitle    *string         `json:"task_title"`
+	Remark       *string         `json:"remark"`
+	Overlaps     []ImportOverlap `json:"overlaps,omitempty"`
+}
+
+// TimeLogImportReport 导入（或预览）结果
+type TimeLogImportReport struct {
+	Source      string                  `json:"source"`
+	DryRun      bool                    `json:"dry_run"`
+	Total       int                     `json:"total"`
+	Imported    int                     `json:"imported"`
+	Duplicates  int                     `json:"duplicates"`
+	Invalid     int                     `json:"invalid"`
+	Overlapping int                     `json:"overlapping"`
+	Categories  []ImportCategoryMapping `json:"categories"`
+	Entries     []TimeLogImportEntry    `json:"entries"`
+}
+
+// errImportDryRun 用于在预览结束时回滚事务
+var errImportDryRun = errors.New("import dry run")
+
+// normalize 校验并补全导入参数
+func (o *TimeLogImportOptions) normalize() error {
+	o.Source = strings.ToLower(strings.TrimSpace(o.Source))
+	if o.Source != "" && !isImportSource(o.Source) {
+		return fmt.Errorf("%w: source must be one of %s", ErrInvalidImport, strings.Join(importSources, ", "))
+	}
+
+	o.loc = model.GetLocation()
+	if o.Timezone != "" {
+		loc, err := time.LoadLocation(o.Timezone)
+		if err != nil {
+			return fmt.Errorf("%w: unknown timezone %q", ErrInvalidImport, o.Timezone)
+		}
+		o.loc = loc
+	}
+
+	if splitCategoryPath(o.DefaultCategory) == nil {
+		o.DefaultCategory = DefaultImportCategory
+	}
+	return nil
+}
+
+func isImportSource(source string) bool {
+	for _, known := range importSources {
+		if source == known {
+			return true
+		}
+	}
+	return false
+}
+
+// splitCategoryPath 将 /Work/Coding 形式的路径拆分为分类名称
+func splitCategoryPath(path string) []string {
+	var names []string
+	for _, name := range strings.Split(path, "/") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ImportTimeLogs 解析外部时间记录文件并在单个事务中导入
+// 支持 Toggl Track/Clockify 明细 CSV、timew export JSON 与 org-mode CLOCK 记录，
+// 缺失的分类按路径逐级创建（超过最大层级的部分被截断），任务按标题匹配已有任务；
+// 与已有日志开始、结束时间都相同的记录视为重复跳过，重叠按 timelog.overlap_policy 处理。
+// 有无法导入的记录时整个导入回滚并返回 ErrImportRowsInvalid，报告中给出每条记录的原因
+func ImportTimeLogs(r io.Reader, opts TimeLogImportOptions) (*TimeLogImportReport, error) {
+	if err := opts.normalize(); err != nil {
+		return nil, err
+	}
+
+	source, records, err := parseImportFile(bufio.NewReader(r), &opts)
+	if err != nil {
+		return nil, err
+	}
+
+	db := model.GetDao().Db()
+	report := &TimeLogImportReport{Source: source, DryRun: opts.DryRun}
+
+	timerMu.Lock()
+	defer timerMu.Unlock()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := applyImportRecords(tx, records, &opts, report); err != nil {
+			return err
+		}
+		if report.Invalid > 0 && !opts.DryRun {
+			return fmt.Errorf("%w: %d of %d entries cannot be imported", ErrImportRowsInvalid, report.Invalid, report.Total)
+		}
+		if opts.DryRun {
+			return errImportDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errImportDryRun) {
+		err = nil
+	}
+	if err != nil && !errors.Is(err, ErrImportRowsInvalid) {
+		return nil, err
+	}
+	if err != nil || opts.DryRun {
+		// 事务已回滚，新建分类的ID不再有效
+		for i := range report.Categories {
+			if report.Categories[i].Created {
+				report.Categories[i].CategoryID = nil
+			}
+		}
+	}
+	return report, err
+}
+
+// importSniffSize 识别文件格式时最多预读的字节数
+const importSniffSize = 64 * 1024
+
+// parseImportFile 按来源（未指定时根据内容识别）解析导入文件
+// 以 [ 开头的是 timew export 的 JSON，含有 CLOCK: 行的是 org 文件，其余按 CSV 解析
+func parseImportFile(r *bufio.Reader, opts *TimeLogImportOptions) (string, []importRecord, error) {
+	// 去掉 Excel/Clockify 导出文件开头的 UTF-8 BOM
+	if bom, err := r.Peek(3); err == nil && string(bom) == "\xef\xbb\xbf" {
+		r.Discard(3)
+	}
+
+	source := opts.Source
+	if source == "" {
+		r = bufio.NewReaderSize(r, importSniffSize)
+		head, _ := r.Peek(importSniffSize)
+		trimmed := bytes.TrimSpace(head)
+		switch {
+		case bytes.HasPrefix(trimmed, []byte("[")):
+			source = ImportSourceTimewarrior
+		case orgClockPattern.Match(head):
+			source = ImportSourceOrg
+		}
+	}
+
+	switch source {
+	case ImportSourceTimewarrior:
+		records, err := parseTimewarriorJSON(r)
+		return source, records, err
+	case ImportSourceOrg:
+		records, err := parseOrgClocks(r, opts)
+		return source, records, err
+	default:
+		return parseTimeTrackerCSV(r, opts)
+	}
+}
+
+// importContext 导入过程中的分类与任务缓存
+type importContext struct {
+	tx         *gorm.DB
+	categories map[string]*ImportCategoryMapping
+	tasks      map[string][]gen.Task
+	createdIDs map[int32]int // 本次导入创建的时间日志ID -> 行号
+}
+
+func applyImportRecords(tx *gorm.DB, records []importRecord, opts *TimeLogImportOptions, report *TimeLogImportReport) error {
+	tasks, err := model.GetAllTasks(tx, true, true)
+	if err != nil {
+		return err
+	}
+	ctx := &importContext{
+		tx:         tx,
+		categories: make(map[string]*ImportCategoryMapping),
+		tasks:      make(map[string][]gen.Task),
+		createdIDs: make(map[int32]int),
+	}
+	for _, task := range tasks {
+		key := importTitleKey(task.Title)
+		ctx.tasks[key] = append(ctx.tasks[key], task)
+	}
+
+	report.Total = len(records)
+	report.Entries = make([]TimeLogImportEntry, 0, len(records))
+	for i := range records {
+		entry, err := ctx.apply(&records[i], opts)
+		if err != nil {
+			return err
+		}
+		switch entry.Status {
+		case ImportStatusImported:
+			report.Imported++
+		case ImportStatusDuplicate:
+			report.Duplicates++
+		case ImportStatusInvalid:
+			report.Invalid++
+		}
+		if len(entry.Overlaps) > 0 {
+			report.Overlapping++
+		}
+		report.Entries = append(report.Entries, entry)
+	}
+
+	report.Categories = make([]ImportCategoryMapping, 0, len(ctx.categories))
+	for _, mapping := range ctx.categories {
+		report.Categories = append(report.Categories, *mapping)
+	}
+	sort.Slice(report.Categories, func(i, j int) bool { return report.Categories[i].Path < report.Categories[j].Path })
+	return nil
+}
+
+// apply 导入一条记录；记录本身的问题写入条目状态，只有数据库错误才返回 error
+func (c *importContext) apply(rec *importRecord, opts *TimeLogImportOptions) (TimeLogImportEntry, error) {
+	entry := TimeLogImportEntry{Line: rec.line, Status: ImportStatusInvalid}
+	if rec.err != nil {
+		entry.Message = rec.err.Error()
+		return entry, nil
+	}
+
+	tl := gen.Timelog{StartTime: rec.start, EndTime: rec.end}
+	normalizeTimeLog(&tl)
+	entry.StartTime = localTimePtr(&tl.StartTime)
+	entry.EndTime = localTimePtr(tl.EndTime)
+	if rec.remark != "" {
+		remark := rec.remark
+		tl.Remark = &remark
+		entry.Remark = &remark
+	}
+
+	path := rec.path
+	if len(path) == 0 {
+		path = splitCategoryPath(opts.DefaultCategory)
+	}
+	var messages []string
+	if maxDepth := int(model.MaxCategoryLevel) + 1; len(path) > maxDepth {
+		messages = append(messages, fmt.Sprintf("dropped %q beyond the max category depth", strings.Join(path[maxDepth:], "/")))
+		path = path[:maxDepth]
+	}
+	entry.CategoryPath = "/" + strings.Join(path, "/")
+
+	// 先判断时间范围与重复，重复的记录不应创建分类
+	if err := validateTimeRange(&tl); err != nil {
+		entry.Message = err.Error()
+		return entry, nil
+	}
+	if _, err := model.FindTimeLogByRange(c.tx, tl.StartTime, tl.EndTime); err == nil {
+		entry.Status = ImportStatusDuplicate
+		entry.Message = "a time log with the same start and end time already exists"
+		return entry, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return entry, err
+	}
+
+	mapping, err := c.resolveCategory(path)
+	if err != nil {
+		entry.Message = err.Error()
+		return entry, nil
+	}
+	mapping.Entries++
+	tl.CategoryID = *mapping.CategoryID
+	if err := ensureCategoryActive(c.tx, tl.CategoryID); err != nil {
+		if !errors.Is(err, ErrCategoryArchived) {
+			return entry, err
+		}
+		entry.Message = err.Error()
+		return entry, nil
+	}
+
+	if task := c.matchTask(rec.taskTitles, tl.CategoryID); task != nil {
+		tl.TaskID = task.ID
+		entry.TaskID = task.ID
+		entry.TaskTitle = &task.Title
+	}
+
+	if tl.EndTime == nil {
+		if err := ensureNoOtherOpenTimeLog(c.tx, nil); err != nil {
+			entry.Message = err.Error()
+			return entry, nil
+		}
+	}
+	if policy := overlapPolicy(); policy != OverlapPolicyOff {
+		conflicts, err := model.ListTimeLogsOverlapping(c.tx, tl.StartTime, tl.EndTime, nil)
+		if err != nil {
+			return entry, err
+		}
+		for _, conflict := range conflicts {
+			if line, ok := c.createdIDs[*conflict.ID]; ok {
+				entry.Overlaps = append(entry.Overlaps, ImportOverlap{Line: line})
+			} else {
+				entry.Overlaps = append(entry.Overlaps, ImportOverlap{TimeLogID: conflict.ID})
+			}
+		}
+		if len(conflicts) > 0 {
+			if policy == OverlapPolicyReject {
+				entry.Message = (&OverlapError{Conflicts: conflicts}).Error()
+				return entry, nil
+			}
+			messages = append(messages, fmt.Sprintf("overlaps %d time log(s)", len(conflicts)))
+		}
+	}
+
+	if err := model.CreateTimeLog(c.tx, &tl); err != nil {
+		return entry, err
+	}
+	c.createdIDs[*tl.ID] = rec.line
+	entry.Status = ImportStatusImported
+	entry.Message = strings.Join(messages, "; ")
+	return entry, nil
+}
+
+// resolveCategory 按路径逐级查找分类，缺失的分类在当前事务中创建
+// UNIQUE(name, parent_id) 同样约束已软删除的分类：优先匹配未删除的分类，只有已删除的同名分类时恢复它
+func (c *importContext) resolveCategory(path []string) (*ImportCategoryMapping, error) {
+	var parent *ImportCategoryMapping
+	for i := range path {
+		key := "/" + strings.Join(path[:i+1], "/")
+		if mapping, ok := c.categories[key]; ok {
+			parent = mapping
+			continue
+		}
+
+		var parentID *int32
+		if parent != nil {
+			parentID = parent.CategoryID
+		}
+		mapping := &ImportCategoryMapping{Path: key}
+		category, err := model.GetCategoryByName(c.tx.Unscoped().Order("deleted_at IS NOT NULL"), path[i], parentID)
+		switch {
+		case err == nil && category.DeletedAt.Valid:
+			if err := c.tx.Unscoped().Model(&gen.Category{}).Where("id = ?", *category.ID).Update("deleted_at", nil).Error; err != nil {
+				return nil, err
+			}
+			mapping.Restored = true
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			category = &gen.Category{Name: path[i], ParentID: parentID}
+			if err := model.CreateCategory(c.tx, category); err != nil {
+				return nil, fmt.Errorf("cannot create category %s: %w", key, err)
+			}
+			mapping.Created = true
+		case err != nil:
+			return nil, err
+		}
+		mapping.CategoryID = category.ID
+		c.categories[key] = mapping
+		parent = mapping
+	}
+	return parent, nil
+}
+
+// matchTask 按标题（忽略大小写与首尾空白）匹配任务，同名任务优先选择同一分类下的
+func (c *importContext) matchTask(titles []string, categoryID int32) *gen.Task {
+	for _, title := range titles {
+		if strings.TrimSpace(title) == "" {
+			continue
+		}
+		candidates := c.tasks[importTitleKey(title)]
+		if len(candidates) == 0 {
+			continue
+		}
+		for i := range candidates {
+			if candidates[i].CategoryID == categoryID {
+				return &candidates[i]
+			}
+		}
+		return &candidates[0]
+	}
+	return nil
+}
+
+func importTitleKey(title string) string {
+	return strings.ToLower(strings.TrimSpace(title))
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Toggl Track 与 Clockify 的明细报表 CSV 使用相同含义的列名（大小写不同），按小写列名读取：
+//
+//	Toggl:    User,Email,Client,Project,Task,Description,Billable,Start date,Start time,End date,End time,Duration,Tags,...
+//	Clockify: Project,Client,Description,Task,User,Group,Email,Tags,Billable,Start Date,Start Time,End Date,End Time,Duration (h),...
+const (
+	csvColClient      = "client"
+	csvColProject     = "project"
+	csvColTask        = "task"
+	csvColDescription = "description"
+	csvColTags        = "tags"
+	csvColStartDate   = "start date"
+	csvColStartTime   = "start time"
+	csvColEndDate     = "end date"
+	csvColEndTime     = "end time"
+)
+
+var csvRequiredColumns = []string{csvColStartDate, csvColStartTime, csvColEndDate, csvColEndTime}
+
+// 日期格式：ISO 与点/短横分隔的日期总是唯一的，斜杠分隔的日期按 DayFirst 区分 月/日 与 日/月
+var (
+	csvDateLayouts         = []string{"2006-01-02", "2006/01/02", "02.01.2006", "02-01-2006"}
+	csvMonthFirstLayouts   = []string{"01/02/2006", "1/2/2006"}
+	csvDayFirstLayouts     = []string{"02/01/2006", "2/1/2006"}
+	csvClockLayouts        = []string{"15:04:05", "15:04", "3:04:05 PM", "3:04 PM"}
+	errCSVMissingTimestamp = errors.New("start and end date/time are required")
+)
+
+// parseTimeTrackerCSV 解析 Toggl Track 或 Clockify 导出的明细 CSV
+// 分类路径为 客户/项目/标签…（空值跳过），任务列与描述依次用于匹配任务标题，描述作为备注
+func parseTimeTrackerCSV(r io.Reader, opts *TimeLogImportOptions) (string, []importRecord, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: cannot read CSV header: %v", ErrInvalidImport, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range csvRequiredColumns {
+		if _, ok := columns[name]; !ok {
+			return "", nil, fmt.Errorf("%w: CSV has no %q column, expected a Toggl Track or Clockify detailed export", ErrInvalidImport, name)
+		}
+	}
+
+	source := opts.Source
+	if source == "" {
+		source = ImportSourceToggl
+		if _, ok := columns["duration (h)"]; ok {
+			source = ImportSourceClockify
+		} else if _, ok := columns["duration (decimal)"]; ok {
+			source = ImportSourceClockify
+		}
+	}
+
+	var records []importRecord
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return "", nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+			}
+			return "", nil, err
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+
+		rec := importRecord{line: line}
+		start, startErr := parseCSVDateTime(field(csvColStartDate), field(csvColStartTime), opts)
+		end, endErr := parseCSVDateTime(field(csvColEndDate), field(csvColEndTime), opts)
+		switch {
+		case startErr != nil:
+			rec.err = startErr
+		case endErr != nil:
+			rec.err = endErr
+		default:
+			rec.start = start
+			rec.end = &end
+		}
+
+		for _, name := range []string{field(csvColClient), field(csvColProject)} {
+			if name != "" {
+				rec.path = append(rec.path, name)
+			}
+		}
+		for _, tag := range strings.Split(field(csvColTags), ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				rec.path = append(rec.path, tag)
+			}
+		}
+
+		description := field(csvColDescription)
+		task := field(csvColTask)
+		for _, title := range []string{task, description} {
+			if title != "" {
+				rec.taskTitles = append(rec.taskTitles, title)
+			}
+		}
+		rec.remark = description
+		if rec.remark == "" {
+			rec.remark = task
+		}
+		records = append(records, rec)
+	}
+	return source, records, nil
+}
+
+// parseCSVDateTime 按 opts 的时区解析日期与时刻两列
+func parseCSVDateTime(date, clock string, opts *TimeLogImportOptions) (time.Time, error) {
+	if date == "" || clock == "" {
+		return time.Time{}, errCSVMissingTimestamp
+	}
+
+	layouts := append([]string{}, csvDateLayouts...)
+	if opts.DayFirst {
+		layouts = append(layouts, csvDayFirstLayouts...)
+	} else {
+		layouts = append(layouts, csvMonthFirstLayouts...)
+	}
+	var day time.Time
+	var err error
+	for _, layout := range layouts {
+		if day, err = time.Parse(layout, date); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unrecognized date %q", date)
+	}
+
+	var tod time.Time
+	upper := strings.ToUpper(clock)
+	for _, layout := range csvClockLayouts {
+		if tod, err = time.Parse(layout, upper); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unrecognized time %q", clock)
+	}
+
+	return time.Date(day.Year(), day.Month(), day.Day(), tod.Hour(), tod.Minute(), tod.Second(), 0, opts.loc), nil
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	orgHeadingPattern   = regexp.MustCompile(`^(\*+)\s+(.*)$`)
+	orgClockPattern     = regexp.MustCompile(`(?m)^\s*CLOCK:\s*\[([^\]]+)\](?:--\[([^\]]+)\])?`)
+	orgTodoSetting      = regexp.MustCompile(`^#\+(?:SEQ_|TYP_)?TODO:\s*(.*)$`)
+	orgTimestampPattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})(?:\s+[^\s\d]+)?\s+(\d{1,2}:\d{2})`)
+	orgPriorityPattern  = regexp.MustCompile(`^\[#[A-Za-z0-9]\]\s*`)
+	orgTagsPattern      = regexp.MustCompile(`\s+:[\w@#%:]+:\s*$`)
+	orgCookiePattern    = regexp.MustCompile(`\s*\[\d*(?:/\d*|%)\]`)
+	orgLinkPattern      = regexp.MustCompile(`\[\[(?:[^\]]*\]\[)?([^\]]*)\]\]`)
+)
+
+// orgDefaultKeywords org-mode 默认及常见的待办关键字，文件中的 #+TODO: 行会追加更多
+var orgDefaultKeywords = []string{"TODO", "DONE", "NEXT", "STARTED", "WAIT", "WAITING", "HOLD", "SOMEDAY", "CANCELLED", "CANCELED"}
+
+// parseOrgClocks 解析 org-mode 文件中的 CLOCK: 记录
+// 所在标题及其所有上级标题（去掉待办关键字、优先级、标签与统计标记）组成分类路径，
+// 最内层标题作为备注并用于匹配任务标题；没有结束时间的 CLOCK 视为正在计时
+func parseOrgClocks(r io.Reader, opts *TimeLogImportOptions) ([]importRecord, error) {
+	keywords := make(map[string]bool)
+	for _, keyword := range orgDefaultKeywords {
+		keywords[keyword] = true
+	}
+
+	var headings []string
+	var records []importRecord
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+
+		if m := orgTodoSetting.FindStringSubmatch(text); m != nil {
+			for _, keyword := range strings.Fields(m[1]) {
+				// 关键字可带快捷键与记录设置，如 WAIT(w@/!)
+				if i := strings.Index(keyword, "("); i > 0 {
+					keyword = keyword[:i]
+				}
+				if keyword != "|" {
+					keywords[keyword] = true
+				}
+			}
+			continue
+		}
+
+		if m := orgHeadingPattern.FindStringSubmatch(text); m != nil {
+			level := len(m[1])
+			if level <= len(headings) {
+				headings = headings[:level-1]
+			}
+			// 跳级的标题用空标题补齐，拼路径时会被忽略
+			for len(headings) < level-1 {
+				headings = append(headings, "")
+			}
+			headings = append(headings, cleanOrgHeading(m[2], keywords))
+			continue
+		}
+
+		m := orgClockPattern.FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+		rec := importRecord{line: line}
+		for _, heading := range headings {
+			if heading != "" {
+				rec.path = append(rec.path, heading)
+			}
+		}
+		if len(rec.path) > 0 {
+			rec.remark = rec.path[len(rec.path)-1]
+			rec.taskTitles = []string{rec.remark}
+		}
+
+		start, err := parseOrgTimestamp(m[1], opts.loc)
+		if err != nil {
+			rec.err = err
+		} else {
+			rec.start = start
+			if m[2] != "" {
+				end, err := parseOrgTimestamp(m[2], opts.loc)
+				if err != nil {
+					rec.err = err
+				}
+				rec.end = &end
+			}
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+	return records, nil
+}
+
+// cleanOrgHeading 去掉标题中的待办关键字、优先级、统计标记、标签与链接语法
+func cleanOrgHeading(title string, keywords map[string]bool) string {
+	if fields := strings.Fields(title); len(fields) > 0 && keywords[fields[0]] {
+		title = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(title), fields[0]))
+	}
+	title = orgPriorityPattern.ReplaceAllString(title, "")
+	title = orgTagsPattern.ReplaceAllString(title, "")
+	title = orgCookiePattern.ReplaceAllString(title, "")
+	title = orgLinkPattern.ReplaceAllString(title, "$1")
+	return strings.TrimSpace(title)
+}
+
+// parseOrgTimestamp 解析 2024-01-15 Mon 09:00 形式的时间戳（星期可以是任意语言或省略）
+func parseOrgTimestamp(value string, loc *time.Location) (time.Time, error) {
+	m := orgTimestampPattern.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil {
+		return time.Time{}, fmt.Errorf("unrecognized timestamp [%s]", value)
+	}
+	t, err := time.ParseInLocation("2006-01-02 15:04", m[1]+" "+m[2], loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unrecognized timestamp [%s]", value)
+	}
+	return t, nil
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"bufio"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func parseCSVForTest(t *testing.T, data string, opts TimeLogImportOptions) (string, []importRecord) {
+	t.Helper()
+	if err := opts.normalize(); err != nil {
+		t.Fatalf("normalize failed: %v", err)
+	}
+	source, records, err := parseTimeTrackerCSV(strings.NewReader(data), &opts)
+	if err != nil {
+		t.Fatalf("parseTimeTrackerCSV failed: %v", err)
+	}
+	return source, records
+}
+
+func TestParseTogglCSV(t *testing.T) {
+	data := "User,Email,Client,Project,Task,Description,Billable,Start date,Start time,End date,End time,Duration,Tags,Amount (USD)\n" +
+		"Ann,ann@example.com,Acme,Website,Landing page,\"Hero, copy\",Yes,2024-01-15,23:30:00,2024-01-16,00:45:00,01:15:00,\"design, review\",\n" +
+		"Ann,ann@example.com,,,,Email,No,2024-01-16,09:00:00,2024-01-16,bad,00:10:00,,\n"
+
+	source, records := parseCSVForTest(t, data, TimeLogImportOptions{Timezone: "Europe/Berlin"})
+	if source != ImportSourceToggl || len(records) != 2 {
+		t.Fatalf("Unexpected parse result: %s, %d records", source, len(records))
+	}
+
+	rec := records[0]
+	if rec.err != nil || rec.line != 2 {
+		t.Fatalf("Unexpected first record: %+v", rec)
+	}
+	if !rec.start.Equal(time.Date(2024, 1, 15, 22, 30, 0, 0, time.UTC)) || !rec.end.Equal(time.Date(2024, 1, 15, 23, 45, 0, 0, time.UTC)) {
+		t.Errorf("Times should be read in the given time zone: %v - %v", rec.start, rec.end)
+	}
+	if strings.Join(rec.path, "/") != "Acme/Website/design/review" {
+		t.Errorf("Unexpected category path %v", rec.path)
+	}
+	if rec.remark != "Hero, copy" || len(rec.taskTitles) != 2 || rec.taskTitles[0] != "Landing page" {
+		t.Errorf("Unexpected remark/task titles: %q %v", rec.remark, rec.taskTitles)
+	}
+
+	if records[1].err == nil || records[1].line != 3 {
+		t.Errorf("Expected an error for the malformed end time on line 3, got %+v", records[1])
+	}
+}
+
+func TestParseClockifyCSV(t *testing.T) {
+	data := "\"Project\",\"Client\",\"Description\",\"Task\",\"User\",\"Group\",\"Email\",\"Tags\",\"Billable\",\"Start Date\",\"Start Time\",\"End Date\",\"End Time\",\"Duration (h)\",\"Duration (decimal)\"\n" +
+		"\"Backend\",\"\",\"Fix login\",\"\",\"Bo\",\"\",\"bo@example.com\",\"\",\"No\",\"02/03/2024\",\"01:05:00 PM\",\"02/03/2024\",\"02:00:00 PM\",\"00:55:00\",\"0.92\"\n"
+
+	source, records := parseCSVForTest(t, data, TimeLogImportOptions{Timezone: "UTC"})
+	if source != ImportSourceClockify || len(records) != 1 || records[0].err != nil {
+		t.Fatalf("Unexpected parse result: %s %+v", source, records)
+	}
+	if want := time.Date(2024, 2, 3, 13, 5, 0, 0, time.UTC); !records[0].start.Equal(want) {
+		t.Errorf("Expected month-first date %v, got %v", want, records[0].start)
+	}
+	if strings.Join(records[0].path, "/") != "Backend" || records[0].remark != "Fix login" {
+		t.Errorf("Unexpected mapping: %+v", records[0])
+	}
+
+	_, records = parseCSVForTest(t, data, TimeLogImportOptions{Timezone: "UTC", DayFirst: true})
+	if want := time.Date(2024, 3, 2, 13, 5, 0, 0, time.UTC); !records[0].start.Equal(want) {
+		t.Errorf("Expected day-first date %v, got %v", want, records[0].start)
+	}
+}
+
+func TestParseTimeTrackerCSVRejectsUnknownFormat(t *testing.T) {
+	opts := TimeLogImportOptions{Timezone: "UTC"}
+	if err := opts.normalize(); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err := parseTimeTrackerCSV(strings.NewReader("date,hours\n2024-01-01,3\n"), &opts)
+	if !errors.Is(err, ErrInvalidImport) {
+		t.Errorf("Expected ErrInvalidImport, got %v", err)
+	}
+
+	bad := TimeLogImportOptions{Timezone: "Mars/Olympus"}
+	if err := bad.normalize(); !errors.Is(err, ErrInvalidImport) {
+		t.Errorf("Expected ErrInvalidImport for an unknown time zone, got %v", err)
+	}
+}
+
+func TestParseTimewarriorJSON(t *testing.T) {
+	data := `[
+{"id":3,"start":"20240115T010000Z","end":"20240115T023000Z","tags":["Work","coding/review"],"annotation":"Review PR"},
+{"id":2,"start":"20240115T030000Z","tags":[]},
+{"id":1,"start":"yesterday","end":"20240115T050000Z","tags":["Work"]}
+]`
+	opts := TimeLogImportOptions{Timezone: "UTC"}
+	if err := opts.normalize(); err != nil {
+		t.Fatal(err)
+	}
+	source, records, err := parseImportFile(bufio.NewReader(strings.NewReader(data)), &opts)
+	if err != nil {
+		t.Fatalf("parseImportFile failed: %v", err)
+	}
+	if source != ImportSourceTimewarrior || len(records) != 3 {
+		t.Fatalf("Unexpected parse result: %s, %d records", source, len(records))
+	}
+	if strings.Join(records[0].path, "/") != "Work/coding/review" || records[0].remark != "Review PR" || records[0].end == nil {
+		t.Errorf("Unexpected first interval: %+v", records[0])
+	}
+	if records[1].err != nil || records[1].end != nil || len(records[1].path) != 0 {
+		t.Errorf("Expected an open interval without category, got %+v", records[1])
+	}
+	if records[2].err == nil {
+		t.Errorf("Expected an error for the malformed start")
+	}
+}
+
+func TestParseOrgClocks(t *testing.T) {
+	data := `#+TITLE: Work log
+#+TODO: TODO BLOCKED(b@) | DONE
+* Work :office:
+** Project X
+*** BLOCKED [#A] Write [[https://example.com][report]] [1/2]  :writing:
+    :LOGBOOK:
+    CLOCK: [2024-01-15 Mon 09:00]--[2024-01-15 Mon 10:30] =>  1:30
+    CLOCK: [2024-01-16 周二 14:00]--[2024-01-16 周二 14:45] =>  0:45
+    :END:
+** Meetings
+   CLOCK: [2024-01-17 Wed 11:00]
+* Personal
+   CLOCK: [2024-01-17 Wed 25:00]--[2024-01-17 Wed 26:00]
+`
+	opts := TimeLogImportOptions{Timezone: "Asia/Singapore"}
+	if err := opts.normalize(); err != nil {
+		t.Fatal(err)
+	}
+	source, records, err := parseImportFile(bufio.NewReader(strings.NewReader(data)), &opts)
+	if err != nil {
+		t.Fatalf("parseImportFile failed: %v", err)
+	}
+	if source != ImportSourceOrg || len(records) != 4 {
+		t.Fatalf("Unexpected parse result: %s, %d records", source, len(records))
+	}
+
+	first := records[0]
+	if strings.Join(first.path, "/") != "Work/Project X/Write report" || first.remark != "Write report" || first.line != 7 {
+		t.Errorf("Unexpected heading path or remark: %+v", first)
+	}
+	if !first.start.Equal(time.Date(2024, 1, 15, 1, 0, 0, 0, time.UTC)) || !first.end.Equal(time.Date(2024, 1, 15, 2, 30, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected clock times: %v - %v", first.start, first.end)
+	}
+	if records[1].err != nil {
+		t.Errorf("Localized weekday names should be accepted: %v", records[1].err)
+	}
+	if strings.Join(records[2].path, "/") != "Work/Meetings" || records[2].end != nil {
+		t.Errorf("Expected an open clock under Work/Meetings: %+v", records[2])
+	}
+	if records[3].err == nil {
+		t.Errorf("Expected an error for the invalid time")
+	}
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// timewarriorTimeLayout timew export 输出的 UTC 时间格式
+const timewarriorTimeLayout = "20060102T150405Z"
+
+// timewarriorInterval timew export 输出的一个区间，未结束的区间没有 end
+type timewarriorInterval struct {
+	Start      string   `json:"start"`
+	End        string   `json:"end"`
+	Tags       []string `json:"tags"`
+	Annotation string   `json:"annotation"`
+}
+
+// parseTimewarriorJSON 解析 timew export 输出的 JSON 数组
+// 标签按顺序组成分类路径（含 / 的标签拆为多级），注释作为备注并用于匹配任务标题；
+// 条目的行号为区间在数组中的序号（从 1 开始）
+func parseTimewarriorJSON(r io.Reader) ([]importRecord, error) {
+	var intervals []timewarriorInterval
+	if err := json.NewDecoder(r).Decode(&intervals); err != nil {
+		return nil, fmt.Errorf("%w: not a timew export JSON array: %v", ErrInvalidImport, err)
+	}
+
+	records := make([]importRecord, 0, len(intervals))
+	for i, interval := range intervals {
+		rec := importRecord{line: i + 1, remark: strings.TrimSpace(interval.Annotation)}
+		for _, tag := range interval.Tags {
+			rec.path = append(rec.path, splitCategoryPath(tag)...)
+		}
+		if rec.remark != "" {
+			rec.taskTitles = []string{rec.remark}
+		}
+
+		start, err := time.Parse(timewarriorTimeLayout, interval.Start)
+		if err != nil {
+			rec.err = fmt.Errorf("unrecognized start %q", interval.Start)
+			records = append(records, rec)
+			continue
+		}
+		rec.start = start
+		if interval.End != "" {
+			end, err := time.Parse(timewarriorTimeLayout, interval.End)
+			if err != nil {
+				rec.err = fmt.Errorf("unrecognized end %q", interval.End)
+			}
+			rec.end = &end
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/model/gen"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 200
+)
+
+var (
+	ErrInvalidTimeLogQuery = errors.New("invalid time log query")
+	ErrInvalidCursor       = errors.New("invalid cursor")
+)
+
+// TimeLogFilter 时间日志列表的查询参数
+type TimeLogFilter struct {
+	From       string `form:"from"`        // 开始日期（本地日期 YYYY-MM-DD，含）
+	To         string `form:"to"`          // 结束日期（本地日期 YYYY-MM-DD，含）
+	CategoryID *int32 `form:"category_id"` // 分类ID，包含其所有子分类
+	TaskID     *int32 `form:"task_id"`
+	Q          string `form:"q"`     // 备注文本匹配
+	State      string `form:"state"` // open|closed|all
+	Sort       string `form:"sort"`  // 排序字段，前缀 "-" 表示倒序，如 -start_time
+	Order      string `form:"order"` // 兼容旧参数，如 "created_at DESC"
+	Limit      int    `form:"limit"` // 不分页时限制返回条数
+	Page       int    `form:"page"`
+	Size       int    `form:"size"`
+	Cursor     string `form:"cursor"`
+}
+
+// Paginated 是否请求了分页（page/size/cursor 任一），否则按旧接口返回数组
+func (f *TimeLogFilter) Paginated() bool {
+	return f.Page > 0 || f.Size > 0 || f.Cursor != ""
+}
+
+// timeLogCursor 游标内容：上一页最后一条日志的ID及其对应的排序方式
+type timeLogCursor struct {
+	Sort string `json:"s"`
+	ID   int32  `json:"id"`
+}
+
+func encodeTimeLogCursor(sort string, id int32) string {
+	raw, _ := json.Marshal(timeLogCursor{Sort: sort, ID: id})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeTimeLogCursor(s string) (*timeLogCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c timeLogCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// parseTimeLogSort 解析排序参数，支持 "-start_time"、"start_time" 及旧格式 "created_at DESC"
+// 返回规范化后的写法（如 "-start_time"）、列名和是否倒序
+func parseTimeLogSort(sort, order string) (string, string, bool, error) {
+	if sort == "" {
+		sort = order
+	}
+	sort = strings.TrimSpace(sort)
+	if sort == "" {
+		return "-start_time", "start_time", true, nil
+	}
+
+	field, desc := sort, false
+	if strings.HasPrefix(field, "-") {
+		field, desc = field[1:], true
+	} else if parts := strings.Fields(sort); len(parts) == 2 {
+		switch strings.ToUpper(parts[1]) {
+		case "ASC":
+			field = parts[0]
+		case "DESC":
+			field, desc = parts[0], true
+		default:
+			return "", "", false, fmt.Errorf("%w: unsupported sort direction %q", ErrInvalidTimeLogQuery, parts[1])
+		}
+	}
+	field = strings.ToLower(field)
+	if !model.IsTimeLogSortField(field) {
+		return "", "", false, fmt.Errorf("%w: unsupported sort field %q", ErrInvalidTimeLogQuery, field)
+	}
+
+	normalized := field
+	if desc {
+		normalized = "-" + field
+	}
+	return normalized, field, desc, nil
+}
+
+// buildTimeLogQuery 将查询参数转换为 model.TimeLogQuery（不含分页）
+func buildTimeLogQuery(f *TimeLogFilter) (model.TimeLogQuery, string, error) {
+	var q model.TimeLogQuery
+
+	sort, field, desc, err := parseTimeLogSort(f.Sort, f.Order)
+	if err != nil {
+		return q, "", err
+	}
+	q.SortField, q.SortDesc = field, desc
+
+	if f.From != "" {
+		date, err := model.ParseLocalDate(f.From)
+		if err != nil {
+			return q, "", fmt.Errorf("%w: from must be YYYY-MM-DD", ErrInvalidTimeLogQuery)
+		}
+		from, _ := model.LocalDayBounds(date)
+		q.StartFrom = &from
+	}
+	if f.To != "" {
+		date, err := model.ParseLocalDate(f.To)
+		if err != nil {
+			return q, "", fmt.Errorf("%w: to must be YYYY-MM-DD", ErrInvalidTimeLogQuery)
+		}
+		_, to := model.LocalDayBounds(date)
+		q.StartBefore = &to
+	}
+	if q.StartFrom != nil && q.StartBefore != nil && !q.StartBefore.After(*q.StartFrom) {
+		return q, "", fmt.Errorf("%w: from must not be after to", ErrInvalidTimeLogQuery)
+	}
+
+	if f.CategoryID != nil {
+		ids, err := model.GetCategoryDescendantIDs(model.GetDao().Db(), *f.CategoryID)
+		if err != nil {
+			return q, "", err
+		}
+		q.CategoryIDs = ids
+	}
+	q.TaskID = f.TaskID
+	q.Remark = strings.TrimSpace(f.Q)
+
+	switch strings.ToLower(f.State) {
+	case "", "all":
+	case "open":
+		open := true
+		q.Open = &open
+	case "closed":
+		closed := false
+		q.Open = &closed
+	default:
+		return q, "", fmt.Errorf("%w: state must be open, closed or all", ErrInvalidTimeLogQuery)
+	}
+	return q, sort, nil
+}
+
+// SearchTimeLogs 按过滤条件查询时间日志，不分页，limit 大于 0 时限制条数
+func SearchTimeLogs(f *TimeLogFilter) ([]gen.Timelog, error) {
+	q, _, err := buildTimeLogQuery(f)
+	if err != nil {
+		return nil, err
+	}
+	if f.Limit > 0 {
+		q.Limit = f.Limit
+	}
+	return model.QueryTimeLogs(model.GetDao().Db(), q)
+}
+
+// SearchTimeLogsPaged 按过滤条件分页查询时间日志
+// 提供 cursor 时使用游标分页（忽略 page），否则使用 page/size，两种方式都返回满足条件的总数
+func SearchTimeLogsPaged(f *TimeLogFilter) (*Response, error) {
+	q, sort, err := buildTimeLogQuery(f)
+	if err != nil {
+		return nil, err
+	}
+
+	size := f.Size
+	if size <= 0 {
+		size = defaultPageSize
+	}
+	if size > maxPageSize {
+		size = maxPageSize
+	}
+	page := f.Page
+	if page <= 0 {
+		page = 1
+	}
+
+	db := model.GetDao().Db()
+	total, err := model.CountTimeLogs(db, q)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Cursor != "" {
+		cursor, err := decodeTimeLogCursor(f.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.Sort != sort {
+			return nil, fmt.Errorf("%w: cursor was issued for sort %q", ErrInvalidCursor, cursor.Sort)
+		}
+		// end_time 可为空，无法作为游标的比较键
+		if q.SortField == "end_time" {
+			return nil, fmt.Errorf("%w: cursor pagination does not support sorting by end_time", ErrInvalidTimeLogQuery)
+		}
+		if _, err := model.GetTimeLogByID(db, cursor.ID); err != nil {
+			return nil, fmt.Errorf("%w: time log %d no longer exists", ErrInvalidCursor, cursor.ID)
+		}
+		q.AfterID = &cursor.ID
+		page = 0
+	} else {
+		q.Offset = (page - 1) * size
+	}
+	// 多取一条用于判断是否还有下一页
+	q.Limit = size + 1
+
+	tls, err := model.QueryTimeLogs(db, q)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &Response{
+		Items: make([]any, 0, size),
+		Pages: Pages{Page: page, Size: size, Total: int(total)},
+	}
+	hasMore := len(tls) > size
+	if hasMore {
+		tls = tls[:size]
+	}
+	for i := range tls {
+		resp.Items = append(resp.Items, tls[i])
+	}
+	if hasMore && len(tls) > 0 && q.SortField != "end_time" {
+		resp.NextCursor = encodeTimeLogCursor(sort, *tls[len(tls)-1].ID)
+	}
+	return resp, nil
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseTimeLogSort(t *testing.T) {
+	cases := []struct {
+		sort, order string
+		normalized  string
+		field       string
+		desc        bool
+	}{
+		{"", "", "-start_time", "start_time", true},
+		{"start_time", "", "start_time", "start_time", false},
+		{"-end_time", "", "-end_time", "end_time", true},
+		{"", "created_at DESC", "-created_at", "created_at", true},
+		{"", "updated_at asc", "updated_at", "updated_at", false},
+		{"id", "created_at DESC", "id", "id", false},
+	}
+	for _, c := range cases {
+		normalized, field, desc, err := parseTimeLogSort(c.sort, c.order)
+		if err != nil {
+			t.Errorf("parseTimeLogSort(%q, %q) returned error: %v", c.sort, c.order, err)
+			continue
+		}
+		if normalized != c.normalized || field != c.field || desc != c.desc {
+			t.Errorf("parseTimeLogSort(%q, %q) = (%q, %q, %v), expected (%q, %q, %v)",
+				c.sort, c.order, normalized, field, desc, c.normalized, c.field, c.desc)
+		}
+	}
+
+	for _, bad := range []string{"remark", "start_time; DROP TABLE timelogs", "start_time sideways", "-"} {
+		if _, _, _, err := parseTimeLogSort(bad, ""); !errors.Is(err, ErrInvalidTimeLogQuery) {
+			t.Errorf("Expected %q to be rejected, got %v", bad, err)
+		}
+	}
+}
+
+func TestTimeLogCursorRoundTrip(t *testing.T) {
+	encoded := encodeTimeLogCursor("-start_time", 42)
+	cursor, err := decodeTimeLogCursor(encoded)
+	if err != nil {
+		t.Fatalf("Failed to decode cursor: %v", err)
+	}
+	if cursor.Sort != "-start_time" || cursor.ID != 42 {
+		t.Errorf("Unexpected cursor: %+v", cursor)
+	}
+
+	for _, bad := range []string{"not-base64!", "bm90IGpzb24", "eyJzIjoiaWQiLCJpZCI6MH0"} {
+		if _, err := decodeTimeLogCursor(bad); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("Expected %q to be rejected, got %v", bad, err)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/model/gen"
+	"gorm.io/gorm"
+)
+
+// 重叠检测策略，对应配置 timelog.overlap_policy
+const (
+	OverlapPolicyReject = "reject"
+	OverlapPolicyWarn   = "warn"
+	OverlapPolicyOff    = "off"
+)
+
+var (
+	ErrInvalidTimeRange = errors.New("end_time must be after start_time")
+	ErrTimeLogOverlap   = errors.New("time log overlaps with existing entries")
+)
+
+// OverlapError 描述与哪些已有日志发生了重叠
+type OverlapError struct {
+	Conflicts []gen.Timelog
+}
+
+func (e *OverlapError) Error() string {
+	ids := make([]string, 0, len(e.Conflicts))
+	for _, tl := range e.Conflicts {
+		ids = append(ids, fmt.Sprintf("#%d", *tl.ID))
+	}
+	return fmt.Sprintf("%s: %s", ErrTimeLogOverlap.Error(), strings.Join(ids, ", "))
+}
+
+func (e *OverlapError) Unwrap() error {
+	return ErrTimeLogOverlap
+}
+
+// ValidateOverlapPolicy 校验 timelog.overlap_policy，未配置表示默认的 reject
+func ValidateOverlapPolicy(policy string) error {
+	switch strings.ToLower(policy) {
+	case "", OverlapPolicyReject, OverlapPolicyWarn, OverlapPolicyOff:
+		return nil
+	}
+	return fmt.Errorf("invalid timelog.overlap_policy %q: must be reject, warn or off", policy)
+}
+
+// overlapPolicy 返回当前配置的重叠检测策略，未配置时默认拒绝
+func overlapPolicy() string {
+	if cfg == nil || cfg.Timelog.OverlapPolicy == "" {
+		return OverlapPolicyReject
+	}
+	return strings.ToLower(cfg.Timelog.OverlapPolicy)
+}
+
+// normalizeTimeLog 将时间统一为精确到秒的 UTC，保证数据库中时间字符串可按字典序比较
+func normalizeTimeLog(tl *gen.Timelog) {
+	tl.StartTime = tl.StartTime.UTC().Truncate(time.Second)
+	if tl.EndTime != nil {
+		end := tl.EndTime.UTC().Truncate(time.Second)
+		tl.EndTime = &end
+	}
+}
+
+// validateTimeRange 校验开始/结束时间，结束时间必须晚于开始时间
+func validateTimeRange(tl *gen.Timelog) error {
+	if tl.StartTime.IsZero() {
+		return errors.New("start_time is required")
+	}
+	if tl.EndTime != nil && !tl.EndTime.After(tl.StartTime) {
+		return ErrInvalidTimeRange
+	}
+	return nil
+}
+
+// ValidateTimeLog 校验时间范围，并按配置的策略检测与已有日志的重叠
+// 结束时间早于开始时间总是被拒绝；重叠在 warn 策略下只记录警告
+func ValidateTimeLog(db *gorm.DB, tl *gen.Timelog) error {
+	if err := validateTimeRange(tl); err != nil {
+		return err
+	}
+
+	policy := overlapPolicy()
+	if policy == OverlapPolicyOff {
+		return nil
+	}
+
+	conflicts, err := model.ListTimeLogsOverlapping(db, tl.StartTime, tl.EndTime, tl.ID)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	overlapErr := &OverlapError{Conflicts: conflicts}
+	if policy == OverlapPolicyWarn {
+		if log != nil {
+			log.Warnw("time log overlaps with existing entries",
+				"start_time", tl.StartTime,
+				"end_time", tl.EndTime,
+				"conflicts", overlapErr.Error(),
+			)
+		}
+		return nil
+	}
+	return overlapErr
+}
+
+// TimeGap 一段未记录的时间区间
+type TimeGap struct {
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	DurationMinutes float64   `json:"duration_minutes"`
+}
+
+// DayGapReport 某一天的未记录时间报告
+type DayGapReport struct {
+	Date             string    `json:"date"`
+	DayStart         time.Time `json:"day_start"`
+	DayEnd           time.Time `json:"day_end"`
+	TrackedMinutes   float64   `json:"tracked_minutes"`
+	UntrackedMinutes float64   `json:"untracked_minutes"`
+	Gaps             []TimeGap `json:"gaps"`
+}
+
+// timeInterval 半开区间 [start, end)
+type timeInterval struct {
+	start time.Time
+	end   time.Time
+}
+
+// computeGaps 计算 [from, to) 内未被 intervals 覆盖的区间，intervals 可以无序或相互重叠
+func computeGaps(intervals []timeInterval, from, to time.Time) []TimeGap {
+	gaps := []TimeGap{}
+	if !to.After(from) {
+		return gaps
+	}
+
+	sorted := make([]timeInterval, 0, len(intervals))
+	for _, iv := range intervals {
+		if iv.end.After(from) && iv.start.Before(to) {
+			sorted = append(sorted, iv)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].start.Before(sorted[j].start)
+	})
+
+	cursor := from
+	for _, iv := range sorted {
+		if iv.start.After(cursor) {
+			gaps = append(gaps, newTimeGap(cursor, iv.start))
+		}
+		if iv.end.After(cursor) {
+			cursor = iv.end
+		}
+		if !cursor.Before(to) {
+			return gaps
+		}
+	}
+	if to.After(cursor) {
+		gaps = append(gaps, newTimeGap(cursor, to))
+	}
+	return gaps
+}
+
+func newTimeGap(start, end time.Time) TimeGap {
+	return TimeGap{
+		Start:           start,
+		End:             end,
+		DurationMinutes: end.Sub(start).Minutes(),
+	}
+}
+
+// FindTimeLogGaps 返回指定本地日期（YYYY-MM-DD）内未被任何时间日志覆盖的区间，一天从 day_starts_at 开始
+// 当天尚未过去的部分不计为空档
+func FindTimeLogGaps(dateStr string) (*DayGapReport, error) {
+	loc := model.GetLocation()
+	date, err := model.ParseLocalDate(dateStr)
+	if err != nil {
+		return nil, err
+	}
+	dayStart, dayEnd := model.LocalDayBounds(date)
+
+	now := time.Now()
+	until := dayEnd
+	if now.Before(until) {
+		until = now
+	}
+
+	db := model.GetDao().Db()
+	tls, err := model.ListTimeLogsOverlapping(db, dayStart, &dayEnd, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	intervals := make([]timeInterval, 0, len(tls))
+	for _, tl := range tls {
+		end := now
+		if tl.EndTime != nil {
+			end = *tl.EndTime
+		}
+		intervals = append(intervals, timeInterval{start: tl.StartTime, end: end})
+	}
+
+	gaps := computeGaps(intervals, dayStart, until)
+	report := &DayGapReport{
+		Date:     dateStr,
+		DayStart: dayStart,
+		DayEnd:   dayEnd,
+		Gaps:     gaps,
+	}
+	for i := range gaps {
+		gaps[i].Start = gaps[i].Start.In(loc)
+		gaps[i].End = gaps[i].End.In(loc)
+		report.UntrackedMinutes += gaps[i].DurationMinutes
+	}
+	if until.After(dayStart) {
+		report.TrackedMinutes = until.Sub(dayStart).Minutes() - report.UntrackedMinutes
+	}
+	return report, nil
+}
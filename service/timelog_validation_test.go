@@ -0,0 +1,106 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model/gen"
+)
+
+func at(hour, minute int) time.Time {
+	return time.Date(2026, 10, 17, hour, minute, 0, 0, time.UTC)
+}
+
+func TestComputeGapsUnsortedAndOverlapping(t *testing.T) {
+	intervals := []timeInterval{
+		{start: at(13, 0), end: at(14, 0)},
+		{start: at(9, 0), end: at(10, 30)},
+		{start: at(10, 0), end: at(11, 0)}, // overlaps the previous one
+	}
+
+	gaps := computeGaps(intervals, at(8, 0), at(16, 0))
+
+	expected := []TimeGap{
+		{Start: at(8, 0), End: at(9, 0), DurationMinutes: 60},
+		{Start: at(11, 0), End: at(13, 0), DurationMinutes: 120},
+		{Start: at(14, 0), End: at(16, 0), DurationMinutes: 120},
+	}
+	if len(gaps) != len(expected) {
+		t.Fatalf("Expected %d gaps, got %d: %+v", len(expected), len(gaps), gaps)
+	}
+	for i := range expected {
+		if !gaps[i].Start.Equal(expected[i].Start) || !gaps[i].End.Equal(expected[i].End) || gaps[i].DurationMinutes != expected[i].DurationMinutes {
+			t.Errorf("Gap %d: expected %+v, got %+v", i, expected[i], gaps[i])
+		}
+	}
+}
+
+func TestComputeGapsClipsToWindow(t *testing.T) {
+	intervals := []timeInterval{
+		{start: at(7, 0), end: at(9, 0)},   // starts before the window
+		{start: at(15, 0), end: at(18, 0)}, // ends after the window
+	}
+
+	gaps := computeGaps(intervals, at(8, 0), at(16, 0))
+
+	if len(gaps) != 1 {
+		t.Fatalf("Expected 1 gap, got %d: %+v", len(gaps), gaps)
+	}
+	if !gaps[0].Start.Equal(at(9, 0)) || !gaps[0].End.Equal(at(15, 0)) {
+		t.Errorf("Expected gap 09:00-15:00, got %s-%s", gaps[0].Start, gaps[0].End)
+	}
+}
+
+func TestComputeGapsFullyCoveredAndEmptyWindow(t *testing.T) {
+	covered := computeGaps([]timeInterval{{start: at(0, 0), end: at(23, 0)}}, at(8, 0), at(16, 0))
+	if len(covered) != 0 {
+		t.Errorf("Expected no gaps for a fully covered window, got %+v", covered)
+	}
+
+	empty := computeGaps(nil, at(8, 0), at(8, 0))
+	if len(empty) != 0 {
+		t.Errorf("Expected no gaps for an empty window, got %+v", empty)
+	}
+
+	untracked := computeGaps(nil, at(8, 0), at(9, 0))
+	if len(untracked) != 1 || untracked[0].DurationMinutes != 60 {
+		t.Errorf("Expected the whole window to be a gap, got %+v", untracked)
+	}
+}
+
+func TestValidateTimeRange(t *testing.T) {
+	end := at(9, 0)
+	inverted := &gen.Timelog{StartTime: at(10, 0), EndTime: &end}
+	if err := validateTimeRange(inverted); !errors.Is(err, ErrInvalidTimeRange) {
+		t.Errorf("Expected ErrInvalidTimeRange for end before start, got %v", err)
+	}
+
+	same := at(10, 0)
+	empty := &gen.Timelog{StartTime: at(10, 0), EndTime: &same}
+	if err := validateTimeRange(empty); !errors.Is(err, ErrInvalidTimeRange) {
+		t.Errorf("Expected ErrInvalidTimeRange for zero-length range, got %v", err)
+	}
+
+	open := &gen.Timelog{StartTime: at(10, 0)}
+	if err := validateTimeRange(open); err != nil {
+		t.Errorf("Expected open time log to be valid, got %v", err)
+	}
+
+	if err := validateTimeRange(&gen.Timelog{}); err == nil {
+		t.Error("Expected missing start_time to be rejected")
+	}
+}
+
+func TestValidateOverlapPolicy(t *testing.T) {
+	for _, policy := range []string{"", "reject", "warn", "off", "WARN"} {
+		if err := ValidateOverlapPolicy(policy); err != nil {
+			t.Errorf("Expected %q to be accepted, got %v", policy, err)
+		}
+	}
+	for _, policy := range []string{"warning", "none", "strict"} {
+		if err := ValidateOverlapPolicy(policy); err == nil {
+			t.Errorf("Expected %q to be rejected", policy)
+		}
+	}
+}
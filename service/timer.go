@@ -0,0 +1,205 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/model/gen"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTimerAlreadyRunning = errors.New("a timer is already running, stop or switch it first")
+	ErrNoRunningTimer      = errors.New("no timer is running")
+)
+
+// timerMu 串行化进程内的计时器操作；检查与写入都在事务中，其他进程的写入由 SQLite 的写锁串行化，
+// 二者共同保证最多只有一条未结束的时间日志
+var timerMu sync.Mutex
+
+// TimerRequest 启动/切换计时器的参数
+type TimerRequest struct {
+	CategoryID int32   `json:"category_id" binding:"required"`
+	TaskID     *int32  `json:"task_id"`
+	Remark     *string `json:"remark"`
+}
+
+// RunningTimer 正在计时的时间日志及已用时长
+type RunningTimer struct {
+	Timelog        *gen.Timelog `json:"timelog"`
+	ElapsedSeconds int64        `json:"elapsed_seconds"`
+}
+
+// TimerSwitchResult 切换计时器的结果
+type TimerSwitchResult struct {
+	Stopped []gen.Timelog `json:"stopped"`
+	Running *RunningTimer `json:"running"`
+}
+
+// nowUTC 返回精确到秒的 UTC 当前时间，保证数据库中时间字符串可按字典序比较
+func nowUTC() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+func newRunningTimer(tl *gen.Timelog, now time.Time) *RunningTimer {
+	return &RunningTimer{
+		Timelog:        tl,
+		ElapsedSeconds: int64(now.Sub(tl.StartTime) / time.Second),
+	}
+}
+
+// GetRunningTimer 获取当前计时器，没有正在计时的日志时返回 nil
+func GetRunningTimer() (*RunningTimer, error) {
+	db := model.GetDao().Db()
+	open, err := model.ListOpenTimeLogs(db)
+	if err != nil {
+		return nil, err
+	}
+	if len(open) == 0 {
+		return nil, nil
+	}
+	return newRunningTimer(&open[0], time.Now()), nil
+}
+
+// StartTimer 启动计时器，已有计时器运行时返回 ErrTimerAlreadyRunning
+func StartTimer(req TimerRequest) (*RunningTimer, error) {
+	timerMu.Lock()
+	defer timerMu.Unlock()
+
+	var started *gen.Timelog
+	now := nowUTC()
+	err := model.GetDao().Db().Transaction(func(tx *gorm.DB) error {
+		open, err := model.ListOpenTimeLogs(tx)
+		if err != nil {
+			return err
+		}
+		if len(open) > 0 {
+			return ErrTimerAlreadyRunning
+		}
+
+		started, err = openTimeLog(tx, req, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newRunningTimer(started, now), nil
+}
+
+// StopTimer 停止计时器，结束所有未结束的时间日志并返回它们
+func StopTimer() ([]gen.Timelog, error) {
+	timerMu.Lock()
+	defer timerMu.Unlock()
+
+	var stopped []gen.Timelog
+	err := model.GetDao().Db().Transaction(func(tx *gorm.DB) error {
+		var err error
+		stopped, err = closeOpenTimeLogs(tx, nowUTC())
+		if err != nil {
+			return err
+		}
+		if len(stopped) == 0 {
+			return ErrNoRunningTimer
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stopped, nil
+}
+
+// SwitchTimer 原子地结束当前计时器并以新的分类/任务开始计时
+// 没有正在计时的日志时等同于 StartTimer
+func SwitchTimer(req TimerRequest) (*TimerSwitchResult, error) {
+	timerMu.Lock()
+	defer timerMu.Unlock()
+
+	result := &TimerSwitchResult{}
+	now := nowUTC()
+	err := model.GetDao().Db().Transaction(func(tx *gorm.DB) error {
+		var err error
+		result.Stopped, err = closeOpenTimeLogs(tx, now)
+		if err != nil {
+			return err
+		}
+
+		started, err := openTimeLog(tx, req, now)
+		if err != nil {
+			return err
+		}
+		result.Running = newRunningTimer(started, now)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// openTimeLog 在事务内创建一条从 now 开始的未结束时间日志
+func openTimeLog(tx *gorm.DB, req TimerRequest, now time.Time) (*gen.Timelog, error) {
+	if _, err := model.GetCategoryByID(tx, req.CategoryID); err != nil {
+		return nil, fmt.Errorf("category %d not found: %w", req.CategoryID, err)
+	}
+	if err := ensureCategoryActive(tx, req.CategoryID); err != nil {
+		return nil, err
+	}
+	if req.TaskID != nil {
+		if _, err := model.GetTaskByID(tx, *req.TaskID); err != nil {
+			return nil, fmt.Errorf("task %d not found: %w", *req.TaskID, err)
+		}
+	}
+
+	tl := &gen.Timelog{
+		StartTime:  now,
+		CategoryID: req.CategoryID,
+		TaskID:     req.TaskID,
+		Remark:     req.Remark,
+	}
+	if err := ValidateTimeLog(tx, tl); err != nil {
+		return nil, err
+	}
+	if err := model.CreateTimeLog(tx, tl); err != nil {
+		return nil, err
+	}
+	return model.GetTimeLogByID(tx, *tl.ID)
+}
+
+// closeOpenTimeLogs 在事务内将所有未结束的时间日志结束于 now
+func closeOpenTimeLogs(tx *gorm.DB, now time.Time) ([]gen.Timelog, error) {
+	open, err := model.ListOpenTimeLogs(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range open {
+		end := now
+		// 开始时间在未来的异常数据，结束时间不早于开始时间
+		if end.Before(open[i].StartTime) {
+			end = open[i].StartTime
+		}
+		if err := model.CloseTimeLog(tx, *open[i].ID, end); err != nil {
+			return nil, err
+		}
+		open[i].EndTime = &end
+	}
+	return open, nil
+}
+
+// ensureNoOtherOpenTimeLog 检查除 excludeID 外是否已存在未结束的时间日志
+func ensureNoOtherOpenTimeLog(db *gorm.DB, excludeID *int32) error {
+	open, err := model.ListOpenTimeLogs(db)
+	if err != nil {
+		return err
+	}
+	for _, tl := range open {
+		if excludeID == nil || *tl.ID != *excludeID {
+			return ErrTimerAlreadyRunning
+		}
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+package integration_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/model/gen"
+	"github.com/blacksheepaul/timelog/service"
+)
+
+func resetArchiveData(t *testing.T) {
+	t.Helper()
+	resetCategoryData(t)
+	if err := model.GetDao().Db().Exec("DELETE FROM constraints").Error; err != nil {
+		t.Fatalf("Failed to clean constraints: %v", err)
+	}
+}
+
+// seedArchiveData 创建 Work/Coding 分类、一个约束、一个进行中的任务、一个已删除的任务和两条时间日志
+func seedArchiveData(t *testing.T) (coding *gen.Category, tl *gen.Timelog) {
+	t.Helper()
+	resetArchiveData(t)
+	db := model.GetDao().Db()
+
+	work := mustCreateCategory(t, "Work", nil)
+	coding = mustCreateCategory(t, "Coding", work.ID)
+
+	start := time.Date(2025, 4, 1, 1, 30, 0, 0, time.UTC)
+	constraint := &gen.Constraint{Description: "No phone", PunishmentQuote: "Try again", StartDate: start}
+	if err := model.CreateConstraint(db, constraint); err != nil {
+		t.Fatalf("Failed to create constraint: %v", err)
+	}
+	task := &gen.Task{Title: "Ship export", CategoryID: *coding.ID, DueDate: start, EstimatedMinutes: 45}
+	if err := model.CreateTask(db, task); err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	dropped := &gen.Task{Title: "Dropped", CategoryID: *coding.ID, DueDate: start, EstimatedMinutes: 10}
+	if err := model.CreateTask(db, dropped); err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	if err := model.DeleteTask(db, *dropped.ID); err != nil {
+		t.Fatalf("Failed to delete task: %v", err)
+	}
+
+	remark := "archive me"
+	tl = &gen.Timelog{StartTime: start, EndTime: ptrTime(start.Add(time.Hour)), CategoryID: *coding.ID, TaskID: task.ID, Remark: &remark}
+	if err := model.CreateTimeLog(db, tl); err != nil {
+		t.Fatalf("Failed to create timelog: %v", err)
+	}
+	if err := model.CreateTimeLog(db, &gen.Timelog{StartTime: start.Add(2 * time.Hour), CategoryID: *work.ID}); err != nil {
+		t.Fatalf("Failed to create timelog: %v", err)
+	}
+	return coding, tl
+}
+
+func exportArchive(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := service.ExportArchive(&buf); err != nil {
+		t.Fatalf("ExportArchive failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func countRows(t *testing.T, table string) int64 {
+	t.Helper()
+	var count int64
+	if err := model.GetDao().Db().Table(table).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestArchiveRestoreKeepsIDs(t *testing.T) {
+	coding, tl := seedArchiveData(t)
+	archive := exportArchive(t)
+	if strings.Contains(string(archive), "webauthn") || strings.Contains(string(archive), "token_hash") {
+		t.Fatalf("Archive must not contain credentials")
+	}
+
+	// 模拟刚迁移的数据库：只有一个默认分类
+	resetArchiveData(t)
+	mustCreateCategory(t, "Default", nil)
+
+	report, err := service.ImportArchive(bytes.NewReader(archive), model.ArchiveImportRestore, true)
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if !report.DryRun || report.Categories.Created != 2 || report.Tasks.Created != 2 || report.TimeLogs.Created != 2 || report.Constraints.Created != 1 {
+		t.Errorf("Unexpected dry run report: %+v", report)
+	}
+	if n := countRows(t, "timelogs"); n != 0 {
+		t.Fatalf("Dry run must not write, found %d timelogs", n)
+	}
+
+	if _, err := service.ImportArchive(bytes.NewReader(archive), model.ArchiveImportRestore, false); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	db := model.GetDao().Db()
+	restored, err := model.GetTimeLogByID(db, *tl.ID)
+	if err != nil {
+		t.Fatalf("Timelog %d not restored: %v", *tl.ID, err)
+	}
+	if restored.CategoryID != *coding.ID || restored.TaskID == nil || *restored.TaskID != *tl.TaskID || !restored.StartTime.Equal(tl.StartTime) {
+		t.Errorf("Restored timelog differs: %+v", restored)
+	}
+	if _, err := model.GetCategoryByName(db, "Default", nil); err == nil {
+		t.Errorf("Restore should replace the seeded categories")
+	}
+	var deleted int64
+	db.Unscoped().Model(&gen.Task{}).Where("deleted_at IS NOT NULL").Count(&deleted)
+	if deleted != 1 {
+		t.Errorf("Expected the soft-deleted task to be restored as deleted, got %d", deleted)
+	}
+
+	if _, err := service.ImportArchive(bytes.NewReader(archive), model.ArchiveImportRestore, false); !errors.Is(err, model.ErrArchiveTargetNotEmpty) {
+		t.Errorf("Expected ErrArchiveTargetNotEmpty on second restore, got %v", err)
+	}
+	report, err = service.ImportArchive(bytes.NewReader(archive), model.ArchiveImportMerge, false)
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if report.Categories.Created+report.Tasks.Created+report.TimeLogs.Created+report.Constraints.Created != 0 {
+		t.Errorf("Merging the same archive again should be a no-op: %+v", report)
+	}
+}
+
+func TestArchiveMergeRemapsIDs(t *testing.T) {
+	_, tl := seedArchiveData(t)
+	archive := exportArchive(t)
+
+	// 目标库已有 Work 分类和不相关的数据，ID 与归档不同
+	resetArchiveData(t)
+	mustCreateCategory(t, "Life", nil)
+	work := mustCreateCategory(t, "Work", nil)
+
+	report, err := service.ImportArchive(bytes.NewReader(archive), "", false)
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if report.Mode != model.ArchiveImportMerge || report.Categories.Existing != 1 || report.Categories.Created != 1 || report.TimeLogs.Created != 2 {
+		t.Errorf("Unexpected merge report: %+v", report)
+	}
+
+	db := model.GetDao().Db()
+	coding, err := model.GetCategoryByName(db, "Coding", work.ID)
+	if err != nil {
+		t.Fatalf("Coding should be created under the existing Work: %v", err)
+	}
+	if coding.Path == nil || *coding.Path != "/Work" {
+		t.Errorf("Unexpected path for merged category: %v", coding.Path)
+	}
+	var merged gen.Timelog
+	if err := db.Where("remark = ?", "archive me").First(&merged).Error; err != nil {
+		t.Fatal(err)
+	}
+	task, err := model.GetTaskByID(db, *merged.TaskID)
+	if err != nil || task.Title != "Ship export" || merged.CategoryID != *coding.ID || !merged.StartTime.Equal(tl.StartTime) {
+		t.Errorf("References were not remapped: timelog %+v, task %+v (%v)", merged, task, err)
+	}
+
+	report, err = service.ImportArchive(bytes.NewReader(archive), model.ArchiveImportMerge, false)
+	if err != nil {
+		t.Fatalf("Second merge failed: %v", err)
+	}
+	if report.TimeLogs.Existing != 2 || report.Tasks.Existing != 2 || report.Constraints.Existing != 1 || report.TimeLogs.Created != 0 {
+		t.Errorf("Second merge should match every record: %+v", report)
+	}
+	if n := countRows(t, "timelogs"); n != 2 {
+		t.Errorf("Expected 2 timelogs after merging twice, got %d", n)
+	}
+}
+
+func TestArchiveRejectsInvalidInput(t *testing.T) {
+	cases := map[string]string{
+		"not an archive": `{"format":"other","version":1}`,
+		"newer version":  `{"format":"timelog-archive","version":2}`,
+		"missing parent": `{"format":"timelog-archive","version":1,"categories":[{"id":2,"name":"Child","parent_id":1}]}`,
+		"missing task": `{"format":"timelog-archive","version":1,"categories":[{"id":1,"name":"Root"}],
+			"timelogs":[{"id":1,"start_time":"2025-01-01T00:00:00Z","category_id":1,"task_id":9}]}`,
+		"category cycle": `{"format":"timelog-archive","version":1,"categories":[{"id":1,"name":"A","parent_id":2},{"id":2,"name":"B","parent_id":1}]}`,
+	}
+	for name, body := range cases {
+		if _, err := service.ImportArchive(strings.NewReader(body), model.ArchiveImportMerge, true); !errors.Is(err, model.ErrInvalidArchive) {
+			t.Errorf("%s: expected ErrInvalidArchive, got %v", name, err)
+		}
+	}
+}
+
+func TestArchiveMergeChecksTimeLogConflicts(t *testing.T) {
+	_, tl := seedArchiveData(t)
+	archive := exportArchive(t)
+
+	// 目标库中有一条与归档日志重叠的日志和一个正在运行的计时
+	resetArchiveData(t)
+	life := mustCreateCategory(t, "Life", nil)
+	overlapping := &gen.Timelog{StartTime: tl.StartTime.Add(30 * time.Minute), EndTime: ptrTime(tl.StartTime.Add(90 * time.Minute)), CategoryID: *life.ID}
+	running := &gen.Timelog{StartTime: tl.StartTime.Add(5 * time.Hour), CategoryID: *life.ID}
+	for _, existing := range []*gen.Timelog{overlapping, running} {
+		if err := service.CreateTimeLog(existing); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := service.ImportArchive(bytes.NewReader(archive), model.ArchiveImportMerge, true)
+	if err != nil {
+		t.Fatalf("Dry run should report conflicts without failing: %v", err)
+	}
+	if len(report.TimeLogConflicts) != 2 {
+		t.Fatalf("Expected 2 conflicts, got %+v", report.TimeLogConflicts)
+	}
+	overlap, open := report.TimeLogConflicts[0], report.TimeLogConflicts[1]
+	if overlap.TimeLogID != *tl.ID || !overlap.Rejected || len(overlap.Overlaps) != 1 || overlap.Overlaps[0] != *overlapping.ID {
+		t.Errorf("Unexpected overlap conflict: %+v", overlap)
+	}
+	if !open.Rejected || open.EndTime != nil || !strings.Contains(open.Message, service.ErrTimerAlreadyRunning.Error()) {
+		t.Errorf("Expected the open archived log to be rejected beside the running timer: %+v", open)
+	}
+
+	report, err = service.ImportArchive(bytes.NewReader(archive), model.ArchiveImportMerge, false)
+	if !errors.Is(err, model.ErrArchiveConflicts) || report == nil || len(report.TimeLogConflicts) != 2 {
+		t.Fatalf("Expected ErrArchiveConflicts with a report, got %+v (%v)", report, err)
+	}
+	if n := countRows(t, "timelogs"); n != 2 {
+		t.Errorf("A rejected merge must not write time logs, found %d", n)
+	}
+	if n := countOpenTimeLogs(t); n != 1 {
+		t.Errorf("Expected only the running timer to stay open, found %d", n)
+	}
+	if _, err := model.GetCategoryByName(model.GetDao().Db(), "Work", nil); !errors.Is(err, model.ErrRecordNotFound) {
+		t.Errorf("A rejected merge must roll back categories, got %v", err)
+	}
+}
+
+func TestArchiveMergeRestoresSoftDeletedCategories(t *testing.T) {
+	_, tl := seedArchiveData(t)
+	archive := exportArchive(t)
+
+	// 目标库中同名的根分类一个已删除一个仍在使用，Coding 只有已删除的一条
+	resetArchiveData(t)
+	db := model.GetDao().Db()
+	deletedWork := mustCreateCategory(t, "Work", nil)
+	if err := db.Delete(&gen.Category{}, *deletedWork.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	work := mustCreateCategory(t, "Work", nil)
+	coding := mustCreateCategory(t, "Coding", work.ID)
+	if err := db.Delete(&gen.Category{}, *coding.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := service.ImportArchive(bytes.NewReader(archive), model.ArchiveImportMerge, false)
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if report.Categories.Existing != 2 || report.Categories.Restored != 1 || report.Categories.Created != 0 {
+		t.Errorf("Unexpected category counts: %+v", report.Categories)
+	}
+
+	restored, err := service.GetCategoryByID(*coding.ID)
+	if err != nil || *restored.ParentID != *work.ID {
+		t.Fatalf("Expected the soft-deleted Coding under the live Work to be restored: %+v (%v)", restored, err)
+	}
+	if _, err := service.GetCategoryByID(*deletedWork.ID); !errors.Is(err, model.ErrRecordNotFound) {
+		t.Errorf("The soft-deleted Work should stay deleted when a live one matches, got %v", err)
+	}
+	var merged gen.Timelog
+	if err := db.Where("remark = ?", "archive me").First(&merged).Error; err != nil {
+		t.Fatal(err)
+	}
+	if merged.CategoryID != *coding.ID || !merged.StartTime.Equal(tl.StartTime) {
+		t.Errorf("Expected the merged time log in the restored Coding, got %+v", merged)
+	}
+}
@@ -0,0 +1,138 @@
+package integration_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/model/gen"
+	"github.com/blacksheepaul/timelog/service"
+)
+
+func categoryNames(categories []gen.Category) map[string]bool {
+	names := map[string]bool{}
+	for _, category := range categories {
+		names[category.Name] = true
+	}
+	return names
+}
+
+func TestArchivedCategoryHiddenButKeepsHistory(t *testing.T) {
+	resetCategoryData(t)
+
+	work := mustCreateCategory(t, "Work", nil)
+	coding := mustCreateCategory(t, "Coding", work.ID)
+	mustCreateCategory(t, "Life", nil)
+
+	start := time.Date(2025, 1, 6, 1, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	tl := &gen.Timelog{StartTime: start, EndTime: &end, CategoryID: *coding.ID}
+	if err := service.CreateTimeLog(tl); err != nil {
+		t.Fatalf("Failed to create timelog: %v", err)
+	}
+
+	if _, err := service.ArchiveCategory(*work.ID); err != nil {
+		t.Fatalf("ArchiveCategory failed: %v", err)
+	}
+
+	active, err := service.ListCategories(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := categoryNames(active); names["Work"] || names["Coding"] || !names["Life"] {
+		t.Errorf("Archived subtree should be hidden, got %v", names)
+	}
+	all, _ := service.ListCategories(true)
+	if names := categoryNames(all); !names["Work"] || !names["Coding"] {
+		t.Errorf("include_archived should list the archived subtree, got %v", names)
+	}
+	tree, _ := service.GetCategoryTree(false)
+	if len(tree) != 1 || tree[0].Category.Name != "Life" {
+		t.Errorf("Expected only Life in the active tree, got %d roots", len(tree))
+	}
+
+	// 不能再新建时间日志、任务或子分类
+	later := end.Add(time.Hour)
+	if err := service.CreateTimeLog(&gen.Timelog{StartTime: end, EndTime: &later, CategoryID: *coding.ID}); !errors.Is(err, service.ErrCategoryArchived) {
+		t.Errorf("Expected ErrCategoryArchived for timelog, got %v", err)
+	}
+	if err := service.CreateTask(&gen.Task{Title: "Old", CategoryID: *work.ID, DueDate: start, EstimatedMinutes: 10}); !errors.Is(err, service.ErrCategoryArchived) {
+		t.Errorf("Expected ErrCategoryArchived for task, got %v", err)
+	}
+	if err := service.CreateCategory(&gen.Category{Name: "Review", ParentID: coding.ID}); !errors.Is(err, service.ErrCategoryArchived) {
+		t.Errorf("Expected ErrCategoryArchived for child category, got %v", err)
+	}
+
+	// 历史日志仍可编辑，统计中仍然可见
+	remark := "kept"
+	tl.Remark = &remark
+	if err := service.UpdateTimeLog(tl); err != nil {
+		t.Errorf("Editing a historical timelog should still work: %v", err)
+	}
+	stats, err := service.GetStats("2025-01-06", "2025-01-06", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Categories) == 0 || stats.Categories[0].CategoryID != *work.ID || stats.Categories[0].TotalMinutes != 60 {
+		t.Errorf("Archived category should still appear in stats, got %+v", stats.Categories)
+	}
+
+	if _, err := service.UnarchiveCategory(*work.ID); err != nil {
+		t.Fatalf("UnarchiveCategory failed: %v", err)
+	}
+	active, _ = service.ListCategories(false)
+	if names := categoryNames(active); !names["Work"] || !names["Coding"] {
+		t.Errorf("Unarchived subtree should be visible again, got %v", names)
+	}
+	if err := service.CreateTimeLog(&gen.Timelog{StartTime: end, EndTime: &later, CategoryID: *coding.ID}); err != nil {
+		t.Errorf("Expected timelog on unarchived category to succeed, got %v", err)
+	}
+}
+
+func TestReorderCategoriesSkipsArchivedSiblings(t *testing.T) {
+	resetCategoryData(t)
+
+	root := mustCreateCategory(t, "Root", nil)
+	a := mustCreateCategory(t, "A", root.ID)
+	b := mustCreateCategory(t, "B", root.ID)
+	old := mustCreateCategory(t, "Old", root.ID)
+	if _, err := service.ArchiveCategory(*old.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.ReorderCategories([]int32{*b.ID, *a.ID}); err != nil {
+		t.Errorf("Archived siblings should be optional when reordering: %v", err)
+	}
+	if err := service.ReorderCategories([]int32{*b.ID, *old.ID}); !errors.Is(err, model.ErrInvalidCategoryOrder) {
+		t.Errorf("Active siblings are still required, got %v", err)
+	}
+}
+
+func TestUpdateCategoryKeepsArchiveState(t *testing.T) {
+	resetCategoryData(t)
+
+	work := mustCreateCategory(t, "Work", nil)
+	life := mustCreateCategory(t, "Life", nil)
+	if _, err := service.ArchiveCategory(*work.ID); err != nil {
+		t.Fatalf("ArchiveCategory failed: %v", err)
+	}
+
+	// 只修改名称不能取消归档
+	if err := service.UpdateCategory(&gen.Category{ID: work.ID, Name: "Old work"}); err != nil {
+		t.Fatalf("UpdateCategory failed: %v", err)
+	}
+	renamed, err := service.GetCategoryByID(*work.ID)
+	if err != nil || renamed.Name != "Old work" || renamed.ArchivedAt == nil {
+		t.Errorf("Expected the renamed category to stay archived, got %+v (%v)", renamed, err)
+	}
+
+	// 也不能通过普通更新归档
+	now := time.Now()
+	if err := service.UpdateCategory(&gen.Category{ID: life.ID, Name: "Life", ArchivedAt: &now}); err != nil {
+		t.Fatalf("UpdateCategory failed: %v", err)
+	}
+	if updated, _ := service.GetCategoryByID(*life.ID); updated.ArchivedAt != nil {
+		t.Errorf("A plain update must not archive the category, got archived_at %v", updated.ArchivedAt)
+	}
+}
@@ -0,0 +1,200 @@
+package integration_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/model/gen"
+	"github.com/blacksheepaul/timelog/service"
+)
+
+func resetCategoryData(t *testing.T) {
+	db := model.GetDao().Db()
+	for _, table := range []string{"timelogs", "tasks", "categories"} {
+		if err := db.Exec("DELETE FROM " + table).Error; err != nil {
+			t.Fatalf("Failed to clean %s: %v", table, err)
+		}
+	}
+}
+
+func mustCreateCategory(t *testing.T, name string, parentID *int32) *gen.Category {
+	t.Helper()
+	category := &gen.Category{Name: name, ParentID: parentID}
+	if err := service.CreateCategory(category); err != nil {
+		t.Fatalf("Failed to create category %s: %v", name, err)
+	}
+	return category
+}
+
+func TestMergeCategoryReassignsDataAndMergesChildren(t *testing.T) {
+	resetCategoryData(t)
+	db := model.GetDao().Db()
+
+	// Work -> Coding, Work -> Meeting; 重复的 work -> Coding -> Review, work -> Reading
+	work := mustCreateCategory(t, "Work", nil)
+	coding := mustCreateCategory(t, "Coding", work.ID)
+	mustCreateCategory(t, "Meeting", work.ID)
+	dup := mustCreateCategory(t, "work", nil)
+	dupCoding := mustCreateCategory(t, "Coding", dup.ID)
+	review := mustCreateCategory(t, "Review", dupCoding.ID)
+	reading := mustCreateCategory(t, "Reading", dup.ID)
+
+	start := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	logs := []*gen.Timelog{
+		{StartTime: start, EndTime: &end, CategoryID: *dup.ID},
+		{StartTime: end, EndTime: ptrTime(end.Add(time.Hour)), CategoryID: *dupCoding.ID},
+	}
+	for _, tl := range logs {
+		if err := model.CreateTimeLog(db, tl); err != nil {
+			t.Fatalf("Failed to create timelog: %v", err)
+		}
+	}
+	task := &gen.Task{Title: "Write report", CategoryID: *dupCoding.ID, DueDate: start, EstimatedMinutes: 30}
+	if err := model.CreateTask(db, task); err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	result, err := service.MergeCategory(*dup.ID, *work.ID)
+	if err != nil {
+		t.Fatalf("MergeCategory failed: %v", err)
+	}
+	if result.TimeLogs != 2 || result.Tasks != 1 || result.MergedCategories != 2 || result.MovedCategories != 2 {
+		t.Errorf("Unexpected merge result: %+v", result)
+	}
+
+	if _, err := service.GetCategoryByID(*dup.ID); !errors.Is(err, model.ErrRecordNotFound) {
+		t.Errorf("Expected source category to be deleted, got %v", err)
+	}
+	if _, err := service.GetCategoryByID(*dupCoding.ID); !errors.Is(err, model.ErrRecordNotFound) {
+		t.Errorf("Expected duplicate child to be merged away, got %v", err)
+	}
+
+	tl0, _ := service.GetTimeLogByID(*logs[0].ID)
+	tl1, _ := service.GetTimeLogByID(*logs[1].ID)
+	if tl0.CategoryID != *work.ID || tl1.CategoryID != *coding.ID {
+		t.Errorf("Timelogs not reassigned: got %d and %d", tl0.CategoryID, tl1.CategoryID)
+	}
+	movedTask, _ := model.GetTaskByID(db, *task.ID)
+	if movedTask.CategoryID != *coding.ID {
+		t.Errorf("Task not reassigned: got category %d, want %d", movedTask.CategoryID, *coding.ID)
+	}
+
+	movedReview, err := service.GetCategoryByID(*review.ID)
+	if err != nil {
+		t.Fatalf("Review category missing: %v", err)
+	}
+	if *movedReview.ParentID != *coding.ID || *movedReview.Level != 2 || model.GetFullPath(movedReview) != "/Work/Coding/Review" {
+		t.Errorf("Review not relocated: parent=%d level=%d path=%s", *movedReview.ParentID, *movedReview.Level, model.GetFullPath(movedReview))
+	}
+	movedReading, _ := service.GetCategoryByID(*reading.ID)
+	if *movedReading.ParentID != *work.ID || model.GetFullPath(movedReading) != "/Work/Reading" {
+		t.Errorf("Reading not relocated: parent=%d path=%s", *movedReading.ParentID, model.GetFullPath(movedReading))
+	}
+}
+
+func TestDeleteCategoryRejectsInvalidTarget(t *testing.T) {
+	resetCategoryData(t)
+
+	root := mustCreateCategory(t, "Root", nil)
+	child := mustCreateCategory(t, "Child", root.ID)
+
+	if _, err := service.DeleteCategory(*root.ID, *root.ID); !errors.Is(err, model.ErrInvalidCategoryTarget) {
+		t.Errorf("Expected ErrInvalidCategoryTarget for self target, got %v", err)
+	}
+	if _, err := service.DeleteCategory(*root.ID, *child.ID); !errors.Is(err, model.ErrInvalidCategoryTarget) {
+		t.Errorf("Expected ErrInvalidCategoryTarget for descendant target, got %v", err)
+	}
+	if _, err := service.DeleteCategory(*root.ID, 99999); !errors.Is(err, model.ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound for missing target, got %v", err)
+	}
+
+	// 失败的操作不应留下任何修改
+	if _, err := service.GetCategoryByID(*child.ID); err != nil {
+		t.Errorf("Child category should still exist: %v", err)
+	}
+}
+
+func TestDeleteCategoryRollsBackWhenTooDeep(t *testing.T) {
+	resetCategoryData(t)
+
+	a := mustCreateCategory(t, "A", nil)
+	a1 := mustCreateCategory(t, "A1", a.ID)
+	mustCreateCategory(t, "A2", a1.ID)
+	b := mustCreateCategory(t, "B", nil)
+	b1 := mustCreateCategory(t, "B1", b.ID)
+
+	// A 的子树高度为 2，挂到第 1 层的 B1 下会超出最大层级
+	if _, err := service.DeleteCategory(*a.ID, *b1.ID); !errors.Is(err, model.ErrInvalidCategoryTarget) {
+		t.Fatalf("Expected ErrInvalidCategoryTarget when children would exceed max level, got %v", err)
+	}
+	if _, err := service.GetCategoryByID(*a.ID); err != nil {
+		t.Errorf("Source category should survive a failed delete: %v", err)
+	}
+	restored, _ := service.GetCategoryByID(*a1.ID)
+	if restored.ParentID == nil || *restored.ParentID != *a.ID {
+		t.Errorf("Child should keep its parent after rollback")
+	}
+}
+
+func TestMergeCategoryRelocatesSoftDeletedChildren(t *testing.T) {
+	resetCategoryData(t)
+	db := model.GetDao().Db()
+
+	source := mustCreateCategory(t, "Source", nil)
+	old := mustCreateCategory(t, "Old", source.ID)
+	keep := mustCreateCategory(t, "Keep", source.ID)
+	target := mustCreateCategory(t, "Target", nil)
+	deletedKeep := mustCreateCategory(t, "Keep", target.ID)
+
+	start := time.Date(2024, 1, 15, 1, 0, 0, 0, time.UTC)
+	oldLog := &gen.Timelog{StartTime: start, EndTime: ptrTime(start.Add(time.Hour)), CategoryID: *old.ID}
+	keepLog := &gen.Timelog{StartTime: start.Add(2 * time.Hour), EndTime: ptrTime(start.Add(3 * time.Hour)), CategoryID: *keep.ID}
+	for _, tl := range []*gen.Timelog{oldLog, keepLog} {
+		if err := service.CreateTimeLog(tl); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 软删除 Source/Old 与 Target/Keep
+	for _, id := range []int32{*old.ID, *deletedKeep.ID} {
+		if err := db.Delete(&gen.Category{}, id).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := service.MergeCategory(*source.ID, *target.ID)
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if result.MovedCategories != 0 || result.MergedCategories != 2 {
+		t.Errorf("Unexpected merge result: %+v", result)
+	}
+
+	var relocated gen.Category
+	if err := db.Unscoped().First(&relocated, *old.ID).Error; err != nil {
+		t.Fatalf("The soft-deleted child must not be cascade-deleted: %v", err)
+	}
+	if !relocated.DeletedAt.Valid || relocated.ParentID == nil || *relocated.ParentID != *target.ID ||
+		*relocated.Level != 1 || model.GetFullPath(&relocated) != "/Target/Old" {
+		t.Errorf("Expected the soft-deleted child to stay deleted under Target with a fresh path: %+v", relocated)
+	}
+	var stillOld gen.Timelog
+	if err := db.First(&stillOld, *oldLog.ID).Error; err != nil || stillOld.CategoryID != *old.ID {
+		t.Errorf("Time logs of the soft-deleted child should keep their category: %+v (%v)", stillOld, err)
+	}
+
+	restored, err := model.GetCategoryByName(db, "Keep", target.ID)
+	if err != nil || *restored.ID != *deletedKeep.ID {
+		t.Fatalf("Expected the deleted Target/Keep to be restored for the live Source/Keep: %v", err)
+	}
+	var moved gen.Timelog
+	if err := db.First(&moved, *keepLog.ID).Error; err != nil || moved.CategoryID != *deletedKeep.ID {
+		t.Errorf("Expected the live child's time log to move onto the restored category: %+v (%v)", moved, err)
+	}
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,70 @@
+package integration_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/model/gen"
+	"github.com/blacksheepaul/timelog/service"
+)
+
+func TestReorderCategories(t *testing.T) {
+	resetCategoryData(t)
+
+	root := mustCreateCategory(t, "Root", nil)
+	a := mustCreateCategory(t, "A", root.ID)
+	b := mustCreateCategory(t, "B", root.ID)
+	c := mustCreateCategory(t, "C", root.ID)
+	other := mustCreateCategory(t, "Other", nil)
+
+	if err := service.ReorderCategories([]int32{*c.ID, *a.ID, *b.ID}); err != nil {
+		t.Fatalf("ReorderCategories failed: %v", err)
+	}
+	children, err := service.GetCategoriesByParentID(root.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, child := range children {
+		names = append(names, child.Name)
+	}
+	if len(names) != 3 || names[0] != "C" || names[1] != "A" || names[2] != "B" {
+		t.Errorf("Expected order C, A, B, got %v", names)
+	}
+
+	invalid := [][]int32{
+		{},
+		{*a.ID, *b.ID},            // 缺少兄弟分类
+		{*a.ID, *b.ID, *other.ID}, // 不同父分类
+		{*a.ID, *a.ID, *b.ID},     // 重复
+	}
+	for _, ids := range invalid {
+		if err := service.ReorderCategories(ids); !errors.Is(err, model.ErrInvalidCategoryOrder) {
+			t.Errorf("Expected ErrInvalidCategoryOrder for %v, got %v", ids, err)
+		}
+	}
+}
+
+func TestConfigurableCategoryDepth(t *testing.T) {
+	resetCategoryData(t)
+	model.SetCategoryMaxDepth(4)
+	defer model.SetCategoryMaxDepth(0)
+
+	area := mustCreateCategory(t, "Area", nil)
+	project := mustCreateCategory(t, "Project", area.ID)
+	component := mustCreateCategory(t, "Component", project.ID)
+	activity := mustCreateCategory(t, "Activity", component.ID)
+	if *activity.Level != 3 || model.GetFullPath(activity) != "/Area/Project/Component/Activity" {
+		t.Errorf("Unexpected fourth level category: level=%d path=%s", *activity.Level, model.GetFullPath(activity))
+	}
+
+	if err := service.CreateCategory(&gen.Category{Name: "Too deep", ParentID: activity.ID}); err == nil {
+		t.Error("Expected a fifth level to be rejected")
+	}
+
+	model.SetCategoryMaxDepth(3)
+	if err := service.CreateCategory(&gen.Category{Name: "Step", ParentID: component.ID}); err == nil {
+		t.Error("Expected the default depth to reject a fourth level")
+	}
+}
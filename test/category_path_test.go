@@ -0,0 +1,173 @@
+package integration_test
+
+import (
+	"testing"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/model/gen"
+	"github.com/blacksheepaul/timelog/service"
+)
+
+func fullPathOf(t *testing.T, id int32) string {
+	t.Helper()
+	category, err := service.GetCategoryByID(id)
+	if err != nil {
+		t.Fatalf("Failed to load category %d: %v", id, err)
+	}
+	return model.GetFullPath(category)
+}
+
+func TestRenameCategoryUpdatesDescendantPaths(t *testing.T) {
+	resetCategoryData(t)
+
+	work := mustCreateCategory(t, "Work", nil)
+	coding := mustCreateCategory(t, "Coding", work.ID)
+	review := mustCreateCategory(t, "Review", coding.ID)
+
+	renamed := &gen.Category{ID: work.ID, Name: "Job"}
+	if err := service.UpdateCategory(renamed); err != nil {
+		t.Fatalf("UpdateCategory failed: %v", err)
+	}
+
+	if got := fullPathOf(t, *coding.ID); got != "/Job/Coding" {
+		t.Errorf("Expected /Job/Coding, got %s", got)
+	}
+	if got := fullPathOf(t, *review.ID); got != "/Job/Coding/Review" {
+		t.Errorf("Expected /Job/Coding/Review, got %s", got)
+	}
+}
+
+func TestRelocateCategoryUpdatesSoftDeletedDescendants(t *testing.T) {
+	resetCategoryData(t)
+	db := model.GetDao().Db()
+
+	work := mustCreateCategory(t, "Work", nil)
+	life := mustCreateCategory(t, "Life", nil)
+	coding := mustCreateCategory(t, "Coding", work.ID)
+	review := mustCreateCategory(t, "Review", coding.ID)
+	if err := db.Delete(&gen.Category{}, *coding.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	deletedPathOf := func(id int32) string {
+		var category gen.Category
+		if err := db.Unscoped().First(&category, id).Error; err != nil {
+			t.Fatalf("Failed to load category %d: %v", id, err)
+		}
+		return model.GetFullPath(&category)
+	}
+
+	if err := service.UpdateCategory(&gen.Category{ID: work.ID, Name: "Job"}); err != nil {
+		t.Fatalf("UpdateCategory failed: %v", err)
+	}
+	if got := deletedPathOf(*coding.ID); got != "/Job/Coding" {
+		t.Errorf("Expected the soft-deleted child at /Job/Coding after rename, got %s", got)
+	}
+	if got := fullPathOf(t, *review.ID); got != "/Job/Coding/Review" {
+		t.Errorf("Expected /Job/Coding/Review below the soft-deleted child, got %s", got)
+	}
+
+	if err := service.MoveCategory(*work.ID, life.ID); err != nil {
+		t.Fatalf("MoveCategory failed: %v", err)
+	}
+	if got := deletedPathOf(*coding.ID); got != "/Life/Job/Coding" {
+		t.Errorf("Expected the soft-deleted child at /Life/Job/Coding after move, got %s", got)
+	}
+}
+
+func TestMoveCategoryLeavesPrefixSiblingsAlone(t *testing.T) {
+	resetCategoryData(t)
+
+	// "Work" 是 "Workout" 的路径前缀，移动 Work 不能改到 Workout 的子分类
+	work := mustCreateCategory(t, "Work", nil)
+	coding := mustCreateCategory(t, "Coding", work.ID)
+	workout := mustCreateCategory(t, "Workout", nil)
+	running := mustCreateCategory(t, "Running", workout.ID)
+	life := mustCreateCategory(t, "Life", nil)
+
+	if err := service.MoveCategory(*work.ID, life.ID); err != nil {
+		t.Fatalf("MoveCategory failed: %v", err)
+	}
+
+	if got := fullPathOf(t, *coding.ID); got != "/Life/Work/Coding" {
+		t.Errorf("Expected /Life/Work/Coding, got %s", got)
+	}
+	moved, _ := service.GetCategoryByID(*coding.ID)
+	if *moved.Level != 2 {
+		t.Errorf("Expected moved child at level 2, got %d", *moved.Level)
+	}
+	if got := fullPathOf(t, *running.ID); got != "/Workout/Running" {
+		t.Errorf("Sibling with shared prefix was rewritten: %s", got)
+	}
+
+	// 子树会超出最大层级时拒绝移动
+	if err := service.MoveCategory(*life.ID, workout.ID); err == nil {
+		t.Error("Expected move to fail when the subtree would exceed max level")
+	}
+}
+
+func TestRepairCategoryHierarchy(t *testing.T) {
+	resetCategoryData(t)
+	db := model.GetDao().Db()
+
+	work := mustCreateCategory(t, "Work", nil)
+	coding := mustCreateCategory(t, "Coding", work.ID)
+	old := mustCreateCategory(t, "Old", nil)
+	orphan := mustCreateCategory(t, "Orphan", old.ID)
+	dropped := mustCreateCategory(t, "Dropped", work.ID)
+
+	// 人为制造不一致：过期的 path，父分类已被软删除的子分类，以及 path 过期的已删除分类
+	for _, id := range []int32{*coding.ID, *dropped.ID} {
+		if err := db.Model(&gen.Category{}).Where("id = ?", id).Update("path", "/Stale").Error; err != nil {
+			t.Fatalf("Failed to corrupt path: %v", err)
+		}
+	}
+	for _, id := range []int32{*old.ID, *dropped.ID} {
+		if err := db.Delete(&gen.Category{}, id).Error; err != nil {
+			t.Fatalf("Failed to soft delete category: %v", err)
+		}
+	}
+
+	report, err := service.RepairCategoryHierarchy(true)
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if report.Checked != 5 || len(report.Repairs) != 3 {
+		t.Fatalf("Expected 3 repairs out of 5, got %+v", report)
+	}
+	if got := fullPathOf(t, *coding.ID); got != "/Stale/Coding" {
+		t.Errorf("Dry run must not write, got %s", got)
+	}
+
+	report, err = service.RepairCategoryHierarchy(false)
+	if err != nil {
+		t.Fatalf("Repair failed: %v", err)
+	}
+	reasons := map[int32]string{}
+	for _, r := range report.Repairs {
+		reasons[r.CategoryID] = r.Reason
+		if r.Deleted != (r.CategoryID == *dropped.ID) {
+			t.Errorf("Unexpected deleted flag on repair %+v", r)
+		}
+	}
+	if reasons[*coding.ID] != model.CategoryRepairStaleLevelPath || reasons[*orphan.ID] != model.CategoryRepairMissingParent ||
+		reasons[*dropped.ID] != model.CategoryRepairStaleLevelPath {
+		t.Errorf("Unexpected repair reasons: %v", reasons)
+	}
+	var repairedDropped gen.Category
+	if err := db.Unscoped().First(&repairedDropped, *dropped.ID).Error; err != nil || model.GetFullPath(&repairedDropped) != "/Work/Dropped" {
+		t.Errorf("Expected the soft-deleted category to be repaired too, got %+v (%v)", repairedDropped, err)
+	}
+	if got := fullPathOf(t, *coding.ID); got != "/Work/Coding" {
+		t.Errorf("Expected /Work/Coding after repair, got %s", got)
+	}
+	repaired, _ := service.GetCategoryByID(*orphan.ID)
+	if repaired.ParentID != nil || *repaired.Level != 0 || model.GetFullPath(repaired) != "/Orphan" {
+		t.Errorf("Orphan should be promoted to root, got parent=%v level=%d path=%s", repaired.ParentID, *repaired.Level, model.GetFullPath(repaired))
+	}
+
+	report, err = service.RepairCategoryHierarchy(false)
+	if err != nil || len(report.Repairs) != 0 {
+		t.Errorf("Expected a clean hierarchy after repair, got %+v, %v", report, err)
+	}
+}
@@ -38,7 +38,7 @@ func TestCategoryTreeStructure(t *testing.T) {
 	}
 
 	// Get the tree
-	tree, err := service.GetCategoryTree()
+	tree, err := service.GetCategoryTree(false)
 	if err != nil {
 		t.Fatalf("Failed to get category tree: %v", err)
 	}
@@ -123,7 +123,7 @@ func TestCategoryTreeMultipleRoots(t *testing.T) {
 	}
 
 	// Get the tree
-	tree, err := service.GetCategoryTree()
+	tree, err := service.GetCategoryTree(false)
 	if err != nil {
 		t.Fatalf("Failed to get category tree: %v", err)
 	}
@@ -0,0 +1,175 @@
+package integration_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/model/gen"
+	"github.com/blacksheepaul/timelog/service"
+)
+
+func seedExportData(t *testing.T) {
+	t.Helper()
+	resetCategoryData(t)
+
+	work := mustCreateCategory(t, "Work", nil)
+	coding := mustCreateCategory(t, "Coding", work.ID)
+
+	due := time.Date(2025, 3, 3, 10, 0, 0, 0, time.UTC)
+	task := &gen.Task{Title: "Refactor, parser", CategoryID: *coding.ID, DueDate: due, EstimatedMinutes: 60}
+	if err := service.CreateTask(task); err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	// 2025-03-03 01:00 UTC 为新加坡时间 09:00；第二条在范围之外
+	for _, start := range []time.Time{
+		time.Date(2025, 3, 3, 1, 0, 0, 0, time.UTC),
+		time.Date(2025, 3, 10, 1, 0, 0, 0, time.UTC),
+	} {
+		end := start.Add(90 * time.Minute)
+		remark := "line one\nline two"
+		tl := &gen.Timelog{StartTime: start, EndTime: &end, CategoryID: *coding.ID, TaskID: task.ID, Remark: &remark}
+		if err := service.CreateTimeLog(tl); err != nil {
+			t.Fatalf("Failed to create timelog: %v", err)
+		}
+	}
+}
+
+func runExport(t *testing.T, opts service.ExportOptions) string {
+	t.Helper()
+	if err := opts.Normalize(); err != nil {
+		t.Fatalf("Normalize failed: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := service.Export(&buf, opts); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	return buf.String()
+}
+
+func TestExportJSON(t *testing.T) {
+	seedExportData(t)
+
+	out := runExport(t, service.ExportOptions{Format: "json", From: "2025-03-01", To: "2025-03-07"})
+	var doc struct {
+		Timezone    string                     `json:"timezone"`
+		Categories  []service.ExportCategory   `json:"categories"`
+		Constraints []service.ExportConstraint `json:"constraints"`
+		Tasks       []service.ExportTask       `json:"tasks"`
+		TimeLogs    []service.ExportTimeLog    `json:"timelogs"`
+	}
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("Export is not valid JSON: %v\n%s", err, out)
+	}
+	if len(doc.Categories) != 2 || doc.Categories[1].Path != "/Work/Coding" {
+		t.Errorf("Unexpected categories: %+v", doc.Categories)
+	}
+	if len(doc.Tasks) != 1 || doc.Tasks[0].CategoryPath != "/Work/Coding" {
+		t.Errorf("Unexpected tasks: %+v", doc.Tasks)
+	}
+	if len(doc.TimeLogs) != 1 {
+		t.Fatalf("Expected one timelog in range, got %d", len(doc.TimeLogs))
+	}
+	tl := doc.TimeLogs[0]
+	if tl.CategoryPath != "/Work/Coding" || tl.TaskTitle == nil || *tl.TaskTitle != "Refactor, parser" {
+		t.Errorf("Category path or task title not resolved: %+v", tl)
+	}
+	if tl.DurationMinutes == nil || *tl.DurationMinutes != 90 {
+		t.Errorf("Unexpected duration: %v", tl.DurationMinutes)
+	}
+	if !strings.Contains(out, `"start_time":"2025-03-03T09:00:00+08:00"`) {
+		t.Errorf("Expected timestamps in the configured time zone (%s), got %s", doc.Timezone, out)
+	}
+}
+
+func TestExportNDJSONAndCSV(t *testing.T) {
+	seedExportData(t)
+
+	out := runExport(t, service.ExportOptions{Format: "ndjson", Types: []string{"timelogs", "tasks"}})
+	counts := map[string]int{}
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		var line struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("Invalid NDJSON line %q: %v", scanner.Text(), err)
+		}
+		counts[line.Type]++
+	}
+	if counts["meta"] != 1 || counts["task"] != 1 || counts["timelog"] != 2 || counts["category"] != 0 {
+		t.Errorf("Unexpected NDJSON record counts: %v", counts)
+	}
+
+	out = runExport(t, service.ExportOptions{Format: "csv"})
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("Invalid CSV: %v", err)
+	}
+	if len(records) != 3 || records[0][0] != "id" || records[0][5] != "category_path" {
+		t.Fatalf("Unexpected CSV: %v", records)
+	}
+	if records[1][1] != "2025-03-03T09:00:00+08:00" || records[1][7] != "Refactor, parser" || records[1][8] != "line one\nline two" {
+		t.Errorf("Unexpected CSV row: %v", records[1])
+	}
+}
+
+func TestExportRejectsInvalidQuery(t *testing.T) {
+	invalid := []service.ExportOptions{
+		{Format: "xml"},
+		{Format: "csv", Types: []string{"timelogs", "tasks"}},
+		{Format: "json", Types: []string{"users"}},
+		{Format: "json", From: "2025-03-08", To: "2025-03-01"},
+		{Format: "json", From: "03/01/2025"},
+	}
+	for _, opts := range invalid {
+		if err := opts.Normalize(); !errors.Is(err, service.ErrInvalidExportQuery) {
+			t.Errorf("Expected ErrInvalidExportQuery for %+v, got %v", opts, err)
+		}
+	}
+}
+
+func TestEachTimeLogReadsInBatchesWithoutBlockingWrites(t *testing.T) {
+	resetCategoryData(t)
+	db := model.GetDao().Db()
+	work := mustCreateCategory(t, "Work", nil)
+
+	// 超过两批的记录，每两条开始时间相同，检验按 (start_time, id) 翻页时不重复也不遗漏
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	logs := make([]gen.Timelog, 1100)
+	for i := range logs {
+		start := base.Add(time.Duration(i/2) * time.Hour)
+		logs[i] = gen.Timelog{StartTime: start, EndTime: ptrTime(start.Add(time.Minute)), CategoryID: *work.ID}
+	}
+	if err := db.CreateInBatches(logs, 200).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[int32]bool, len(logs))
+	var last *gen.Timelog
+	err := model.EachTimeLog(db, nil, nil, func(tl *gen.Timelog) error {
+		if seen[*tl.ID] {
+			t.Fatalf("Time log #%d was read twice", *tl.ID)
+		}
+		seen[*tl.ID] = true
+		if last != nil && (tl.StartTime.Before(last.StartTime) || tl.StartTime.Equal(last.StartTime) && *tl.ID < *last.ID) {
+			t.Fatalf("Time logs out of order: #%d after #%d", *tl.ID, *last.ID)
+		}
+		last = tl
+		// 回调期间不应持有读游标，写入不能被阻塞
+		return db.Model(&gen.Timelog{}).Where("id = ?", *tl.ID).Update("remark", "exported").Error
+	})
+	if err != nil {
+		t.Fatalf("EachTimeLog failed: %v", err)
+	}
+	if len(seen) != len(logs) {
+		t.Errorf("Expected %d time logs, read %d", len(logs), len(seen))
+	}
+}
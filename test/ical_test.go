@@ -0,0 +1,121 @@
+package integration_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/model/gen"
+	"github.com/blacksheepaul/timelog/service"
+)
+
+// unfoldICal 还原折行并按行拆分
+func unfoldICal(data string) []string {
+	return strings.Split(strings.TrimSuffix(strings.ReplaceAll(data, "\r\n ", ""), "\r\n"), "\r\n")
+}
+
+func icalHasLine(lines []string, want string) bool {
+	for _, line := range lines {
+		if line == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestICalTimeLogsFeed(t *testing.T) {
+	resetCategoryData(t)
+	work := mustCreateCategory(t, "Work", nil)
+	coding := mustCreateCategory(t, "Coding", work.ID)
+	task := &gen.Task{Title: "Parser", CategoryID: *coding.ID, DueDate: time.Now().AddDate(0, 0, 7), EstimatedMinutes: 60}
+	if err := service.CreateTask(task); err != nil {
+		t.Fatal(err)
+	}
+
+	recent := time.Now().UTC().Truncate(time.Hour).AddDate(0, 0, -2)
+	old := time.Date(2020, 5, 1, 1, 0, 0, 0, time.UTC)
+	remark := "Tokenizer, lexer; tests\nsecond line"
+	timelogs := []*gen.Timelog{
+		{StartTime: recent, EndTime: ptrTime(recent.Add(90 * time.Minute)), CategoryID: *coding.ID, TaskID: task.ID, Remark: &remark},
+		{StartTime: old, EndTime: ptrTime(old.Add(time.Hour)), CategoryID: *work.ID},
+		{StartTime: time.Now().Add(-time.Hour), CategoryID: *work.ID},
+	}
+	for _, tl := range timelogs {
+		if err := service.CreateTimeLog(tl); err != nil {
+			t.Fatalf("Failed to create timelog: %v", err)
+		}
+	}
+
+	feed := &model.CalendarFeed{Name: "Tracked, time", Kind: service.CalendarFeedTimeLogs}
+	var buf bytes.Buffer
+	if err := service.WriteTimeLogsICal(&buf, feed, service.DefaultICalTimeLogDays); err != nil {
+		t.Fatalf("WriteTimeLogsICal failed: %v", err)
+	}
+	lines := unfoldICal(buf.String())
+	if lines[0] != "BEGIN:VCALENDAR" || lines[len(lines)-1] != "END:VCALENDAR" || !icalHasLine(lines, `X-WR-CALNAME:Tracked\, time`) {
+		t.Fatalf("Unexpected calendar envelope:\n%s", buf.String())
+	}
+	if n := strings.Count(buf.String(), "BEGIN:VEVENT"); n != 1 {
+		t.Fatalf("Expected only the recent finished timelog, got %d events:\n%s", n, buf.String())
+	}
+	for _, want := range []string{
+		"DTSTART:" + recent.Format("20060102T150405Z"),
+		"DTEND:" + recent.Add(90*time.Minute).Format("20060102T150405Z"),
+		"SUMMARY:Parser",
+		"CATEGORIES:/Work/Coding",
+		`DESCRIPTION:Tokenizer\, lexer\; tests\nsecond line`,
+	} {
+		if !icalHasLine(lines, want) {
+			t.Errorf("Missing %q in:\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if err := service.WriteTimeLogsICal(&buf, feed, 0); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(buf.String(), "BEGIN:VEVENT"); n != 2 || !strings.Contains(buf.String(), "SUMMARY:/Work\r\n") {
+		t.Errorf("Expected the whole history without the running timer, titled by category when there is no task or remark:\n%s", buf.String())
+	}
+}
+
+func TestICalTasksFeed(t *testing.T) {
+	resetCategoryData(t)
+	db := model.GetDao().Db()
+	work := mustCreateCategory(t, "Work", nil)
+	due := time.Date(2025, 3, 3, 10, 0, 0, 0, time.UTC)
+	description := "Draft the outline"
+	open := &gen.Task{Title: "Write report", Description: &description, CategoryID: *work.ID, DueDate: due, EstimatedMinutes: 90}
+	done := &gen.Task{Title: "Send invoice", CategoryID: *work.ID, DueDate: due.AddDate(0, 0, 1), EstimatedMinutes: 15}
+	for _, task := range []*gen.Task{open, done} {
+		if err := service.CreateTask(task); err != nil {
+			t.Fatal(err)
+		}
+	}
+	completedAt := due.Add(-time.Hour)
+	if err := db.Model(&gen.Task{}).Where("id = ?", *done.ID).Updates(map[string]interface{}{"is_completed": true, "completed_at": completedAt}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := service.WriteTasksICal(&buf, &model.CalendarFeed{Name: "Tasks", Kind: service.CalendarFeedTasks}); err != nil {
+		t.Fatalf("WriteTasksICal failed: %v", err)
+	}
+	data := buf.String()
+	if n := strings.Count(data, "BEGIN:VTODO"); n != 2 {
+		t.Fatalf("Expected 2 todos, got %d:\n%s", n, data)
+	}
+	first, second, _ := strings.Cut(data, "END:VTODO")
+	for _, want := range []string{"SUMMARY:Write report", "DUE:20250303T100000Z", "CATEGORIES:/Work", "DESCRIPTION:Draft the outline", "STATUS:NEEDS-ACTION"} {
+		if !strings.Contains(first, want+"\r\n") {
+			t.Errorf("Missing %q in the first todo:\n%s", want, first)
+		}
+	}
+	for _, want := range []string{"SUMMARY:Send invoice", "STATUS:COMPLETED", "COMPLETED:20250303T090000Z"} {
+		if !strings.Contains(second, want+"\r\n") {
+			t.Errorf("Missing %q in the second todo:\n%s", want, second)
+		}
+	}
+}
@@ -12,8 +12,6 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/sqlite3"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 )
 
 func TestMain(m *testing.M) {
@@ -31,19 +29,17 @@ func TestMain(m *testing.M) {
 
 func flushDb() {
 	dao := model.GetDao()
-	driver, err := sqlite3.WithInstance(dao.RawDB, &sqlite3.Config{})
+	m, err := model.NewMigrate(dao.RawDB)
 	if err != nil {
 		panic(err)
 	}
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://../model/migrations",
-		"sqlite3", driver)
-	if err != nil {
-		panic(err)
-	}
-	if err := m.Down(); err != nil && err != migrate.ErrNoChange {
+	if err := m.Drop(); err != nil {
 		panic(fmt.Errorf("Failed to drop tables: %v", err))
 	}
+	// Drop 会删除版本表，需要重新创建 migrate 实例
+	if m, err = model.NewMigrate(dao.RawDB); err != nil {
+		panic(err)
+	}
 	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
 		panic(fmt.Errorf("Failed to apply migrations: %v", err))
 	}
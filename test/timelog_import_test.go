@@ -0,0 +1,209 @@
+package integration_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/model/gen"
+	"github.com/blacksheepaul/timelog/service"
+)
+
+const togglHeader = "User,Email,Client,Project,Task,Description,Billable,Start date,Start time,End date,End time,Duration,Tags,Amount (USD)\n"
+
+func togglRow(client, project, task, description, date, start, end, tags string) string {
+	return strings.Join([]string{"Ann", "ann@example.com", client, project, task, description, "No", date, start, date, end, "", tags, ""}, ",") + "\n"
+}
+
+func findImportMapping(report *service.TimeLogImportReport, path string) *service.ImportCategoryMapping {
+	for i := range report.Categories {
+		if report.Categories[i].Path == path {
+			return &report.Categories[i]
+		}
+	}
+	return nil
+}
+
+func TestImportTogglPreviewThenCommit(t *testing.T) {
+	resetCategoryData(t)
+	db := model.GetDao().Db()
+	work := mustCreateCategory(t, "Work", nil)
+	task := &gen.Task{Title: "Landing page", CategoryID: *work.ID, DueDate: time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC), EstimatedMinutes: 120}
+	if err := service.CreateTask(task); err != nil {
+		t.Fatal(err)
+	}
+
+	csv := togglHeader +
+		togglRow("Work", "Website", "landing page", "Hero copy", "2024-01-15", "09:00:00", "10:30:00", "") +
+		togglRow("", "", "", "Inbox zero", "2024-01-15", "11:00:00", "11:20:00", "") +
+		togglRow("Work", "Website", "", "Polish", "2024-01-15", "13:00:00", "14:00:00", "\"design, review\"")
+
+	report, err := service.ImportTimeLogs(strings.NewReader(csv), service.TimeLogImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Preview failed: %v", err)
+	}
+	if report.Source != service.ImportSourceToggl || report.Imported != 3 || report.Invalid != 0 {
+		t.Fatalf("Unexpected preview: %+v", report)
+	}
+	if m := findImportMapping(report, "/Work"); m == nil || m.Created || m.CategoryID == nil || *m.CategoryID != *work.ID {
+		t.Errorf("Work should map to the existing category: %+v", m)
+	}
+	if m := findImportMapping(report, "/Work/Website/design"); m == nil || !m.Created || m.CategoryID != nil {
+		t.Errorf("Expected a new Work/Website/design category in the preview: %+v", report.Categories)
+	}
+	if m := findImportMapping(report, "/Imported"); m == nil || m.Entries != 1 {
+		t.Errorf("Entries without client, project or tags should go to /Imported: %+v", report.Categories)
+	}
+	first := report.Entries[0]
+	if first.TaskID == nil || *first.TaskID != *task.ID || first.Remark == nil || *first.Remark != "Hero copy" {
+		t.Errorf("Expected the first entry linked to the task with the description as remark: %+v", first)
+	}
+	if !strings.Contains(report.Entries[2].Message, "review") {
+		t.Errorf("Expected a warning about the tag beyond the max depth, got %q", report.Entries[2].Message)
+	}
+	if n := countRows(t, "timelogs"); n != 0 {
+		t.Fatalf("Preview must not write, found %d timelogs", n)
+	}
+	if _, err := model.GetCategoryByName(db, "Website", work.ID); err == nil {
+		t.Fatalf("Preview must not create categories")
+	}
+
+	report, err = service.ImportTimeLogs(strings.NewReader(csv), service.TimeLogImportOptions{})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if report.Imported != 3 || countRows(t, "timelogs") != 3 {
+		t.Fatalf("Expected 3 imported timelogs: %+v", report)
+	}
+	website, err := model.GetCategoryByName(db, "Website", work.ID)
+	if err != nil {
+		t.Fatalf("Website category not created: %v", err)
+	}
+	var tl gen.Timelog
+	if err := db.Where("remark = ?", "Hero copy").First(&tl).Error; err != nil {
+		t.Fatal(err)
+	}
+	// 2024-01-15 09:00 新加坡时间
+	if tl.CategoryID != *website.ID || !tl.StartTime.Equal(time.Date(2024, 1, 15, 1, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected imported timelog: %+v", tl)
+	}
+
+	report, err = service.ImportTimeLogs(strings.NewReader(csv), service.TimeLogImportOptions{})
+	if err != nil {
+		t.Fatalf("Re-import failed: %v", err)
+	}
+	if report.Duplicates != 3 || report.Imported != 0 || countRows(t, "timelogs") != 3 {
+		t.Errorf("Re-importing the same file should only find duplicates: %+v", report)
+	}
+}
+
+func TestImportRollsBackOnInvalidRows(t *testing.T) {
+	resetCategoryData(t)
+	work := mustCreateCategory(t, "Work", nil)
+	start := time.Date(2024, 1, 15, 2, 0, 0, 0, time.UTC) // 新加坡时间 10:00
+	existing := &gen.Timelog{StartTime: start, EndTime: ptrTime(start.Add(time.Hour)), CategoryID: *work.ID}
+	if err := service.CreateTimeLog(existing); err != nil {
+		t.Fatal(err)
+	}
+
+	csv := togglHeader +
+		togglRow("Work", "", "", "Fine", "2024-01-15", "08:00:00", "09:00:00", "") +
+		togglRow("Work", "", "", "Overlaps", "2024-01-15", "10:30:00", "11:30:00", "") +
+		togglRow("Work", "", "", "Backwards", "2024-01-15", "15:00:00", "14:00:00", "")
+
+	report, err := service.ImportTimeLogs(strings.NewReader(csv), service.TimeLogImportOptions{})
+	if !errors.Is(err, service.ErrImportRowsInvalid) {
+		t.Fatalf("Expected ErrImportRowsInvalid, got %v", err)
+	}
+	if report == nil || report.Invalid != 2 || report.Overlapping != 1 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+	overlap := report.Entries[1]
+	if overlap.Status != service.ImportStatusInvalid || len(overlap.Overlaps) != 1 || overlap.Overlaps[0].TimeLogID == nil || *overlap.Overlaps[0].TimeLogID != *existing.ID {
+		t.Errorf("Expected the overlap with #%d to be reported: %+v", *existing.ID, overlap)
+	}
+	if n := countRows(t, "timelogs"); n != 1 {
+		t.Errorf("Nothing should be imported when rows are invalid, found %d timelogs", n)
+	}
+}
+
+func TestImportRestoresSoftDeletedCategory(t *testing.T) {
+	resetCategoryData(t)
+	db := model.GetDao().Db()
+	work := mustCreateCategory(t, "Work", nil)
+	website := mustCreateCategory(t, "Website", work.ID)
+	if err := db.Delete(&gen.Category{}, *website.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	csv := togglHeader + togglRow("Work", "Website", "", "Hero copy", "2024-01-15", "09:00:00", "10:30:00", "")
+
+	report, err := service.ImportTimeLogs(strings.NewReader(csv), service.TimeLogImportOptions{DryRun: true})
+	if err != nil || report.Imported != 1 {
+		t.Fatalf("Preview failed: %+v (%v)", report, err)
+	}
+	if m := findImportMapping(report, "/Work/Website"); m == nil || m.Created || !m.Restored || m.CategoryID == nil || *m.CategoryID != *website.ID {
+		t.Errorf("Expected the preview to restore the soft-deleted Work/Website: %+v", m)
+	}
+	if _, err := service.GetCategoryByID(*website.ID); !errors.Is(err, model.ErrRecordNotFound) {
+		t.Errorf("The preview must not restore the category, got %v", err)
+	}
+
+	report, err = service.ImportTimeLogs(strings.NewReader(csv), service.TimeLogImportOptions{})
+	if err != nil || report.Imported != 1 {
+		t.Fatalf("Import failed: %+v (%v)", report, err)
+	}
+	if _, err := service.GetCategoryByID(*website.ID); err != nil {
+		t.Errorf("Expected Work/Website to be restored: %v", err)
+	}
+	var imported gen.Timelog
+	if err := db.Where("remark = ?", "Hero copy").First(&imported).Error; err != nil || imported.CategoryID != *website.ID {
+		t.Errorf("Expected the time log in the restored category, got %+v (%v)", imported, err)
+	}
+}
+
+func TestImportTimewarriorSkipsDuplicatesAndReportsOverlaps(t *testing.T) {
+	resetCategoryData(t)
+	work := mustCreateCategory(t, "Work", nil)
+	start := time.Date(2024, 1, 15, 1, 0, 0, 0, time.UTC)
+	existing := &gen.Timelog{StartTime: start, EndTime: ptrTime(start.Add(time.Hour)), CategoryID: *work.ID}
+	if err := service.CreateTimeLog(existing); err != nil {
+		t.Fatal(err)
+	}
+
+	duplicate := `{"start":"20240115T010000Z","end":"20240115T020000Z","tags":["Work"]}`
+	fresh := `{"start":"20240115T030000Z","end":"20240115T040000Z","tags":["Work","Deep"],"annotation":"Focus"}`
+	export := "[" + strings.Join([]string{
+		duplicate,
+		`{"start":"20240115T013000Z","end":"20240115T023000Z","tags":["Work"]}`,
+		fresh,
+		`{"start":"20240115T033000Z","end":"20240115T043000Z","tags":["Life"]}`,
+	}, ",\n") + "]"
+
+	report, err := service.ImportTimeLogs(strings.NewReader(export), service.TimeLogImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Preview failed: %v", err)
+	}
+	if report.Source != service.ImportSourceTimewarrior || report.Duplicates != 1 || report.Imported != 1 || report.Invalid != 2 || report.Overlapping != 2 {
+		t.Fatalf("Unexpected preview: %+v", report)
+	}
+	if e := report.Entries[1]; len(e.Overlaps) != 1 || e.Overlaps[0].TimeLogID == nil || *e.Overlaps[0].TimeLogID != *existing.ID {
+		t.Errorf("Expected an overlap with existing #%d: %+v", *existing.ID, e)
+	}
+	if e := report.Entries[3]; len(e.Overlaps) != 1 || e.Overlaps[0].Line != 3 {
+		t.Errorf("Expected an overlap with entry 3 of the same file: %+v", e)
+	}
+	if m := findImportMapping(report, "/Work/Deep"); m == nil || !m.Created {
+		t.Errorf("Expected tags to map onto /Work/Deep: %+v", report.Categories)
+	}
+
+	report, err = service.ImportTimeLogs(strings.NewReader("["+duplicate+","+fresh+"]"), service.TimeLogImportOptions{})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if report.Imported != 1 || report.Duplicates != 1 || countRows(t, "timelogs") != 2 {
+		t.Errorf("Expected one new and one duplicate interval: %+v", report)
+	}
+}
@@ -0,0 +1,122 @@
+package integration_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/blacksheepaul/timelog/model"
+	"github.com/blacksheepaul/timelog/model/gen"
+	"github.com/blacksheepaul/timelog/service"
+)
+
+func countOpenTimeLogs(t *testing.T) int {
+	t.Helper()
+	open, err := model.ListOpenTimeLogs(model.GetDao().Db())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(open)
+}
+
+func TestTimerAllowsOnlyOneRunningTimer(t *testing.T) {
+	resetCategoryData(t)
+	work := mustCreateCategory(t, "Work", nil)
+
+	running, err := service.StartTimer(service.TimerRequest{CategoryID: *work.ID})
+	if err != nil {
+		t.Fatalf("Failed to start timer: %v", err)
+	}
+	if running.Timelog.EndTime != nil {
+		t.Fatalf("Expected an open time log, got %+v", running.Timelog)
+	}
+
+	if _, err := service.StartTimer(service.TimerRequest{CategoryID: *work.ID}); !errors.Is(err, service.ErrTimerAlreadyRunning) {
+		t.Errorf("Expected ErrTimerAlreadyRunning, got %v", err)
+	}
+	open := &gen.Timelog{StartTime: time.Now().Add(-time.Hour), CategoryID: *work.ID}
+	if err := service.CreateTimeLog(open); !errors.Is(err, service.ErrTimerAlreadyRunning) {
+		t.Errorf("Creating a second open time log should be rejected, got %v", err)
+	}
+	if n := countOpenTimeLogs(t); n != 1 {
+		t.Fatalf("Expected exactly one open time log, found %d", n)
+	}
+
+	stopped, err := service.StopTimer()
+	if err != nil || len(stopped) != 1 || stopped[0].EndTime == nil || *stopped[0].ID != *running.Timelog.ID {
+		t.Fatalf("Expected the running timer to be stopped, got %+v (%v)", stopped, err)
+	}
+	if _, err := service.StopTimer(); !errors.Is(err, service.ErrNoRunningTimer) {
+		t.Errorf("Expected ErrNoRunningTimer when nothing is running, got %v", err)
+	}
+	if running, err := service.GetRunningTimer(); err != nil || running != nil {
+		t.Errorf("Expected no running timer, got %+v (%v)", running, err)
+	}
+}
+
+func TestTimerSwitchIsAtomic(t *testing.T) {
+	resetCategoryData(t)
+	work := mustCreateCategory(t, "Work", nil)
+	life := mustCreateCategory(t, "Life", nil)
+
+	// 先创建一条半小时前开始的计时，避免与切换在同一秒开始
+	current := &gen.Timelog{StartTime: time.Now().Add(-30 * time.Minute), CategoryID: *work.ID}
+	if err := service.CreateTimeLog(current); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := service.SwitchTimer(service.TimerRequest{CategoryID: *life.ID})
+	if err != nil {
+		t.Fatalf("Failed to switch timer: %v", err)
+	}
+	if len(result.Stopped) != 1 || *result.Stopped[0].ID != *current.ID || result.Stopped[0].EndTime == nil {
+		t.Fatalf("Expected the previous timer to be stopped: %+v", result.Stopped)
+	}
+	next := result.Running.Timelog
+	if next.CategoryID != *life.ID || next.EndTime != nil {
+		t.Fatalf("Expected a new open time log in Life: %+v", next)
+	}
+	if !result.Stopped[0].EndTime.Equal(next.StartTime) {
+		t.Errorf("The old log should end at the second the new one starts: %v vs %v", result.Stopped[0].EndTime, next.StartTime)
+	}
+	stored, err := service.GetTimeLogByID(*current.ID)
+	if err != nil || stored.EndTime == nil || !stored.EndTime.Equal(next.StartTime) {
+		t.Errorf("Expected the stored end time to match the switch, got %+v (%v)", stored, err)
+	}
+
+	// 切换到不存在的分类时整个切换回滚，当前计时保持运行
+	if _, err := service.SwitchTimer(service.TimerRequest{CategoryID: *life.ID + 1000}); err == nil {
+		t.Fatal("Expected switching to a missing category to fail")
+	}
+	running, err := service.GetRunningTimer()
+	if err != nil || running == nil || *running.Timelog.ID != *next.ID {
+		t.Errorf("A failed switch must leave the running timer untouched, got %+v (%v)", running, err)
+	}
+	if n := countOpenTimeLogs(t); n != 1 {
+		t.Errorf("Expected exactly one open time log, found %d", n)
+	}
+}
+
+func TestTimerStartRejectsOverlapWithClosedLog(t *testing.T) {
+	resetCategoryData(t)
+	work := mustCreateCategory(t, "Work", nil)
+
+	// 一条结束时间在未来的已结束日志，与现在开始的计时重叠
+	now := time.Now()
+	planned := &gen.Timelog{StartTime: now.Add(-10 * time.Minute), EndTime: ptrTime(now.Add(30 * time.Minute)), CategoryID: *work.ID}
+	if err := service.CreateTimeLog(planned); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := service.StartTimer(service.TimerRequest{CategoryID: *work.ID})
+	var overlapErr *service.OverlapError
+	if !errors.As(err, &overlapErr) || len(overlapErr.Conflicts) != 1 || *overlapErr.Conflicts[0].ID != *planned.ID {
+		t.Fatalf("Expected an overlap with #%d under the reject policy, got %v", *planned.ID, err)
+	}
+	if n := countOpenTimeLogs(t); n != 0 {
+		t.Errorf("A rejected start must not leave an open time log, found %d", n)
+	}
+	if n := countRows(t, "timelogs"); n != 1 {
+		t.Errorf("Expected only the closed log, found %d time logs", n)
+	}
+}
@@ -59,6 +59,9 @@ export interface Task {
   is_suspended: boolean
   created_at: string
   updated_at: string
+  actual_minutes?: number
+  variance_minutes?: number | null
+  variance_percent?: number | null
 }
 
 export interface CreateTaskRequest {